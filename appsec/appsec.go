@@ -18,8 +18,10 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/customsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/trace"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
 
@@ -41,6 +43,31 @@ func MonitorParsedHTTPBody(ctx context.Context, body interface{}) error {
 	return httpsec.MonitorParsedBody(ctx, body)
 }
 
+// Monitor runs the security monitoring rules on the given addresses, which map
+// WAF addresses to their values, such as the fields of a message received by a
+// queue consumer, and returns an error if they are suspicious and configured to
+// be blocked. It allows to protect application data which isn't received through
+// an instrumented HTTP, gRPC or GraphQL server. When the given context is the one
+// of a monitored request, the addresses are monitored along with the request.
+// Otherwise, the security events are set on the service entry span found in the
+// given context, if any. The returned error is a *events.BlockingSecurityEvent,
+// in which case the caller must immediately abort its processing of the data.
+// This function always returns nil when appsec is disabled.
+func Monitor(ctx context.Context, addresses map[string]any) error {
+	if !appsec.Enabled() {
+		appsecDisabledLog.Do(func() { log.Warn("appsec: not enabled. Custom data blocking checks won't be performed.") })
+		return nil
+	}
+	var span trace.TagSetter = trace.NoopTagSetter{}
+	if s, ok := tracer.SpanFromContext(ctx); ok {
+		span = s
+		if rs, ok := s.(interface{ Root() tracer.Span }); ok {
+			span = rs.Root()
+		}
+	}
+	return customsec.Monitor(ctx, span, addresses)
+}
+
 // SetUser wraps tracer.SetUser() and extends it with user blocking.
 // On top of associating the authenticated user information to the service entry span,
 // it checks whether the given user ID is blocked or not by returning an error when it is.
@@ -167,6 +167,24 @@ func TestSetUser(t *testing.T) {
 	})
 }
 
+func TestMonitor(t *testing.T) {
+	t.Run("early-return/appsec-disabled", func(t *testing.T) {
+		err := appsec.Monitor(context.Background(), map[string]any{"custom.message": "attack"})
+		require.NoError(t, err)
+	})
+
+	privateAppsec.Start()
+	defer privateAppsec.Stop()
+	if !privateAppsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+
+	t.Run("no-span", func(t *testing.T) {
+		err := appsec.Monitor(context.Background(), map[string]any{"custom.message": "hello"})
+		require.NoError(t, err)
+	})
+}
+
 func ExampleTrackUserLoginSuccessEvent() {
 	// Create an example span and set a user login success appsec event example to it.
 	span, ctx := tracer.StartSpanFromContext(context.Background(), "example")
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+// Package events provides the types of the security events which can be
+// returned by the functions instrumented by AppSec.
+package events
+
+// BlockingSecurityEvent is the error returned by an instrumented call, such as
+// an outbound HTTP request or a SQL query, which was aborted because AppSec
+// detected it as an exploit attempt and blocked it. The request being served is
+// also blocked, so that the error should be returned up to the request handler
+// without being retried. It can be detected using errors.As.
+type BlockingSecurityEvent struct{}
+
+// Error implements the error interface.
+func (*BlockingSecurityEvent) Error() string {
+	return "request blocked by the WAF"
+}
@@ -6,6 +6,7 @@
 package appsec_test
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/appsec"
 	echotrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/labstack/echo.v4"
 	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
 	"github.com/labstack/echo/v4"
 )
@@ -79,3 +81,19 @@ func ExampleSetUser() {
 		w.Write([]byte("User monitored using AppSec SetUser SDK\n"))
 	})
 }
+
+// Monitor and block the messages of a queue consumer
+func ExampleMonitor() {
+	messages := make(chan parsedBodyType)
+	for msg := range messages {
+		span, ctx := tracer.StartSpanFromContext(context.Background(), "queue.consume")
+		// We use Monitor() here to run the security rules on the message fields. The return value
+		// can then be checked to decide whether to drop the message or not.
+		if err := appsec.Monitor(ctx, map[string]any{"custom.message": map[string]any{"value": msg.Value}}); err != nil {
+			span.Finish()
+			continue
+		}
+		// Process the message
+		span.Finish()
+	}
+}
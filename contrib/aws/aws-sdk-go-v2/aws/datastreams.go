@@ -0,0 +1,275 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams/options"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go/middleware"
+)
+
+const (
+	// datadogKey is the message attribute, record field or event detail field
+	// holding the injected trace context and pathway.
+	datadogKey = "_datadog"
+	// maxMessageAttributes is the number of message attributes allowed by
+	// SQS and SNS. The Datadog attribute is not added to full messages.
+	maxMessageAttributes = 10
+	// defaultEventBus is the event bus used by EventBridge when none is given.
+	defaultEventBus = "default"
+)
+
+// injectDataStreams injects the context of span and a data streams pathway into
+// the messages, records or events sent by the given request, setting a
+// direction:out checkpoint for each of them.
+func injectDataStreams(ctx context.Context, span ddtrace.Span, in middleware.InitializeInput) {
+	switch params := in.Parameters.(type) {
+	case *sqs.SendMessageInput:
+		params.MessageAttributes = injectSQSAttributes(ctx, span, queueName(in), params.MessageBody, params.MessageAttributes)
+	case *sqs.SendMessageBatchInput:
+		queue := queueName(in)
+		for i := range params.Entries {
+			entry := &params.Entries[i]
+			entry.MessageAttributes = injectSQSAttributes(ctx, span, queue, entry.MessageBody, entry.MessageAttributes)
+		}
+	case *sqs.ReceiveMessageInput:
+		params.MessageAttributeNames = withDatadogAttribute(params.MessageAttributeNames)
+	case *sns.PublishInput:
+		_, topic := destinationTagValue(in)
+		params.MessageAttributes = injectSNSAttributes(ctx, span, topic, params.Message, params.MessageAttributes)
+	case *sns.PublishBatchInput:
+		_, topic := destinationTagValue(in)
+		for i := range params.PublishBatchRequestEntries {
+			entry := &params.PublishBatchRequestEntries[i]
+			entry.MessageAttributes = injectSNSAttributes(ctx, span, topic, entry.Message, entry.MessageAttributes)
+		}
+	case *kinesis.PutRecordInput:
+		params.Data = injectJSON(ctx, span, params.Data, "topic:"+streamName(in), "type:kinesis")
+	case *kinesis.PutRecordsInput:
+		stream := streamName(in)
+		for i := range params.Records {
+			params.Records[i].Data = injectJSON(ctx, span, params.Records[i].Data, "topic:"+stream, "type:kinesis")
+		}
+	case *eventbridge.PutEventsInput:
+		for i := range params.Entries {
+			entry := &params.Entries[i]
+			if entry.Detail == nil {
+				continue
+			}
+			bus := defaultEventBus
+			if entry.EventBusName != nil {
+				bus = *entry.EventBusName
+			}
+			entry.Detail = aws.String(string(injectJSON(ctx, span, []byte(*entry.Detail), "topic:"+bus, "type:bus")))
+		}
+	}
+}
+
+// extractDataStreams sets a direction:in checkpoint for each message or record
+// returned by a request, continuing the pathway injected by the producer.
+func extractDataStreams(ctx context.Context, in middleware.InitializeInput, result interface{}) {
+	switch res := result.(type) {
+	case *sqs.ReceiveMessageOutput:
+		queue := queueName(in)
+		for i := range res.Messages {
+			setSQSConsumeCheckpoint(ctx, queue, &res.Messages[i])
+		}
+	case *kinesis.GetRecordsOutput:
+		stream := streamName(in)
+		for _, r := range res.Records {
+			setJSONConsumeCheckpoint(ctx, r.Data, "topic:"+stream, "type:kinesis")
+		}
+	}
+}
+
+// newCarrier returns a carrier holding the context of span and the pathway
+// resulting from a checkpoint with the given edge tags.
+func newCarrier(ctx context.Context, span ddtrace.Span, payloadSize int, edgeTags ...string) tracer.TextMapCarrier {
+	carrier := tracer.TextMapCarrier{}
+	if err := tracer.Inject(span.Context(), carrier); err != nil {
+		log.Debug("contrib/aws/aws-sdk-go-v2/aws: failed to inject trace context: %v", err)
+	}
+	edges := append([]string{"direction:out"}, edgeTags...)
+	ctx, ok := tracer.SetDataStreamsCheckpointWithParams(ctx, options.CheckpointParams{PayloadSize: int64(payloadSize)}, edges...)
+	if ok {
+		datastreams.InjectToBase64Carrier(ctx, carrier)
+	}
+	return carrier
+}
+
+func injectSQSAttributes(ctx context.Context, span ddtrace.Span, queue string, body *string, attrs map[string]sqstypes.MessageAttributeValue) map[string]sqstypes.MessageAttributeValue {
+	if len(attrs) >= maxMessageAttributes {
+		log.Debug("contrib/aws/aws-sdk-go-v2/aws: message to queue %q already has %d attributes, not injecting context", queue, len(attrs))
+		return attrs
+	}
+	carrier := newCarrier(ctx, span, len(aws.ToString(body)), "topic:"+queue, "type:sqs")
+	data, err := json.Marshal(carrier)
+	if err != nil {
+		return attrs
+	}
+	if attrs == nil {
+		attrs = make(map[string]sqstypes.MessageAttributeValue, 1)
+	}
+	attrs[datadogKey] = sqstypes.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(string(data)),
+	}
+	return attrs
+}
+
+func injectSNSAttributes(ctx context.Context, span ddtrace.Span, topic string, msg *string, attrs map[string]snstypes.MessageAttributeValue) map[string]snstypes.MessageAttributeValue {
+	if len(attrs) >= maxMessageAttributes {
+		log.Debug("contrib/aws/aws-sdk-go-v2/aws: message to topic %q already has %d attributes, not injecting context", topic, len(attrs))
+		return attrs
+	}
+	carrier := newCarrier(ctx, span, len(aws.ToString(msg)), "topic:"+topic, "type:sns")
+	data, err := json.Marshal(carrier)
+	if err != nil {
+		return attrs
+	}
+	if attrs == nil {
+		attrs = make(map[string]snstypes.MessageAttributeValue, 1)
+	}
+	// SNS carriers are sent as Binary attributes, like the other Datadog
+	// tracers do, so that any instrumented subscriber can read them.
+	attrs[datadogKey] = snstypes.MessageAttributeValue{
+		DataType:    aws.String("Binary"),
+		BinaryValue: data,
+	}
+	return attrs
+}
+
+// injectJSON adds the carrier under datadogKey to data when it holds a JSON
+// object. Any other payload is returned unchanged.
+func injectJSON(ctx context.Context, span ddtrace.Span, data []byte, edgeTags ...string) []byte {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil || obj == nil {
+		return data
+	}
+	carrier := newCarrier(ctx, span, len(data), edgeTags...)
+	v, err := json.Marshal(carrier)
+	if err != nil {
+		return data
+	}
+	obj[datadogKey] = v
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// withDatadogAttribute makes sure the Datadog message attribute is requested
+// by a ReceiveMessage call.
+func withDatadogAttribute(names []string) []string {
+	for _, n := range names {
+		if n == datadogKey || n == "All" || n == ".*" {
+			return names
+		}
+	}
+	return append(names, datadogKey)
+}
+
+// sqsCarrier returns the carrier found in msg, either as a message attribute
+// or in the body of an SNS notification delivered to the queue.
+func sqsCarrier(msg *sqstypes.Message) (tracer.TextMapCarrier, bool) {
+	var data []byte
+	if attr, ok := msg.MessageAttributes[datadogKey]; ok {
+		if attr.StringValue != nil {
+			data = []byte(*attr.StringValue)
+		} else {
+			data = attr.BinaryValue
+		}
+	} else if msg.Body != nil {
+		var notification struct {
+			MessageAttributes map[string]struct {
+				Type  string
+				Value string
+			}
+		}
+		if err := json.Unmarshal([]byte(*msg.Body), &notification); err != nil {
+			return nil, false
+		}
+		attr, ok := notification.MessageAttributes[datadogKey]
+		if !ok {
+			return nil, false
+		}
+		data = []byte(attr.Value)
+		if attr.Type == "Binary" {
+			b, err := base64.StdEncoding.DecodeString(attr.Value)
+			if err != nil {
+				return nil, false
+			}
+			data = b
+		}
+	}
+	if data == nil {
+		return nil, false
+	}
+	var carrier tracer.TextMapCarrier
+	if err := json.Unmarshal(data, &carrier); err != nil {
+		return nil, false
+	}
+	return carrier, true
+}
+
+// setSQSConsumeCheckpoint sets a direction:in checkpoint for msg and stores the
+// resulting pathway in its Datadog attribute, so that it can be propagated
+// further by the application.
+func setSQSConsumeCheckpoint(ctx context.Context, queue string, msg *sqstypes.Message) {
+	carrier, ok := sqsCarrier(msg)
+	if !ok {
+		carrier = tracer.TextMapCarrier{}
+	}
+	ctx, ok = tracer.SetDataStreamsCheckpointWithParams(
+		datastreams.ExtractFromBase64Carrier(ctx, carrier),
+		options.CheckpointParams{PayloadSize: int64(len(aws.ToString(msg.Body)))},
+		"direction:in", "topic:"+queue, "type:sqs",
+	)
+	if !ok {
+		return
+	}
+	datastreams.InjectToBase64Carrier(ctx, carrier)
+	data, err := json.Marshal(carrier)
+	if err != nil {
+		return
+	}
+	if msg.MessageAttributes == nil {
+		msg.MessageAttributes = make(map[string]sqstypes.MessageAttributeValue, 1)
+	}
+	msg.MessageAttributes[datadogKey] = sqstypes.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(string(data)),
+	}
+}
+
+// setJSONConsumeCheckpoint sets a direction:in checkpoint for the JSON object
+// in data, continuing the pathway found under datadogKey, if any.
+func setJSONConsumeCheckpoint(ctx context.Context, data []byte, edgeTags ...string) {
+	var obj struct {
+		Datadog tracer.TextMapCarrier `json:"_datadog"`
+	}
+	if err := json.Unmarshal(data, &obj); err == nil && obj.Datadog != nil {
+		ctx = datastreams.ExtractFromBase64Carrier(ctx, obj.Datadog)
+	}
+	edges := append([]string{"direction:in"}, edgeTags...)
+	tracer.SetDataStreamsCheckpointWithParams(ctx, options.CheckpointParams{PayloadSize: int64(len(data))}, edges...)
+}
@@ -0,0 +1,197 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataStreamsActivation(t *testing.T) {
+	newConfig := func(opts ...Option) *config {
+		cfg := &config{}
+		defaults(cfg)
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		return cfg
+	}
+	t.Run("default", func(t *testing.T) {
+		assert.False(t, newConfig().dataStreamsEnabled)
+	})
+	t.Run("withOption", func(t *testing.T) {
+		assert.True(t, newConfig(WithDataStreams()).dataStreamsEnabled)
+	})
+	t.Run("withEnv", func(t *testing.T) {
+		t.Setenv("DD_DATA_STREAMS_ENABLED", "true")
+		assert.True(t, newConfig().dataStreamsEnabled)
+	})
+}
+
+func TestDataStreamsSQS(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	server := mockAWS(200)
+	defer server.Close()
+
+	resolver := aws.EndpointResolverFunc(func(service, region string) (aws.Endpoint, error) {
+		return aws.Endpoint{
+			PartitionID:   "aws",
+			URL:           server.URL,
+			SigningRegion: "eu-west-1",
+		}, nil
+	})
+	awsCfg := aws.Config{
+		Region:           "eu-west-1",
+		Credentials:      aws.AnonymousCredentials{},
+		EndpointResolver: resolver,
+	}
+	AppendMiddleware(&awsCfg, WithDataStreams())
+	sqsClient := sqs.NewFromConfig(awsCfg)
+
+	in := &sqs.SendMessageInput{
+		QueueUrl:    aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/MyQueueName"),
+		MessageBody: aws.String("body"),
+	}
+	sqsClient.SendMessage(context.Background(), in)
+
+	spans := mt.FinishedSpans()
+	require.Len(t, spans, 1)
+	attr, ok := in.MessageAttributes[datadogKey]
+	require.True(t, ok)
+	assert.Equal(t, "String", *attr.DataType)
+
+	// the trace context of the SendMessage span is injected
+	var carrier tracer.TextMapCarrier
+	require.NoError(t, json.Unmarshal([]byte(*attr.StringValue), &carrier))
+	sctx, err := tracer.Extract(carrier)
+	require.NoError(t, err)
+	assert.Equal(t, spans[0].TraceID(), sctx.TraceID())
+	assert.Equal(t, spans[0].SpanID(), sctx.SpanID())
+
+	// and so is the pathway
+	p, ok := datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), carrier))
+	require.True(t, ok)
+	expectedCtx, _ := tracer.SetDataStreamsCheckpoint(context.Background(), "direction:out", "topic:MyQueueName", "type:sqs")
+	expected, _ := datastreams.PathwayFromContext(expectedCtx)
+	assert.NotEqual(t, expected.GetHash(), 0)
+	assert.Equal(t, expected.GetHash(), p.GetHash())
+
+	// the consumer continues the pathway of the producer
+	msg := types.Message{Body: in.MessageBody, MessageAttributes: in.MessageAttributes}
+	setSQSConsumeCheckpoint(context.Background(), "MyQueueName", &msg)
+	require.NoError(t, json.Unmarshal([]byte(*msg.MessageAttributes[datadogKey].StringValue), &carrier))
+	p, ok = datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), carrier))
+	require.True(t, ok)
+	expectedCtx, _ = tracer.SetDataStreamsCheckpoint(expectedCtx, "direction:in", "topic:MyQueueName", "type:sqs")
+	expected, _ = datastreams.PathwayFromContext(expectedCtx)
+	assert.Equal(t, expected.GetHash(), p.GetHash())
+}
+
+func TestDataStreamsSQSLimit(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	attrs := make(map[string]types.MessageAttributeValue, maxMessageAttributes)
+	for i := 0; i < maxMessageAttributes; i++ {
+		attrs[string(rune('a'+i))] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String("v")}
+	}
+	span := tracer.StartSpan("test")
+	attrs = injectSQSAttributes(context.Background(), span, "queue", aws.String("body"), attrs)
+	assert.Len(t, attrs, maxMessageAttributes)
+	assert.NotContains(t, attrs, datadogKey)
+}
+
+func TestDataStreamsSQSReceiveInput(t *testing.T) {
+	in := &sqs.ReceiveMessageInput{QueueUrl: aws.String("https://sqs.us-west-2.amazonaws.com/123456789012/MyQueueName")}
+	injectDataStreams(context.Background(), nil, middleware.InitializeInput{Parameters: in})
+	assert.Equal(t, []string{datadogKey}, in.MessageAttributeNames)
+
+	in.MessageAttributeNames = []string{"All"}
+	injectDataStreams(context.Background(), nil, middleware.InitializeInput{Parameters: in})
+	assert.Equal(t, []string{"All"}, in.MessageAttributeNames)
+}
+
+func TestDataStreamsSNSToSQS(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	in := &sns.PublishInput{
+		TopicArn: aws.String("arn:aws:sns:us-west-2:123456789012:MyTopic"),
+		Message:  aws.String("message"),
+	}
+	injectDataStreams(context.Background(), span, middleware.InitializeInput{Parameters: in})
+	attr, ok := in.MessageAttributes[datadogKey]
+	require.True(t, ok)
+	assert.Equal(t, "Binary", *attr.DataType)
+
+	// an SNS notification delivered to SQS without raw message delivery
+	body, err := json.Marshal(map[string]interface{}{
+		"Type":    "Notification",
+		"Message": "message",
+		"MessageAttributes": map[string]interface{}{
+			datadogKey: map[string]string{
+				"Type":  "Binary",
+				"Value": base64.StdEncoding.EncodeToString(attr.BinaryValue),
+			},
+		},
+	})
+	require.NoError(t, err)
+	msg := types.Message{Body: aws.String(string(body))}
+	carrier, ok := sqsCarrier(&msg)
+	require.True(t, ok)
+	p, ok := datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), carrier))
+	require.True(t, ok)
+	expectedCtx, _ := tracer.SetDataStreamsCheckpoint(context.Background(), "direction:out", "topic:MyTopic", "type:sns")
+	expected, _ := datastreams.PathwayFromContext(expectedCtx)
+	assert.Equal(t, expected.GetHash(), p.GetHash())
+}
+
+func TestDataStreamsKinesis(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	span := tracer.StartSpan("test")
+	in := &kinesis.PutRecordsInput{
+		StreamName: aws.String("MyStream"),
+		Records: []kinesistypes.PutRecordsRequestEntry{
+			{Data: []byte(`{"key":"value"}`), PartitionKey: aws.String("1")},
+			{Data: []byte("not json"), PartitionKey: aws.String("2")},
+		},
+	}
+	injectDataStreams(context.Background(), span, middleware.InitializeInput{Parameters: in})
+	assert.Equal(t, []byte("not json"), in.Records[1].Data)
+
+	var obj struct {
+		Key     string                `json:"key"`
+		Datadog tracer.TextMapCarrier `json:"_datadog"`
+	}
+	require.NoError(t, json.Unmarshal(in.Records[0].Data, &obj))
+	assert.Equal(t, "value", obj.Key)
+	p, ok := datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), obj.Datadog))
+	require.True(t, ok)
+	expectedCtx, _ := tracer.SetDataStreamsCheckpoint(context.Background(), "direction:out", "topic:MyStream", "type:kinesis")
+	expected, _ := datastreams.PathwayFromContext(expectedCtx)
+	assert.Equal(t, expected.GetHash(), p.GetHash())
+}
@@ -15,6 +15,8 @@ type config struct {
 	serviceName   string
 	analyticsRate float64
 	errCheck      func(err error) bool
+	// dataStreamsEnabled injects trace context and data streams pathways into messages.
+	dataStreamsEnabled bool
 }
 
 // Option represents an option that can be passed to Dial.
@@ -26,6 +28,7 @@ func defaults(cfg *config) {
 	} else {
 		cfg.analyticsRate = math.NaN()
 	}
+	cfg.dataStreamsEnabled = internal.BoolEnv("DD_DATA_STREAMS_ENABLED", false)
 }
 
 // WithServiceName sets the given service name for the dialled connection.
@@ -68,3 +71,13 @@ func WithErrorCheck(fn func(err error) bool) Option {
 		cfg.errCheck = fn
 	}
 }
+
+// WithDataStreams enables the Data Streams monitoring product features: https://www.datadoghq.com/product/data-streams-monitoring/
+// The trace context and the pathway are injected into the messages sent to SQS,
+// SNS, Kinesis and EventBridge, and extracted from the messages received from SQS
+// and the records read from Kinesis.
+func WithDataStreams() Option {
+	return func(cfg *config) {
+		cfg.dataStreamsEnabled = true
+	}
+}
@@ -6,6 +6,7 @@
 package pubsub
 
 import (
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/namingschema"
 )
 
@@ -14,14 +15,17 @@ type config struct {
 	publishSpanName string
 	receiveSpanName string
 	measured        bool
+	// dataStreamsEnabled sets data streams checkpoints on publish and receive.
+	dataStreamsEnabled bool
 }
 
 func defaultConfig() *config {
 	return &config{
-		serviceName:     namingschema.ServiceNameOverrideV0("", ""),
-		publishSpanName: namingschema.OpName(namingschema.GCPPubSubOutbound),
-		receiveSpanName: namingschema.OpName(namingschema.GCPPubSubInbound),
-		measured:        false,
+		serviceName:        namingschema.ServiceNameOverrideV0("", ""),
+		publishSpanName:    namingschema.OpName(namingschema.GCPPubSubOutbound),
+		receiveSpanName:    namingschema.OpName(namingschema.GCPPubSubInbound),
+		measured:           false,
+		dataStreamsEnabled: internal.BoolEnv("DD_DATA_STREAMS_ENABLED", false),
 	}
 }
 
@@ -44,3 +48,10 @@ func WithMeasured() Option {
 		cfg.measured = true
 	}
 }
+
+// WithDataStreams enables the Data Streams monitoring product features: https://www.datadoghq.com/product/data-streams-monitoring/
+func WithDataStreams() Option {
+	return func(cfg *config) {
+		cfg.dataStreamsEnabled = true
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"context"
 	"sync"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams/options"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -63,6 +65,9 @@ func Publish(ctx context.Context, t *pubsub.Topic, msg *pubsub.Message, opts ...
 	if err := tracer.Inject(span.Context(), tracer.TextMapCarrier(msg.Attributes)); err != nil {
 		log.Debug("contrib/cloud.google.com/go/pubsub.v1/: failed injecting tracing attributes: %v", err)
 	}
+	if cfg.dataStreamsEnabled {
+		setProduceCheckpoint(ctx, t.ID(), msg)
+	}
 	span.SetTag("num_attributes", len(msg.Attributes))
 	return &PublishResult{
 		PublishResult: t.Publish(ctx, msg),
@@ -119,6 +124,9 @@ func WrapReceiveHandler(s *pubsub.Subscription, f func(context.Context, *pubsub.
 			opts = append(opts, tracer.Measured())
 		}
 		span, ctx := tracer.StartSpanFromContext(ctx, cfg.receiveSpanName, opts...)
+		if cfg.dataStreamsEnabled {
+			ctx = setConsumeCheckpoint(ctx, s.ID(), msg)
+		}
 		if msg.DeliveryAttempt != nil {
 			span.SetTag("delivery_attempt", *msg.DeliveryAttempt)
 		}
@@ -126,3 +134,40 @@ func WrapReceiveHandler(s *pubsub.Subscription, f func(context.Context, *pubsub.
 		f(ctx, msg)
 	}
 }
+
+// setProduceCheckpoint sets a data streams checkpoint for a message published on
+// topic and carries the resulting pathway in the message attributes.
+func setProduceCheckpoint(ctx context.Context, topic string, msg *pubsub.Message) {
+	ctx, ok := tracer.SetDataStreamsCheckpointWithParams(
+		ctx,
+		options.CheckpointParams{PayloadSize: getMsgSize(msg)},
+		"direction:out", "topic:"+topic, "type:google-pubsub",
+	)
+	if !ok {
+		return
+	}
+	if msg.Attributes == nil {
+		msg.Attributes = make(map[string]string)
+	}
+	datastreams.InjectToBase64Carrier(ctx, tracer.TextMapCarrier(msg.Attributes))
+}
+
+// setConsumeCheckpoint sets a data streams checkpoint for a message received from
+// subscription, continuing the pathway found in its attributes. The checkpoint is
+// tagged with the subscription ID, as the topic of the subscription isn't known
+// without an extra API call. The returned context holds the resulting pathway.
+func setConsumeCheckpoint(ctx context.Context, subscription string, msg *pubsub.Message) context.Context {
+	ctx, _ = tracer.SetDataStreamsCheckpointWithParams(
+		datastreams.ExtractFromBase64Carrier(ctx, tracer.TextMapCarrier(msg.Attributes)),
+		options.CheckpointParams{PayloadSize: getMsgSize(msg)},
+		"direction:in", "subscription:"+subscription, "type:google-pubsub",
+	)
+	return ctx
+}
+
+func getMsgSize(msg *pubsub.Message) (size int64) {
+	for k, v := range msg.Attributes {
+		size += int64(len(k) + len(v))
+	}
+	return size + int64(len(msg.Data))
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/namingschematest"
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -238,6 +239,35 @@ func TestPropagationNoPublisherSpan(t *testing.T) {
 	}, spans[0].Tags())
 }
 
+func TestDataStreams(t *testing.T) {
+	ctx, cancel, _, topic, sub := setup(t)
+
+	msg := &pubsub.Message{Data: []byte("hello"), OrderingKey: "xxx"}
+	_, err := Publish(ctx, topic, msg, WithDataStreams()).Get(ctx)
+	require.NoError(t, err)
+	p, ok := datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), tracer.TextMapCarrier(msg.Attributes)))
+	require.True(t, ok)
+	expectedCtx, _ := tracer.SetDataStreamsCheckpoint(context.Background(), "direction:out", "topic:topic", "type:google-pubsub")
+	expected, _ := datastreams.PathwayFromContext(expectedCtx)
+	assert.NotEqual(t, expected.GetHash(), 0)
+	assert.Equal(t, expected.GetHash(), p.GetHash())
+
+	var called bool
+	err = sub.Receive(ctx, WrapReceiveHandler(sub, func(ctx context.Context, msg *pubsub.Message) {
+		// the receive handler context holds the pathway continued from the publisher
+		p, ok := datastreams.PathwayFromContext(ctx)
+		assert.True(t, ok)
+		expectedCtx, _ := tracer.SetDataStreamsCheckpoint(expectedCtx, "direction:in", "subscription:subscription", "type:google-pubsub")
+		expected, _ := datastreams.PathwayFromContext(expectedCtx)
+		assert.Equal(t, expected.GetHash(), p.GetHash())
+		msg.Ack()
+		called = true
+		cancel()
+	}, WithDataStreams()))
+	assert.NoError(t, err)
+	assert.True(t, called, "callback not called")
+}
+
 func TestNamingSchema(t *testing.T) {
 	genSpans := namingschematest.GenSpansFn(func(t *testing.T, serviceOverride string) []mocktracer.Span {
 		var opts []Option
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package sql
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/database/sql/internal"
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSec(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "../../../internal/appsec/testdata/rasp.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("appsec disabled")
+	}
+
+	mockDriver := &internal.MockDriver{}
+	Register("mock-appsec", mockDriver)
+	db, err := Open("mock-appsec", "")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var queryErr error
+	mux := httptrace.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		var rows *sql.Rows
+		rows, queryErr = db.QueryContext(r.Context(), "SELECT * FROM users WHERE id = '"+r.URL.Query().Get("id")+"'")
+		if queryErr != nil {
+			return
+		}
+		rows.Close()
+		w.Write([]byte("Hello World!\n"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("no-block", func(t *testing.T) {
+		mockDriver.Executed = nil
+		res, err := srv.Client().Get(srv.URL + "/user?id=1")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.NoError(t, queryErr)
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Len(t, mockDriver.Executed, 1)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		mockDriver.Executed = nil
+		res, err := srv.Client().Get(srv.URL + "/user?id=%27%20OR%201%3D1%20--")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		var blocked *events.BlockingSecurityEvent
+		require.True(t, errors.As(queryErr, &blocked))
+		require.Equal(t, http.StatusForbidden, res.StatusCode)
+		// the query was aborted before reaching the database
+		require.Empty(t, mockDriver.Executed)
+		var found bool
+		for _, s := range mt.FinishedSpans() {
+			if event, ok := s.Tag("_dd.appsec.json").(string); ok {
+				found = found || strings.Contains(event, "rasp-942-100")
+			}
+		}
+		require.True(t, found)
+	})
+}
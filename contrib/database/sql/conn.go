@@ -15,6 +15,8 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sqlsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
 
@@ -106,6 +108,9 @@ func (tc *TracedConn) PrepareContext(ctx context.Context, query string) (stmt dr
 		// no context other than service in prepared statements
 		mode = tracer.DBMPropagationModeService
 	}
+	if err := tc.protect(ctx, QueryTypePrepare, query, start); err != nil {
+		return nil, err
+	}
 	cquery, spanID := tc.injectComments(ctx, query, mode)
 	if connPrepareCtx, ok := tc.Conn.(driver.ConnPrepareContext); ok {
 		ctx, end := startTraceTask(ctx, QueryTypePrepare)
@@ -132,6 +137,9 @@ func (tc *TracedConn) PrepareContext(ctx context.Context, query string) (stmt dr
 func (tc *TracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (r driver.Result, err error) {
 	start := time.Now()
 	if execContext, ok := tc.Conn.(driver.ExecerContext); ok {
+		if err := tc.protect(ctx, QueryTypeExec, query, start); err != nil {
+			return nil, err
+		}
 		cquery, spanID := tc.injectComments(ctx, query, tc.cfg.dbmPropagationMode)
 		ctx, end := startTraceTask(ctx, QueryTypeExec)
 		defer end()
@@ -149,6 +157,9 @@ func (tc *TracedConn) ExecContext(ctx context.Context, query string, args []driv
 			return nil, ctx.Err()
 		default:
 		}
+		if err := tc.protect(ctx, QueryTypeExec, query, start); err != nil {
+			return nil, err
+		}
 		cquery, spanID := tc.injectComments(ctx, query, tc.cfg.dbmPropagationMode)
 		ctx, end := startTraceTask(ctx, QueryTypeExec)
 		defer end()
@@ -176,6 +187,9 @@ func (tc *TracedConn) Ping(ctx context.Context) (err error) {
 func (tc *TracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
 	start := time.Now()
 	if queryerContext, ok := tc.Conn.(driver.QueryerContext); ok {
+		if err := tc.protect(ctx, QueryTypeQuery, query, start); err != nil {
+			return nil, err
+		}
 		cquery, spanID := tc.injectComments(ctx, query, tc.cfg.dbmPropagationMode)
 		ctx, end := startTraceTask(ctx, QueryTypeQuery)
 		defer end()
@@ -193,6 +207,9 @@ func (tc *TracedConn) QueryContext(ctx context.Context, query string, args []dri
 			return nil, ctx.Err()
 		default:
 		}
+		if err := tc.protect(ctx, QueryTypeQuery, query, start); err != nil {
+			return nil, err
+		}
 		cquery, spanID := tc.injectComments(ctx, query, tc.cfg.dbmPropagationMode)
 		ctx, end := startTraceTask(ctx, QueryTypeQuery)
 		defer end()
@@ -261,6 +278,21 @@ func (tc *TracedConn) injectComments(ctx context.Context, query string, mode tra
 	return carrier.Query, carrier.SpanID
 }
 
+// protect runs the AppSec SQL injection protection of the query when enabled,
+// and returns the error aborting it when it must be blocked, in which case the
+// aborted query is traced with the error.
+func (tc *TracedConn) protect(ctx context.Context, qtype QueryType, query string, start time.Time) error {
+	if !appsec.RASPEnabled() {
+		return nil
+	}
+	dbSystem, _ := normalizeDBSystem(tc.driverName)
+	err := sqlsec.ProtectSQLOperation(ctx, query, dbSystem)
+	if err != nil {
+		tc.tryTrace(ctx, qtype, query, start, err)
+	}
+	return err
+}
+
 func withDBMTraceInjectedTag(mode tracer.DBMPropagationMode) []tracer.StartSpanOption {
 	if mode == tracer.DBMPropagationModeFull {
 		return []tracer.StartSpanOption{tracer.Tag(keyDBMTraceInjected, true)}
@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package zap_test
+
+import (
+	"context"
+
+	zaptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go.uber.org/zap"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"go.uber.org/zap"
+)
+
+func ExampleWithTraceFields() {
+	// start the DataDog tracer
+	tracer.Start()
+	defer tracer.Stop()
+
+	// create the application logger
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	// start a new span
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "ExampleWithTraceFields")
+	defer span.Finish()
+
+	// log a message correlated to the span
+	zaptrace.WithTraceFields(ctx, logger).Info("this is a log with tracing information")
+
+	// or add the correlation fields to a single entry
+	logger.Info("this is another log with tracing information", zaptrace.TraceFields(ctx)...)
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+// Package zap provides log/span correlation helpers for the go.uber.org/zap package (https://github.com/uber-go/zap).
+package zap
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/logtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"go.uber.org/zap"
+)
+
+const componentName = "go.uber.org/zap"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+	tracer.MarkIntegrationImported("go.uber.org/zap")
+}
+
+// TraceFields returns the fields correlating a log entry to the span found in ctx.
+// It returns nil if ctx holds no span.
+func TraceFields(ctx context.Context) []zap.Field {
+	fields, ok := logtrace.Fields(ctx)
+	if !ok {
+		return nil
+	}
+	zfields := make([]zap.Field, len(fields))
+	for i, f := range fields {
+		zfields[i] = zap.String(f.Key, f.Value)
+	}
+	return zfields
+}
+
+// WithTraceFields returns a child of logger whose entries are correlated to the
+// span found in ctx. The logger is returned as is if ctx holds no span.
+func WithTraceFields(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := TraceFields(ctx)
+	if len(fields) == 0 {
+		return logger
+	}
+	return logger.With(fields...)
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package zap
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithTraceFields(t *testing.T) {
+	tracer.Start(tracer.WithLogStartup(false), tracer.WithEnv("env"), tracer.WithServiceVersion("1.2.3"))
+	defer tracer.Stop()
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "testSpan", tracer.WithSpanID(1234))
+	defer span.Finish()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	WithTraceFields(ctx, logger).Info("message")
+	entries := logs.TakeAll()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, span.Context().(ddtrace.SpanContextW3C).TraceID128(), fields["dd.trace_id"])
+	assert.Equal(t, strconv.FormatUint(span.Context().SpanID(), 10), fields["dd.span_id"])
+	assert.Equal(t, "env", fields["dd.env"])
+	assert.Equal(t, "1.2.3", fields["dd.version"])
+
+	// the logger is returned as is when there is no span
+	assert.Same(t, logger, WithTraceFields(context.Background(), logger))
+	assert.Nil(t, TraceFields(context.Background()))
+}
@@ -0,0 +1,219 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package fiber
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppSec(t *testing.T) {
+	appsec.Start()
+	defer appsec.Stop()
+
+	if !appsec.Enabled() {
+		t.Skip("appsec disabled")
+	}
+
+	router := fiber.New()
+	router.Use(Middleware())
+	router.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("Hello World!\n")
+	})
+
+	params := fiber.New()
+	params.Get("/path0.0/:myPathParam0/path0.1/:myPathParam1", Middleware(), func(c *fiber.Ctx) error {
+		return c.SendString("Hello World!\n")
+	})
+
+	// Test an LFI attack via the request URI
+	t.Run("request-uri", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		// Send an LFI attack (according to appsec rule id crs-930-110)
+		res, err := router.Test(httptest.NewRequest("GET", "/../../../secret.txt", nil))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+		// The span should contain the security event
+		finished := mt.FinishedSpans()
+		require.Len(t, finished, 1)
+		event := finished[0].Tag("_dd.appsec.json").(string)
+		require.True(t, strings.Contains(event, "crs-930-110"))
+		require.True(t, strings.Contains(event, "server.request.uri.raw"))
+	})
+
+	// Test that the status of the not found error response is monitored
+	t.Run("response-status", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		res, err := router.Test(httptest.NewRequest("POST", "/etc/", nil))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+		finished := mt.FinishedSpans()
+		require.Len(t, finished, 1)
+		event := finished[0].Tag("_dd.appsec.json").(string)
+		require.True(t, strings.Contains(event, "server.response.status"))
+		require.True(t, strings.Contains(event, "nfd-000-001"))
+	})
+
+	// Test a security scanner attack via path parameters
+	t.Run("path-params", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		// Send a security scanner attack (according to appsec rule id crs-913-120)
+		res, err := params.Test(httptest.NewRequest("GET", "/path0.0/param0/path0.1/appscan_fingerprint", nil))
+		require.NoError(t, err)
+		defer res.Body.Close()
+		// Check that the handler was properly called
+		b, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, "Hello World!\n", string(b))
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		// The span should contain the security event
+		finished := mt.FinishedSpans()
+		require.Len(t, finished, 1)
+		event := finished[0].Tag("_dd.appsec.json").(string)
+		require.True(t, strings.Contains(event, "crs-913-120"))
+		require.True(t, strings.Contains(event, "myPathParam1"))
+		require.True(t, strings.Contains(event, "server.request.path_params"))
+	})
+}
+
+// Test that IP and query blocking works by using custom rules/rules data
+func TestBlocking(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "../../../internal/appsec/testdata/blocking.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("appsec disabled")
+	}
+
+	router := fiber.New()
+	router.Use(Middleware())
+	router.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("Hello World!\n")
+	})
+
+	for _, tc := range []struct {
+		name    string
+		uri     string
+		headers map[string]string
+		blocked bool
+	}{
+		{name: "ip/no-block", uri: "/", headers: map[string]string{"x-forwarded-for": "1.2.3.5"}},
+		{name: "ip/block", uri: "/", headers: map[string]string{"x-forwarded-for": "1.2.3.4"}, blocked: true},
+		{name: "query/no-block", uri: "/?x=value"},
+		{name: "query/block", uri: "/?x=$globals", blocked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			req := httptest.NewRequest("GET", tc.uri, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			res, err := router.Test(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+			b, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			if tc.blocked {
+				require.Equal(t, http.StatusForbidden, res.StatusCode)
+				require.Contains(t, string(b), "Security provided by Datadog")
+				require.Equal(t, true, spans[0].Tag("appsec.blocked"))
+			} else {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "Hello World!\n", string(b))
+				require.Nil(t, spans[0].Tag("appsec.blocked"))
+			}
+		})
+	}
+}
+
+// Test that the block response of a RASP action isn't replaced by the error
+// handler, when the handler returns the blocking error of the aborted call.
+func TestRASP(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "../../../internal/appsec/testdata/rasp.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.RASPEnabled() {
+		t.Skip("RASP needs to be enabled for this test")
+	}
+
+	client := httptrace.WrapClient(&http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})})
+	var roundTripErr error
+	router := fiber.New()
+	router.Use(Middleware())
+	router.Get("/ssrf", func(c *fiber.Ctx) error {
+		req, _ := http.NewRequestWithContext(c.UserContext(), "GET", c.Query("url"), nil)
+		res, err := client.Do(req)
+		if roundTripErr = err; err != nil {
+			return err
+		}
+		res.Body.Close()
+		return c.SendString("Hello World!\n")
+	})
+
+	for _, tc := range []struct {
+		name    string
+		url     string
+		blocked bool
+	}{
+		{name: "no-block", url: "https://example.com"},
+		{name: "block", url: "http://169.254.169.254/latest/meta-data", blocked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			res, err := router.Test(httptest.NewRequest("GET", "/ssrf?url="+url.QueryEscape(tc.url), nil))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			b, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			if !tc.blocked {
+				require.NoError(t, roundTripErr)
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				return
+			}
+			var blocked *events.BlockingSecurityEvent
+			require.True(t, errors.As(roundTripErr, &blocked))
+			require.Equal(t, http.StatusForbidden, res.StatusCode)
+			require.Contains(t, string(b), "Security provided by Datadog")
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestErrorStatus(t *testing.T) {
+	require.Equal(t, http.StatusNotFound, errorStatus(fiber.ErrNotFound))
+	require.Equal(t, http.StatusTeapot, errorStatus(fmt.Errorf("wrapped: %w", fiber.NewError(http.StatusTeapot))))
+	require.Equal(t, http.StatusInternalServerError, errorStatus(errors.New("boom")))
+}
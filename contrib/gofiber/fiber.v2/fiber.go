@@ -7,14 +7,18 @@
 package fiber // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/gofiber/fiber.v2"
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
 	"strconv"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/fasthttptrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 
@@ -28,7 +32,11 @@ func init() {
 	tracer.MarkIntegrationImported("github.com/gofiber/fiber/v2")
 }
 
-// Middleware returns middleware that will trace incoming requests.
+// Middleware returns middleware that will trace incoming requests. When AppSec
+// is enabled, requests are also monitored, and blocked or redirected according
+// to the security rules. The path parameters are only monitored when the
+// middleware is registered on the route defining them, as they aren't known
+// yet when it is registered using fiber.App.Use.
 func Middleware(opts ...Option) func(c *fiber.Ctx) error {
 	cfg := new(config)
 	defaults(cfg)
@@ -73,7 +81,15 @@ func Middleware(opts ...Option) func(c *fiber.Ctx) error {
 		c.SetUserContext(ctx)
 
 		// pass the execution down the line
-		err := c.Next()
+		var err error
+		if appsec.Enabled() {
+			err = fasthttptrace.MonitorAppSec(ctx, c.Context(), span, c.AllParams(), errorStatus, func(ctx context.Context) error {
+				c.SetUserContext(ctx)
+				return c.Next()
+			})
+		} else {
+			err = c.Next()
+		}
 
 		span.SetTag(ext.ResourceName, cfg.resourceNamer(c))
 		span.SetTag(ext.HTTPRoute, c.Route().Path)
@@ -96,3 +112,14 @@ func Middleware(opts ...Option) func(c *fiber.Ctx) error {
 		return err
 	}
 }
+
+// errorStatus returns the status code of the response written by the default
+// fiber error handler for err, such as 404 for the fiber.ErrNotFound error
+// returned for unknown routes, as it is only written once the middleware returns.
+func errorStatus(err error) int {
+	var e *fiber.Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package fasthttptrace
+
+import (
+	"context"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/trace/httptrace"
+
+	"github.com/valyala/fasthttp"
+)
+
+// MonitorAppSec monitors the request of fctx with AppSec while it is served by
+// next, which is called with ctx once it holds the AppSec operation. The operation
+// is also stored in the user values of fctx, so that fctx can be used with the
+// appsec package. The responses of the blocking and redirect actions are written
+// to fctx, either instead of calling next, or in place of the response written by
+// next. pathParams holds the path parameters of the request, when known.
+// errorStatus, when not nil, returns the status code of the response written for
+// the error returned by next, for frameworks such as fiber writing the error
+// responses only once the middleware returned. It returns the error returned by next, unless it is a monitoring error or an action
+// response was written, in which case the error must not change the response, e.g.
+// when next returns the *events.BlockingSecurityEvent error of a RASP action.
+func MonitorAppSec(ctx context.Context, fctx *fasthttp.RequestCtx, span tracer.Span, pathParams map[string]string, errorStatus func(error) int, next func(context.Context) error) error {
+	args, ipTags := makeHandlerOperationArgs(fctx, pathParams)
+	ctx, m := httpsec.StartHandlerMonitor(ctx, span, args, ipTags)
+	fctx.SetUserValue(listener.ContextKey{}, ctx.Value(listener.ContextKey{}))
+
+	var err error
+	if m.Action() == nil {
+		err = next(ctx)
+		if _, ok := err.(*types.MonitoringError); ok {
+			err = nil
+		}
+	}
+	status := fctx.Response.StatusCode()
+	if err != nil && errorStatus != nil {
+		status = errorStatus(err)
+	}
+	m.Finish(types.HandlerOperationRes{
+		Status:  status,
+		Headers: makeHeaders(&fctx.Response.Header),
+	})
+	if a := m.Action(); a != nil {
+		writeActionResponse(fctx, a)
+		return nil
+	}
+	return err
+}
+
+// makeHandlerOperationArgs returns the AppSec operation arguments of the request
+// of fctx, along with its client IP tags.
+func makeHandlerOperationArgs(fctx *fasthttp.RequestCtx, pathParams map[string]string) (types.HandlerOperationArgs, map[string]string) {
+	headers := makeHeaders(&fctx.Request.Header)
+	headers["host"] = []string{string(fctx.Host())}
+	var cookies map[string][]string
+	fctx.Request.Header.VisitAllCookie(func(k, v []byte) {
+		if cookies == nil {
+			cookies = make(map[string][]string)
+		}
+		cookies[string(k)] = append(cookies[string(k)], string(v))
+	})
+	query := make(map[string][]string)
+	fctx.QueryArgs().VisitAll(func(k, v []byte) {
+		query[string(k)] = append(query[string(k)], string(v))
+	})
+	ipTags, clientIP := httptrace.ClientIPTags(headers, false, fctx.RemoteAddr().String())
+	return types.HandlerOperationArgs{
+		Method:     string(fctx.Method()),
+		RequestURI: string(fctx.RequestURI()),
+		Headers:    headers,
+		Cookies:    cookies,
+		Query:      query,
+		PathParams: pathParams,
+		ClientIP:   clientIP,
+	}, ipTags
+}
+
+// header is implemented by fasthttp.RequestHeader and fasthttp.ResponseHeader.
+type header interface {
+	VisitAll(f func(key, value []byte))
+}
+
+// makeHeaders returns the headers of h, but the cookies, with lower-case names.
+func makeHeaders(h header) map[string][]string {
+	headers := make(map[string][]string)
+	h.VisitAll(func(k, v []byte) {
+		key := strings.ToLower(string(k))
+		if key == "cookie" {
+			return
+		}
+		headers[key] = append(headers[key], string(v))
+	})
+	return headers
+}
+
+// writeActionResponse replaces the response of fctx with the one of the action a.
+func writeActionResponse(fctx *fasthttp.RequestCtx, a *sharedsec.Action) {
+	resp := a.HTTPResponse(string(fctx.Request.Header.Peek(fasthttp.HeaderAccept)))
+	fctx.Response.Reset()
+	fctx.SetStatusCode(resp.Status)
+	for k, v := range resp.Headers {
+		fctx.Response.Header.Set(k, v)
+	}
+	fctx.SetBody(resp.Body)
+}
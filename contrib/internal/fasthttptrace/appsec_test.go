@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package fasthttptrace
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMonitorAppSec(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	newRequestCtx := func() *fasthttp.RequestCtx {
+		var req fasthttp.Request
+		req.SetRequestURI("/path?a=1&a=2&b=3")
+		req.SetHost("example.com")
+		req.Header.SetMethod("POST")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("X-Forwarded-For", "1.2.3.4")
+		req.Header.SetCookie("session", "abc")
+		var fctx fasthttp.RequestCtx
+		fctx.Init(&req, &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}, nil)
+		return &fctx
+	}
+	// run serves a request with MonitorAppSec, whose operation emits the
+	// action start when it starts and the action finish when it finishes. When
+	// rasp is set, the handler emits it and returns a blocking error, the way
+	// RASP protected calls do.
+	run := func(t *testing.T, start, finish, rasp *sharedsec.Action) (fctx *fasthttp.RequestCtx, args types.HandlerOperationArgs, res types.HandlerOperationRes, called bool) {
+		root := dyngo.NewRootOperation()
+		dyngo.SwapRootOperation(root)
+		defer dyngo.SwapRootOperation(dyngo.NewRootOperation())
+		dyngo.On(root, func(op *types.Operation, a types.HandlerOperationArgs) {
+			args = a
+			if start != nil {
+				dyngo.EmitData(op, start)
+			}
+			dyngo.OnFinish(op, func(op *types.Operation, r types.HandlerOperationRes) {
+				res = r
+				if finish != nil {
+					dyngo.EmitData(op, finish)
+				}
+			})
+		})
+
+		fctx = newRequestCtx()
+		span := tracer.StartSpan("http.request")
+		defer span.Finish()
+		err := MonitorAppSec(context.Background(), fctx, span, map[string]string{"id": "42"}, nil, func(ctx context.Context) error {
+			called = true
+			assert.NotNil(t, ctx.Value(listener.ContextKey{}))
+			assert.Equal(t, ctx.Value(listener.ContextKey{}), fctx.UserValue(listener.ContextKey{}))
+			fctx.Response.Header.Set("X-Handler", "yes")
+			fctx.SetStatusCode(fasthttp.StatusCreated)
+			fctx.SetBodyString("created")
+			if rasp != nil {
+				dyngo.EmitData(ctx.Value(listener.ContextKey{}).(*types.Operation), rasp)
+				return &events.BlockingSecurityEvent{}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		return fctx, args, res, called
+	}
+
+	t.Run("monitored", func(t *testing.T) {
+		fctx, args, res, called := run(t, nil, nil, nil)
+		assert.True(t, called)
+		assert.Equal(t, "POST", args.Method)
+		assert.Equal(t, "/path?a=1&a=2&b=3", args.RequestURI)
+		assert.Equal(t, []string{"example.com"}, args.Headers["host"])
+		assert.Equal(t, []string{"1.2.3.4"}, args.Headers["x-forwarded-for"])
+		assert.NotContains(t, args.Headers, "cookie")
+		assert.Equal(t, map[string][]string{"session": {"abc"}}, args.Cookies)
+		assert.Equal(t, map[string][]string{"a": {"1", "2"}, "b": {"3"}}, args.Query)
+		assert.Equal(t, map[string]string{"id": "42"}, args.PathParams)
+		assert.Equal(t, "1.2.3.4", args.ClientIP.String())
+		assert.Equal(t, fasthttp.StatusCreated, res.Status)
+		assert.Equal(t, []string{"yes"}, res.Headers["x-handler"])
+		assert.Equal(t, "created", string(fctx.Response.Body()))
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		fctx, _, _, called := run(t, sharedsec.NewBlockRequestAction(fasthttp.StatusForbidden, 10, "auto"), nil, nil)
+		assert.False(t, called)
+		assert.Equal(t, fasthttp.StatusForbidden, fctx.Response.StatusCode())
+		assert.Equal(t, "application/json", string(fctx.Response.Header.ContentType()))
+		assert.Contains(t, string(fctx.Response.Body()), "Security provided by Datadog")
+	})
+
+	t.Run("redirected-on-response", func(t *testing.T) {
+		fctx, _, _, called := run(t, nil, sharedsec.NewRedirectRequestAction(fasthttp.StatusFound, "/login"), nil)
+		assert.True(t, called)
+		assert.Equal(t, fasthttp.StatusFound, fctx.Response.StatusCode())
+		assert.Equal(t, "/login", string(fctx.Response.Header.Peek("Location")))
+		assert.Empty(t, fctx.Response.Header.Peek("X-Handler"))
+		assert.Empty(t, fctx.Response.Body())
+	})
+
+	t.Run("error-status", func(t *testing.T) {
+		// the status of the error response written once the middleware returns
+		// is monitored instead of the one of the response written so far.
+		root := dyngo.NewRootOperation()
+		dyngo.SwapRootOperation(root)
+		defer dyngo.SwapRootOperation(dyngo.NewRootOperation())
+		var res types.HandlerOperationRes
+		dyngo.On(root, func(op *types.Operation, _ types.HandlerOperationArgs) {
+			dyngo.OnFinish(op, func(_ *types.Operation, r types.HandlerOperationRes) {
+				res = r
+			})
+		})
+
+		span := tracer.StartSpan("http.request")
+		defer span.Finish()
+		errNotFound := errors.New("not found")
+		errorStatus := func(err error) int {
+			assert.Equal(t, errNotFound, err)
+			return fasthttp.StatusNotFound
+		}
+		err := MonitorAppSec(context.Background(), newRequestCtx(), span, nil, errorStatus, func(context.Context) error {
+			return errNotFound
+		})
+		assert.Equal(t, errNotFound, err)
+		assert.Equal(t, fasthttp.StatusNotFound, res.Status)
+	})
+
+	t.Run("rasp-blocked", func(t *testing.T) {
+		// the blocking error returned by the handler is not returned, as the
+		// block response was written.
+		fctx, _, _, called := run(t, nil, nil, sharedsec.NewBlockRequestAction(fasthttp.StatusForbidden, 10, "auto"))
+		assert.True(t, called)
+		assert.Equal(t, fasthttp.StatusForbidden, fctx.Response.StatusCode())
+		assert.Empty(t, fctx.Response.Header.Peek("X-Handler"))
+		assert.Contains(t, string(fctx.Response.Body()), "Security provided by Datadog")
+	})
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+// Package logtrace provides the trace/log correlation fields shared by the
+// logging integrations.
+package logtrace
+
+import (
+	"context"
+	"strconv"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+)
+
+// Keys of the fields added to log records.
+const (
+	TraceIDKey = "dd.trace_id"
+	SpanIDKey  = "dd.span_id"
+	ServiceKey = "dd.service"
+	EnvKey     = "dd.env"
+	VersionKey = "dd.version"
+)
+
+// Field is a log correlation field.
+type Field struct {
+	Key   string
+	Value string
+}
+
+// Fields returns the fields correlating a log record to the span found in ctx,
+// followed by the service, environment and version of the application when they
+// are set. It returns false if ctx holds no span, or if logs injection is disabled
+// through DD_LOGS_INJECTION or remote configuration.
+//
+// The trace ID is the 128-bit hex-encoded trace ID when its upper 64 bits are
+// set, and the decimal 64-bit trace ID otherwise.
+func Fields(ctx context.Context) ([]Field, bool) {
+	if ctx == nil || !globalconfig.LogsInjection() {
+		return nil, false
+	}
+	span, ok := tracer.SpanFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	sctx := span.Context()
+	fields := make([]Field, 0, 5)
+	fields = append(fields,
+		Field{TraceIDKey, traceID(sctx)},
+		Field{SpanIDKey, strconv.FormatUint(sctx.SpanID(), 10)},
+	)
+	if v := globalconfig.ServiceName(); v != "" {
+		fields = append(fields, Field{ServiceKey, v})
+	}
+	if v := globalconfig.Env(); v != "" {
+		fields = append(fields, Field{EnvKey, v})
+	}
+	if v := globalconfig.Version(); v != "" {
+		fields = append(fields, Field{VersionKey, v})
+	}
+	return fields, true
+}
+
+func traceID(sctx ddtrace.SpanContext) string {
+	if w3c, ok := sctx.(ddtrace.SpanContextW3C); ok {
+		id := w3c.TraceID128Bytes()
+		for _, b := range id[:8] {
+			if b != 0 {
+				return w3c.TraceID128()
+			}
+		}
+	}
+	return strconv.FormatUint(sctx.TraceID(), 10)
+}
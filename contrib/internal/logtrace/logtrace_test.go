@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package logtrace
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/globalconfig"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	t.Run("no-span", func(t *testing.T) {
+		_, ok := Fields(context.Background())
+		assert.False(t, ok)
+	})
+
+	t.Run("64-bit", func(t *testing.T) {
+		t.Setenv("DD_TRACE_128_BIT_TRACEID_GENERATION_ENABLED", "false")
+		tracer.Start(tracer.WithLogStartup(false))
+		defer tracer.Stop()
+		_, ctx := tracer.StartSpanFromContext(context.Background(), "test", tracer.WithSpanID(1234))
+
+		fields, ok := Fields(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, []Field{{TraceIDKey, "1234"}, {SpanIDKey, "1234"}}, fields)
+	})
+
+	t.Run("128-bit", func(t *testing.T) {
+		tracer.Start(
+			tracer.WithLogStartup(false),
+			tracer.WithService("service"),
+			tracer.WithEnv("env"),
+			tracer.WithServiceVersion("1.2.3"),
+		)
+		defer tracer.Stop()
+		span, ctx := tracer.StartSpanFromContext(context.Background(), "test", tracer.WithSpanID(1234))
+
+		fields, ok := Fields(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, []Field{
+			{TraceIDKey, span.Context().(ddtrace.SpanContextW3C).TraceID128()},
+			{SpanIDKey, "1234"},
+			{ServiceKey, "service"},
+			{EnvKey, "env"},
+			{VersionKey, "1.2.3"},
+		}, fields)
+		assert.Len(t, fields[0].Value, 32)
+	})
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("DD_LOGS_INJECTION", "false")
+		defer globalconfig.SetLogsInjection(true)
+		tracer.Start(tracer.WithLogStartup(false))
+		defer tracer.Stop()
+		_, ctx := tracer.StartSpanFromContext(context.Background(), "test")
+
+		_, ok := Fields(ctx)
+		assert.False(t, ok)
+	})
+}
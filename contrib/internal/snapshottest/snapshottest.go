@@ -0,0 +1,271 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+// Package snapshottest records the spans collected by the mocktracer to golden
+// JSON files and compares later runs against them, in order to catch changes to
+// the names, resources, services and tags produced by integrations.
+//
+// Golden files live in the testdata/snapshots directory of the package under
+// test. They are written, or rewritten, when running the tests with the
+// -update-snapshots flag or with DD_UPDATE_SNAPSHOTS=true:
+//
+//	go test ./contrib/net/http -update-snapshots
+package snapshottest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update-snapshots", false, "rewrite the golden files of snapshottest")
+
+// Dir is the directory, relative to the package under test, holding the golden files.
+const Dir = "testdata/snapshots"
+
+// volatileTags holds the tags which change on every run and are never recorded.
+var volatileTags = []string{
+	ext.RuntimeID,
+	ext.ErrorStack,
+	"process_id",
+	"_dd.p.tid",
+}
+
+// fieldTags holds the tags which are recorded as fields of the span rather than as tags.
+var fieldTags = []string{
+	ext.ServiceName,
+	ext.ResourceName,
+	ext.SpanType,
+	ext.Error,
+}
+
+// Option customizes the way spans are recorded.
+type Option func(*config)
+
+type config struct {
+	ignored  map[string]bool
+	scrubbed map[string]string
+	replacer []string
+	replace  func(string) string
+}
+
+// WithIgnoredTags omits the given tags from the snapshot. It is meant for tags
+// whose presence depends on the environment.
+func WithIgnoredTags(keys ...string) Option {
+	return func(cfg *config) {
+		for _, k := range keys {
+			cfg.ignored[k] = true
+		}
+	}
+}
+
+// WithScrubbedTag records the given tag with the placeholder value instead of its
+// actual value, when present. It is meant for tags having a value that changes on
+// every run, such as the port of a test server, but whose presence must be checked.
+func WithScrubbedTag(key, placeholder string) Option {
+	return func(cfg *config) {
+		cfg.scrubbed[key] = placeholder
+	}
+}
+
+// WithReplacedString replaces every occurrence of old with new in the string tags
+// of the spans. It is meant for values which change on every run and appear within
+// other tags, such as the address of a test server within a URL.
+func WithReplacedString(old, new string) Option {
+	return func(cfg *config) {
+		cfg.replacer = append(cfg.replacer, old, new)
+	}
+}
+
+// Snapshot is the recorded form of a trace tree, stripped of any IDs and timestamps.
+type Snapshot struct {
+	Name     string                 `json:"name"`
+	Resource string                 `json:"resource"`
+	Service  string                 `json:"service"`
+	Type     string                 `json:"type,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Tags     map[string]interface{} `json:"tags,omitempty"`
+	Events   []Event                `json:"events,omitempty"`
+	Links    []map[string]string    `json:"links,omitempty"`
+	Children []*Snapshot            `json:"children,omitempty"`
+}
+
+// Event is the recorded form of a span event.
+type Event struct {
+	Name       string                 `json:"name"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Assert compares the given spans against the golden file named name, or writes
+// them to it when updating. The spans are arranged as trees, and sibling trees
+// are sorted by operation name, resource and start time, so that the snapshot
+// does not depend on the order in which the spans were finished.
+func Assert(t *testing.T, name string, spans []mocktracer.Span, opts ...Option) {
+	t.Helper()
+	got, err := Marshal(spans, opts...)
+	require.NoError(t, err)
+	path := filepath.Join(Dir, name+".json")
+	if *update || internal.BoolEnv("DD_UPDATE_SNAPSHOTS", false) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		t.Logf("snapshottest: wrote %s", path)
+		return
+	}
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("snapshottest: %s does not exist; run the test with -update-snapshots to create it", path)
+	}
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got), "snapshottest: spans differ from %s; run the test with -update-snapshots if the change is expected", path)
+}
+
+// Marshal returns the indented JSON snapshot of the given spans, as it would be
+// written to a golden file.
+func Marshal(spans []mocktracer.Span, opts ...Option) ([]byte, error) {
+	cfg := &config{
+		ignored:  make(map[string]bool),
+		scrubbed: make(map[string]string),
+	}
+	for _, k := range volatileTags {
+		cfg.ignored[k] = true
+	}
+	for _, k := range fieldTags {
+		cfg.ignored[k] = true
+	}
+	for _, fn := range opts {
+		fn(cfg)
+	}
+	if len(cfg.replacer) > 0 {
+		cfg.replace = strings.NewReplacer(cfg.replacer...).Replace
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(record(spans, cfg)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// record arranges the spans as trees, using mocktracer.BuildTraces, and returns
+// the snapshots of the trees of all the traces.
+func record(spans []mocktracer.Span, cfg *config) []*Snapshot {
+	var roots []*mocktracer.SpanTree
+	for _, t := range mocktracer.BuildTraces(spans) {
+		roots = append(roots, t.Roots...)
+	}
+	return cfg.collect(roots)
+}
+
+// collect returns the sorted snapshots of the trees, along with the ones of
+// their children.
+func (cfg *config) collect(trees []*mocktracer.SpanTree) []*Snapshot {
+	type node struct {
+		tree *mocktracer.SpanTree
+		snap *Snapshot
+	}
+	nodes := make([]node, len(trees))
+	for i, t := range trees {
+		nodes[i] = node{tree: t, snap: cfg.snapshot(t.Span)}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := nodes[i], nodes[j]
+		if a.snap.Name != b.snap.Name {
+			return a.snap.Name < b.snap.Name
+		}
+		if a.snap.Resource != b.snap.Resource {
+			return a.snap.Resource < b.snap.Resource
+		}
+		return a.tree.StartTime().Before(b.tree.StartTime())
+	})
+	snaps := make([]*Snapshot, len(nodes))
+	for i, n := range nodes {
+		n.snap.Children = cfg.collect(n.tree.Children)
+		snaps[i] = n.snap
+	}
+	return snaps
+}
+
+func (cfg *config) snapshot(s mocktracer.Span) *Snapshot {
+	snap := &Snapshot{Name: s.OperationName()}
+	if v, ok := s.Tag(ext.ResourceName).(string); ok {
+		snap.Resource = v
+	}
+	if v, ok := s.Tag(ext.ServiceName).(string); ok {
+		snap.Service = v
+	}
+	if v, ok := s.Tag(ext.SpanType).(string); ok {
+		snap.Type = v
+	}
+	if err := s.Error(); err != nil {
+		snap.Error = err.Error()
+	}
+	for k, v := range s.Tags() {
+		if cfg.ignored[k] {
+			continue
+		}
+		if snap.Tags == nil {
+			snap.Tags = make(map[string]interface{})
+		}
+		if p, ok := cfg.scrubbed[k]; ok {
+			snap.Tags[k] = p
+			continue
+		}
+		if v, ok := v.(string); ok && cfg.replace != nil {
+			snap.Tags[k] = cfg.replace(v)
+			continue
+		}
+		snap.Tags[k] = value(v)
+	}
+	for _, e := range s.Events() {
+		ev := Event{Name: e.Name}
+		for k, v := range e.Attributes {
+			if ev.Attributes == nil {
+				ev.Attributes = make(map[string]interface{})
+			}
+			ev.Attributes[k] = value(v)
+		}
+		snap.Events = append(snap.Events, ev)
+	}
+	for _, l := range s.Links() {
+		// Linked span IDs are volatile, so only the attributes are recorded.
+		attrs := l.Attributes
+		if attrs == nil {
+			attrs = map[string]string{}
+		}
+		snap.Links = append(snap.Links, attrs)
+	}
+	return snap
+}
+
+// value returns v in a form which survives a JSON round trip unchanged.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string, bool, nil:
+		return v
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package snapshottest
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// genSpans finishes a small trace whose children are finished in reverse order
+// of their operation names.
+func genSpans(t *testing.T) []mocktracer.Span {
+	mt := mocktracer.Start()
+	t.Cleanup(mt.Stop)
+
+	root := tracer.StartSpan("http.request",
+		tracer.ServiceName("web"),
+		tracer.ResourceName("GET /users"),
+		tracer.SpanType(ext.SpanTypeWeb),
+		tracer.Tag(ext.HTTPURL, "http://127.0.0.1:54321/users"),
+		tracer.Tag(ext.HTTPCode, "200"),
+		tracer.Tag(ext.RuntimeID, "volatile"),
+		tracer.WithSpanLinks([]ddtrace.SpanLink{{TraceID: 1, SpanID: 2, Attributes: map[string]string{"reason": "retry"}}}),
+	)
+	redis := tracer.StartSpan("redis.command", tracer.ChildOf(root.Context()), tracer.ResourceName("GET"))
+	sql := tracer.StartSpan("postgres.query", tracer.ChildOf(root.Context()), tracer.ResourceName("SELECT 1"))
+	sql.SetTag("db.rows", 3)
+	redis.Finish(tracer.WithError(errors.New("timeout")))
+	sql.Finish()
+	root.Finish()
+	return mt.FinishedSpans()
+}
+
+func TestMarshal(t *testing.T) {
+	b, err := Marshal(genSpans(t), WithScrubbedTag(ext.HTTPURL, "http://127.0.0.1:<port>/users"))
+	require.NoError(t, err)
+
+	var snaps []*Snapshot
+	require.NoError(t, json.Unmarshal(b, &snaps))
+	require.Len(t, snaps, 1)
+	root := snaps[0]
+	assert.Equal(t, "http.request", root.Name)
+	assert.Equal(t, "GET /users", root.Resource)
+	assert.Equal(t, "web", root.Service)
+	assert.Equal(t, ext.SpanTypeWeb, root.Type)
+	assert.Equal(t, "http://127.0.0.1:<port>/users", root.Tags[ext.HTTPURL])
+	assert.Equal(t, "200", root.Tags[ext.HTTPCode])
+	assert.NotContains(t, root.Tags, ext.RuntimeID)
+	assert.NotContains(t, root.Tags, ext.ResourceName)
+	assert.Equal(t, []map[string]string{{"reason": "retry"}}, root.Links)
+
+	require.Len(t, root.Children, 2)
+	assert.Equal(t, "postgres.query", root.Children[0].Name)
+	assert.Equal(t, float64(3), root.Children[0].Tags["db.rows"])
+	assert.Equal(t, "redis.command", root.Children[1].Name)
+	assert.Equal(t, "timeout", root.Children[1].Error)
+	assert.NotContains(t, root.Children[1].Tags, ext.Error)
+}
+
+func TestMarshalIgnoredTags(t *testing.T) {
+	b, err := Marshal(genSpans(t), WithIgnoredTags(ext.HTTPURL))
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), ext.HTTPURL)
+	assert.NotContains(t, string(b), "volatile")
+}
+
+func TestMarshalReplacedString(t *testing.T) {
+	b, err := Marshal(genSpans(t), WithReplacedString("127.0.0.1:54321", "<host>"))
+	require.NoError(t, err)
+
+	var snaps []*Snapshot
+	require.NoError(t, json.Unmarshal(b, &snaps))
+	require.Len(t, snaps, 1)
+	assert.Equal(t, "http://<host>/users", snaps[0].Tags[ext.HTTPURL])
+}
+
+func TestMarshalStable(t *testing.T) {
+	a, err := Marshal(genSpans(t))
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	b, err := Marshal(genSpans(t))
+	require.NoError(t, err)
+	assert.Equal(t, string(a), string(b))
+}
+
+func TestAssert(t *testing.T) {
+	Assert(t, "basic", genSpans(t), WithScrubbedTag(ext.HTTPURL, "http://127.0.0.1:<port>/users"))
+}
@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+//go:build go1.21
+// +build go1.21
+
+package slog_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	slogtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/log/slog"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+func ExampleNewJSONHandler() {
+	// start the DataDog tracer
+	tracer.Start()
+	defer tracer.Stop()
+
+	// create the application logger
+	logger := slog.New(slogtrace.NewJSONHandler(os.Stdout, nil))
+
+	// start a new span
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "ExampleNewJSONHandler")
+	defer span.Finish()
+
+	// log a message using the context containing span information
+	logger.Log(ctx, slog.LevelInfo, "this is a log with tracing information")
+}
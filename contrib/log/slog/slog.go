@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+//go:build go1.21
+// +build go1.21
+
+// Package slog provides a log/span correlation handler for the log/slog package (https://pkg.go.dev/log/slog).
+package slog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/logtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+)
+
+const componentName = "log/slog"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+	tracer.MarkIntegrationImported("log/slog")
+}
+
+// NewJSONHandler is a convenience function that returns a *slog.JSONHandler logger enhanced with
+// tracing information.
+func NewJSONHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	return WrapHandler(slog.NewJSONHandler(w, opts))
+}
+
+// WrapHandler enhances the given slog.Handler so that the records it handles are
+// correlated to the span found in the context passed to the logger, e.g. through
+// (*slog.Logger).InfoContext. The trace and span details are always added at the
+// top level of the records, even when the logger has groups.
+func WrapHandler(h slog.Handler) slog.Handler {
+	return &handler{Handler: h}
+}
+
+type handler struct {
+	slog.Handler
+	// groups holds the groups and attributes added once the first group is
+	// opened, in order. They are applied to the records by Handle, so that the
+	// wrapped handler doesn't open the groups itself.
+	groups []groupOrAttrs
+}
+
+// groupOrAttrs is either a group opened by WithGroup or attributes added by
+// WithAttrs.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// Handle implements slog.Handler, adding the trace and span details found in ctx
+// to the record.
+func (h *handler) Handle(ctx context.Context, rec slog.Record) error {
+	fields, ok := logtrace.Fields(ctx)
+	if !ok && len(h.groups) == 0 {
+		return h.Handler.Handle(ctx, rec)
+	}
+	if len(h.groups) > 0 {
+		rec = h.groupRecord(rec)
+	} else {
+		rec = rec.Clone()
+	}
+	for _, f := range fields {
+		rec.AddAttrs(slog.String(f.Key, f.Value))
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+// groupRecord returns a copy of rec whose attributes are nested in h.groups,
+// along with the attributes added to them.
+func (h *handler) groupRecord(rec slog.Record) slog.Record {
+	attrs := make([]slog.Attr, 0, rec.NumAttrs())
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		if g := h.groups[i]; g.group != "" {
+			attrs = []slog.Attr{{Key: g.group, Value: slog.GroupValue(attrs...)}}
+		} else {
+			attrs = append(append(make([]slog.Attr, 0, len(g.attrs)+len(attrs)), g.attrs...), attrs...)
+		}
+	}
+	grouped := slog.NewRecord(rec.Time, rec.Level, rec.Message, rec.PC)
+	grouped.AddAttrs(attrs...)
+	return grouped
+}
+
+// WithAttrs implements slog.Handler.
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	if len(h.groups) == 0 {
+		return WrapHandler(h.Handler.WithAttrs(attrs))
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup implements slog.Handler.
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+func (h *handler) withGroupOrAttrs(g groupOrAttrs) *handler {
+	groups := make([]groupOrAttrs, len(h.groups)+1)
+	copy(groups, h.groups)
+	groups[len(h.groups)] = g
+	return &handler{Handler: h.Handler, groups: groups}
+}
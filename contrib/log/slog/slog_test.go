@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+//go:build go1.21
+// +build go1.21
+
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	tracer.Start(tracer.WithLogStartup(false), tracer.WithEnv("env"), tracer.WithServiceVersion("1.2.3"))
+	defer tracer.Stop()
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "testSpan", tracer.WithSpanID(1234))
+	defer span.Finish()
+
+	var buf bytes.Buffer
+	logger := slog.New(NewJSONHandler(&buf, nil))
+
+	decode := func() map[string]interface{} {
+		var out map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+		buf.Reset()
+		return out
+	}
+
+	t.Run("span", func(t *testing.T) {
+		logger.InfoContext(ctx, "message")
+		out := decode()
+		assert.Equal(t, "message", out["msg"])
+		assert.Equal(t, span.Context().(ddtrace.SpanContextW3C).TraceID128(), out["dd.trace_id"])
+		assert.Equal(t, strconv.FormatUint(span.Context().SpanID(), 10), out["dd.span_id"])
+		assert.Equal(t, "env", out["dd.env"])
+		assert.Equal(t, "1.2.3", out["dd.version"])
+	})
+
+	t.Run("no-span", func(t *testing.T) {
+		logger.InfoContext(context.Background(), "message")
+		out := decode()
+		assert.NotContains(t, out, "dd.trace_id")
+		assert.NotContains(t, out, "dd.span_id")
+	})
+
+	t.Run("with-attrs", func(t *testing.T) {
+		logger.With("key", "value").InfoContext(ctx, "message")
+		out := decode()
+		assert.Equal(t, "value", out["key"])
+		assert.Equal(t, "1234", out["dd.span_id"])
+	})
+	t.Run("with-group", func(t *testing.T) {
+		logger.With("a", "b").WithGroup("g").With("key", "value").WithGroup("h").InfoContext(ctx, "message", "k", "v")
+		out := decode()
+		assert.Equal(t, "b", out["a"])
+		assert.Equal(t, map[string]interface{}{"key": "value", "h": map[string]interface{}{"k": "v"}}, out["g"])
+		assert.Equal(t, "1234", out["dd.span_id"])
+		assert.Equal(t, "env", out["dd.env"])
+
+		logger.WithGroup("g").InfoContext(ctx, "message")
+		out = decode()
+		assert.NotContains(t, out, "g")
+		assert.Equal(t, "1234", out["dd.span_id"])
+
+		logger.WithGroup("g").InfoContext(context.Background(), "message", "k", "v")
+		out = decode()
+		assert.Equal(t, map[string]interface{}{"k": "v"}, out["g"])
+		assert.NotContains(t, out, "dd.span_id")
+	})
+}
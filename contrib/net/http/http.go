@@ -58,10 +58,11 @@ func (mux *ServeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	copy(so, mux.cfg.spanOpts)
 	so = append(so, httptrace.HeaderTagsFromRequest(r, mux.cfg.headerTags))
 	TraceAndServe(mux.ServeMux, w, r, &ServeConfig{
-		Service:  mux.cfg.serviceName,
-		Resource: resource,
-		SpanOpts: so,
-		Route:    route,
+		Service:         mux.cfg.serviceName,
+		Resource:        resource,
+		SpanOpts:        so,
+		Route:           route,
+		AppSecBodyLimit: mux.cfg.appsecBody,
 	})
 }
 
@@ -89,10 +90,11 @@ func WrapHandler(h http.Handler, service, resource string, opts ...Option) http.
 		copy(so, cfg.spanOpts)
 		so = append(so, httptrace.HeaderTagsFromRequest(req, cfg.headerTags))
 		TraceAndServe(h, w, req, &ServeConfig{
-			Service:    service,
-			Resource:   resc,
-			FinishOpts: cfg.finishOpts,
-			SpanOpts:   so,
+			Service:         service,
+			Resource:        resc,
+			FinishOpts:      cfg.finishOpts,
+			SpanOpts:        so,
+			AppSecBodyLimit: cfg.appsecBody,
 		})
 	})
 }
@@ -13,6 +13,7 @@ import (
 	"testing"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/namingschematest"
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/snapshottest"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -200,6 +201,25 @@ func TestHttpTracer500(t *testing.T) {
 	assert.Equal("net/http", s.Tag(ext.Component))
 }
 
+func TestSnapshot(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	srv := httptest.NewServer(router())
+	defer srv.Close()
+	client := WrapClient(srv.Client())
+	for _, url := range []string{"/200", "/500"} {
+		resp, err := client.Get(srv.URL + url)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	snapshottest.Assert(t, "http", mt.FinishedSpans(),
+		snapshottest.WithReplacedString(strings.TrimPrefix(srv.URL, "http://"), "<host>"),
+		snapshottest.WithScrubbedTag(ext.NetworkDestinationPort, "<port>"),
+	)
+}
+
 func TestWrapHandler200(t *testing.T) {
 	mt := mocktracer.Start()
 	defer mt.Stop()
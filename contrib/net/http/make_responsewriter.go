@@ -61,6 +61,7 @@ func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWr
 	type monitoredResponseWriter interface {
 		http.ResponseWriter
 		Status() int
+		Body() []byte
 	}
 	switch {
 {{- range .Combinations }}
@@ -28,6 +28,7 @@ type config struct {
 	ignoreRequest func(*http.Request) bool
 	resourceNamer func(*http.Request) string
 	headerTags    *internal.LockMap
+	appsecBody    int64
 }
 
 // MuxOption has been deprecated in favor of Option.
@@ -52,6 +53,15 @@ func defaults(cfg *config) {
 	cfg.resourceNamer = func(_ *http.Request) string { return "" }
 }
 
+// WithAppSecBodyParsing enables the automatic parsing of the request and response bodies
+// no larger than limit bytes, so that they are monitored by AppSec when it is enabled.
+// See ServeConfig.AppSecBodyLimit for more details.
+func WithAppSecBodyParsing(limit int64) MuxOption {
+	return func(cfg *config) {
+		cfg.appsecBody = limit
+	}
+}
+
 // WithIgnoreRequest holds the function to use for determining if the
 // incoming HTTP request should not be traced.
 func WithIgnoreRequest(f func(*http.Request) bool) MuxOption {
@@ -8,6 +8,7 @@ package http // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
 //go:generate sh -c "go run make_responsewriter.go | gofmt > trace_gen.go"
 
 import (
+	"bytes"
 	"net/http"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/httptrace"
@@ -42,6 +43,11 @@ type ServeConfig struct {
 	// in as /user/123 we'll have {"id": "123"}). This field is optional and is used for monitoring
 	// by AppSec. It is only taken into account when AppSec is enabled.
 	RouteParams map[string]string
+	// AppSecBodyLimit enables, when greater than zero, the automatic parsing of the request and
+	// response bodies no larger than this number of bytes, so that they are monitored by AppSec.
+	// Only JSON, form-urlencoded, multipart and XML bodies are parsed. The request body remains
+	// entirely readable by the handler. It is only taken into account when AppSec is enabled.
+	AppSecBodyLimit int64
 	// FinishOpts specifies any options to be used when finishing the request span.
 	FinishOpts []ddtrace.FinishOption
 	// SpanOpts specifies any options to be applied to the request starting span.
@@ -71,20 +77,27 @@ func TraceAndServe(h http.Handler, w http.ResponseWriter, r *http.Request, cfg *
 	}()
 
 	if appsec.Enabled() {
+		if cfg.AppSecBodyLimit > 0 {
+			ddrw.recordBody(cfg.AppSecBodyLimit)
+			h = httpsec.WrapBodyHandler(h, cfg.AppSecBodyLimit)
+		}
 		h = httpsec.WrapHandler(h, span, cfg.RouteParams)
 	}
 	h.ServeHTTP(rw, r.WithContext(ctx))
 }
 
 // responseWriter is a small wrapper around an http response writer that will
-// intercept and store the status of a request.
+// intercept and store the status of a request, and optionally its body.
 type responseWriter struct {
 	http.ResponseWriter
 	status int
+
+	body      *bytes.Buffer // recorded response body, if any
+	bodyLimit int64         // maximum size of the recorded body
 }
 
 func newResponseWriter(w http.ResponseWriter) *responseWriter {
-	return &responseWriter{w, 0}
+	return &responseWriter{ResponseWriter: w}
 }
 
 // Status returns the status code that was monitored.
@@ -92,6 +105,22 @@ func (w *responseWriter) Status() int {
 	return w.status
 }
 
+// recordBody starts recording the response body, as long as it is no larger
+// than limit bytes.
+func (w *responseWriter) recordBody(limit int64) {
+	w.body = new(bytes.Buffer)
+	w.bodyLimit = limit
+}
+
+// Body returns the recorded response body, or nil when it isn't recorded or is
+// larger than the limit.
+func (w *responseWriter) Body() []byte {
+	if w.body == nil {
+		return nil
+	}
+	return w.body.Bytes()
+}
+
 // Write writes the data to the connection as part of an HTTP reply.
 // We explicitly call WriteHeader with the 200 status code
 // in order to get it reported into the span.
@@ -99,6 +128,13 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	if w.status == 0 {
 		w.WriteHeader(http.StatusOK)
 	}
+	if w.body != nil {
+		if int64(w.body.Len()+len(b)) > w.bodyLimit {
+			w.body = nil
+		} else {
+			w.body.Write(b)
+		}
+	}
 	return w.ResponseWriter.Write(b)
 }
 
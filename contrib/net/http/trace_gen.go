@@ -27,6 +27,7 @@ func wrapResponseWriter(w http.ResponseWriter) (http.ResponseWriter, *responseWr
 	type monitoredResponseWriter interface {
 		http.ResponseWriter
 		Status() int
+		Body() []byte
 	}
 	switch {
 	case okFlusher && okPusher && okCloseNotifier && okHijacker:
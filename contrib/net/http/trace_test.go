@@ -164,6 +164,21 @@ func TestTraceAndServe(t *testing.T) {
 		assert.True(t, ok)
 	})
 
+	t.Run("body", func(t *testing.T) {
+		w, rw := wrapResponseWriter(httptest.NewRecorder())
+		bw, ok := w.(interface{ Body() []byte })
+		assert.True(t, ok)
+		assert.Nil(t, bw.Body())
+
+		rw.recordBody(10)
+		w.Write([]byte("Hello, "))
+		w.Write([]byte("abc"))
+		assert.Equal(t, "Hello, abc", string(bw.Body()))
+		// the body is no longer recorded once larger than the limit
+		w.Write([]byte("!"))
+		assert.Nil(t, bw.Body())
+	})
+
 	t.Run("distributed", func(t *testing.T) {
 		mt := mocktracer.Start()
 		assert := assert.New(t)
@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package zerolog_test
+
+import (
+	"context"
+	"os"
+
+	zerologtrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/rs/zerolog"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/rs/zerolog"
+)
+
+func ExampleDDContextLogHook() {
+	// start the DataDog tracer
+	tracer.Start()
+	defer tracer.Stop()
+
+	// create the application logger with the correlation hook
+	logger := zerolog.New(os.Stdout).Hook(&zerologtrace.DDContextLogHook{})
+
+	// start a new span
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "ExampleDDContextLogHook")
+	defer span.Finish()
+
+	// log a message using the context containing span information
+	logger.Info().Ctx(ctx).Msg("this is a log with tracing information")
+}
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+// Package zerolog provides a log/span correlation hook for the rs/zerolog package (https://github.com/rs/zerolog).
+package zerolog
+
+import (
+	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/logtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/rs/zerolog"
+)
+
+const componentName = "rs/zerolog"
+
+func init() {
+	telemetry.LoadIntegration(componentName)
+	tracer.MarkIntegrationImported("github.com/rs/zerolog")
+}
+
+// DDContextLogHook ensures that any span in the context of an event is correlated to log output.
+// The context is set with (*zerolog.Event).Ctx or (zerolog.Context).Ctx.
+type DDContextLogHook struct{}
+
+var _ zerolog.Hook = (*DDContextLogHook)(nil)
+
+// Run implements zerolog.Hook interface, attaches trace and span details found in the event context
+func (d *DDContextLogHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	fields, ok := logtrace.Fields(e.GetCtx())
+	if !ok {
+		return
+	}
+	for _, f := range fields {
+		e.Str(f.Key, f.Value)
+	}
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package zerolog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	tracer.Start(tracer.WithLogStartup(false), tracer.WithEnv("env"), tracer.WithServiceVersion("1.2.3"))
+	defer tracer.Stop()
+	span, ctx := tracer.StartSpanFromContext(context.Background(), "testSpan", tracer.WithSpanID(1234))
+	defer span.Finish()
+
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf).Hook(&DDContextLogHook{})
+
+	logger.Info().Ctx(ctx).Msg("message")
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, "message", out["message"])
+	assert.Equal(t, span.Context().(ddtrace.SpanContextW3C).TraceID128(), out["dd.trace_id"])
+	assert.Equal(t, strconv.FormatUint(span.Context().SpanID(), 10), out["dd.span_id"])
+	assert.Equal(t, "env", out["dd.env"])
+	assert.Equal(t, "1.2.3", out["dd.version"])
+
+	// events without a span are left untouched
+	buf.Reset()
+	logger.Info().Msg("message")
+	out = nil
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.NotContains(t, out, "dd.trace_id")
+}
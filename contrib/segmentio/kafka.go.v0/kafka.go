@@ -10,6 +10,8 @@ import (
 	"math"
 	"strings"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams/options"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -46,6 +48,7 @@ func WrapReader(c *kafka.Reader, opts ...Option) *Reader {
 	if c.Config().Brokers != nil {
 		wrapped.bootstrapServers = strings.Join(c.Config().Brokers, ",")
 	}
+	wrapped.groupID = c.Config().GroupID
 	log.Debug("contrib/segmentio/kafka-go.v0/kafka: Wrapping Reader: %#v", wrapped.cfg)
 	return wrapped
 }
@@ -53,6 +56,7 @@ func WrapReader(c *kafka.Reader, opts ...Option) *Reader {
 // A kafkaConfig struct holds information from the kafka config for span tags
 type kafkaConfig struct {
 	bootstrapServers string
+	groupID          string
 }
 
 // A Reader wraps a kafka.Reader.
@@ -115,6 +119,11 @@ func (r *Reader) ReadMessage(ctx context.Context) (kafka.Message, error) {
 		return kafka.Message{}, err
 	}
 	r.prev = r.startSpan(ctx, &msg)
+	setConsumeCheckpoint(r.cfg.dataStreamsEnabled, r.groupID, &msg)
+	if r.groupID != "" {
+		// ReadMessage commits the offset of the message when a consumer group is set
+		commitOffsets(r.cfg.dataStreamsEnabled, r.groupID, msg)
+	}
 	return msg, nil
 }
 
@@ -129,10 +138,24 @@ func (r *Reader) FetchMessage(ctx context.Context) (kafka.Message, error) {
 		return msg, err
 	}
 	r.prev = r.startSpan(ctx, &msg)
+	setConsumeCheckpoint(r.cfg.dataStreamsEnabled, r.groupID, &msg)
 	return msg, nil
 }
 
-// WrapWriter wraps a kafka.Writer so requests are traced.
+// CommitMessages calls the underlying Reader.CommitMessages and, if data
+// streams are enabled, tracks the committed offsets.
+func (r *Reader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	err := r.Reader.CommitMessages(ctx, msgs...)
+	if err == nil {
+		commitOffsets(r.cfg.dataStreamsEnabled, r.groupID, msgs...)
+	}
+	return err
+}
+
+// WrapWriter wraps a kafka.Writer so requests are traced. When data streams
+// are enabled, the offsets of the messages written by WriteMessages are tracked
+// once they are delivered, which is only known when w is not asynchronous. w is
+// not modified.
 func WrapWriter(w *kafka.Writer, opts ...Option) *Writer {
 	writer := &Writer{
 		Writer: w,
@@ -175,6 +198,7 @@ func (w *Writer) startSpan(ctx context.Context, msg *kafka.Message) ddtrace.Span
 	if err := tracer.Inject(span.Context(), carrier); err != nil {
 		log.Debug("contrib/segmentio/kafka.go.v0: Failed to inject span context into carrier in writer, %v", err)
 	}
+	setProduceCheckpoint(w.cfg.dataStreamsEnabled, w.Writer.Topic, msg)
 	return span
 }
 
@@ -196,5 +220,69 @@ func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error
 	for i, span := range spans {
 		finishSpan(span, msgs[i].Partition, msgs[i].Offset, err)
 	}
+	if w.cfg.dataStreamsEnabled && !w.Writer.Async {
+		// asynchronous writers return before the messages are delivered
+		trackProduceOffsets(msgs, err)
+	}
 	return err
 }
+
+func setConsumeCheckpoint(enabled bool, groupID string, msg *kafka.Message) {
+	if !enabled || msg == nil {
+		return
+	}
+	edges := []string{"direction:in", "topic:" + msg.Topic, "type:kafka"}
+	if groupID != "" {
+		edges = append(edges, "group:"+groupID)
+	}
+	carrier := messageCarrier{msg}
+	ctx, ok := tracer.SetDataStreamsCheckpointWithParams(datastreams.ExtractFromBase64Carrier(context.Background(), carrier), options.CheckpointParams{PayloadSize: getMsgSize(msg)}, edges...)
+	if !ok {
+		return
+	}
+	datastreams.InjectToBase64Carrier(ctx, carrier)
+}
+
+// setProduceCheckpoint sets a produce checkpoint for msg, which is written to
+// the writer's topic if set, or else to the message's topic.
+func setProduceCheckpoint(enabled bool, topic string, msg *kafka.Message) {
+	if !enabled || msg == nil {
+		return
+	}
+	if topic == "" {
+		topic = msg.Topic
+	}
+	edges := []string{"direction:out", "topic:" + topic, "type:kafka"}
+	carrier := messageCarrier{msg}
+	ctx, ok := tracer.SetDataStreamsCheckpointWithParams(datastreams.ExtractFromBase64Carrier(context.Background(), carrier), options.CheckpointParams{PayloadSize: getMsgSize(msg)}, edges...)
+	if !ok {
+		return
+	}
+	datastreams.InjectToBase64Carrier(ctx, carrier)
+}
+
+func commitOffsets(enabled bool, groupID string, msgs ...kafka.Message) {
+	if !enabled || groupID == "" {
+		return
+	}
+	for _, msg := range msgs {
+		tracer.TrackKafkaCommitOffset(groupID, msg.Topic, int32(msg.Partition), msg.Offset)
+	}
+}
+
+func trackProduceOffsets(msgs []kafka.Message, err error) {
+	if err != nil {
+		// we only track Kafka lag if messages have been sent successfully. Otherwise, we have no way to know to which partition data was sent to.
+		return
+	}
+	for _, msg := range msgs {
+		tracer.TrackKafkaProduceOffset(msg.Topic, int32(msg.Partition), msg.Offset)
+	}
+}
+
+func getMsgSize(msg *kafka.Message) (size int64) {
+	for _, header := range msg.Headers {
+		size += int64(len(header.Key) + len(header.Value))
+	}
+	return size + int64(len(msg.Value)+len(msg.Key))
+}
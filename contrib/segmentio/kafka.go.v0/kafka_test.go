@@ -12,8 +12,10 @@ import (
 	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/contrib/internal/namingschematest"
+	"gopkg.in/DataDog/dd-trace-go.v1/datastreams"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 
 	kafka "github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
@@ -94,12 +96,14 @@ func TestReadMessageFunctional(t *testing.T) {
 			readMsg, err := r.ReadMessage(ctx)
 			require.NoError(t, err, "Expected to consume message")
 			assert.Equal(t, testMessages[0].Value, readMsg.Value, "Values should be equal")
+			_, ok := datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), messageCarrier{&readMsg}))
+			assert.True(t, ok, "Expected a data streams pathway")
 
 			err = r.CommitMessages(context.Background(), readMsg)
 			assert.NoError(t, err, "Expected CommitMessages to not return an error")
 		},
-		[]Option{WithAnalyticsRate(0.1)},
-		[]Option{},
+		[]Option{WithAnalyticsRate(0.1), WithDataStreams()},
+		[]Option{WithDataStreams()},
 	)
 
 	// producer span
@@ -233,3 +237,38 @@ func BenchmarkWriterStartSpan(b *testing.B) {
 		w.startSpan(nil, &testMessages[0])
 	}
 }
+
+func TestDataStreamsCheckpoints(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	msg := kafka.Message{Key: []byte("key1"), Value: []byte("value1")}
+	setProduceCheckpoint(true, testTopic, &msg)
+	p, ok := datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), messageCarrier{&msg}))
+	require.True(t, ok)
+	expectedCtx, _ := tracer.SetDataStreamsCheckpoint(context.Background(), "direction:out", "topic:"+testTopic, "type:kafka")
+	expected, _ := datastreams.PathwayFromContext(expectedCtx)
+	assert.NotEqual(t, expected.GetHash(), 0)
+	assert.Equal(t, expected.GetHash(), p.GetHash())
+
+	// the consumer reads the message with the pathway set by the producer
+	msg.Topic = testTopic
+	setConsumeCheckpoint(true, testGroupID, &msg)
+	p, ok = datastreams.PathwayFromContext(datastreams.ExtractFromBase64Carrier(context.Background(), messageCarrier{&msg}))
+	require.True(t, ok)
+	expectedCtx, _ = tracer.SetDataStreamsCheckpoint(expectedCtx, "direction:in", "topic:"+testTopic, "type:kafka", "group:"+testGroupID)
+	expected, _ = datastreams.PathwayFromContext(expectedCtx)
+	assert.Equal(t, expected.GetHash(), p.GetHash())
+
+	// checkpoints are only set when data streams are enabled
+	msg = kafka.Message{Value: []byte("value1")}
+	setProduceCheckpoint(false, testTopic, &msg)
+	assert.Empty(t, msg.Headers)
+}
+
+func TestWrapWriterCompletion(t *testing.T) {
+	// the writer of the caller is not modified
+	w := &kafka.Writer{Addr: kafka.TCP("localhost:9092")}
+	WrapWriter(w, WithDataStreams())
+	assert.Nil(t, w.Completion)
+}
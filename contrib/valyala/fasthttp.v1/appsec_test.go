@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package fasthttp
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
+
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+)
+
+// serve serves a request to uri with the given headers using h.
+func serve(h fasthttp.RequestHandler, uri string, headers map[string]string) *fasthttp.RequestCtx {
+	var req fasthttp.Request
+	req.SetRequestURI(uri)
+	req.SetHost("localhost")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	var fctx fasthttp.RequestCtx
+	fctx.Init(&req, &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, nil)
+	h(&fctx)
+	return &fctx
+}
+
+func TestAppSec(t *testing.T) {
+	appsec.Start()
+	defer appsec.Stop()
+
+	if !appsec.Enabled() {
+		t.Skip("appsec disabled")
+	}
+
+	h := WrapHandler(func(fctx *fasthttp.RequestCtx) {
+		fctx.SetStatusCode(fasthttp.StatusNotFound)
+	})
+
+	// Test an LFI attack via the request URI
+	t.Run("request-uri", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		// Send an LFI attack (according to appsec rule id crs-930-110)
+		fctx := serve(h, "/../../../secret.txt", nil)
+		require.Equal(t, fasthttp.StatusNotFound, fctx.Response.StatusCode())
+		// The span should contain the security event
+		finished := mt.FinishedSpans()
+		require.Len(t, finished, 1)
+		event := finished[0].Tag("_dd.appsec.json").(string)
+		require.True(t, strings.Contains(event, "crs-930-110"))
+		require.True(t, strings.Contains(event, "server.request.uri.raw"))
+	})
+
+	// Test a security scanner attack via the request headers
+	t.Run("headers", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		// Send a security scanner attack (according to appsec rule id ua0-600-55x)
+		fctx := serve(h, "/", map[string]string{"User-Agent": "Arachni/v1"})
+		require.Equal(t, fasthttp.StatusNotFound, fctx.Response.StatusCode())
+		finished := mt.FinishedSpans()
+		require.Len(t, finished, 1)
+		event := finished[0].Tag("_dd.appsec.json").(string)
+		require.True(t, strings.Contains(event, "ua0-600-55x"))
+		require.True(t, strings.Contains(event, "server.request.headers.no_cookies"))
+	})
+}
+
+// Test that IP and query blocking works by using custom rules/rules data
+func TestBlocking(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "../../../internal/appsec/testdata/blocking.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("appsec disabled")
+	}
+
+	var called bool
+	h := WrapHandler(func(fctx *fasthttp.RequestCtx) {
+		called = true
+		fctx.SetBodyString("Hello World!\n")
+	})
+
+	for _, tc := range []struct {
+		name    string
+		uri     string
+		headers map[string]string
+		blocked bool
+	}{
+		{name: "ip/no-block", uri: "/", headers: map[string]string{"x-forwarded-for": "1.2.3.5"}},
+		{name: "ip/block", uri: "/", headers: map[string]string{"x-forwarded-for": "1.2.3.4"}, blocked: true},
+		{name: "query/no-block", uri: "/?x=value"},
+		{name: "query/block", uri: "/?x=$globals", blocked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			called = false
+			headers := map[string]string{"Accept": "application/json"}
+			for k, v := range tc.headers {
+				headers[k] = v
+			}
+			fctx := serve(h, tc.uri, headers)
+			spans := mt.FinishedSpans()
+			require.Len(t, spans, 1)
+			if tc.blocked {
+				require.False(t, called)
+				require.Equal(t, fasthttp.StatusForbidden, fctx.Response.StatusCode())
+				require.Equal(t, "application/json", string(fctx.Response.Header.ContentType()))
+				require.Contains(t, string(fctx.Response.Body()), "Security provided by Datadog")
+				require.Equal(t, true, spans[0].Tag("appsec.blocked"))
+				require.Equal(t, "403", spans[0].Tag("http.status_code"))
+			} else {
+				require.True(t, called)
+				require.Equal(t, fasthttp.StatusOK, fctx.Response.StatusCode())
+				require.Equal(t, "Hello World!\n", string(fctx.Response.Body()))
+				require.Nil(t, spans[0].Tag("appsec.blocked"))
+			}
+		})
+	}
+}
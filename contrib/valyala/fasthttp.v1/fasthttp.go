@@ -7,6 +7,7 @@
 package fasthttp // import "gopkg.in/DataDog/dd-trace-go.v1/contrib/valyala/fasthttp.v1"
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -15,6 +16,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
 )
@@ -26,7 +28,9 @@ func init() {
 	tracer.MarkIntegrationImported(componentName)
 }
 
-// WrapHandler wraps a fasthttp.RequestHandler with tracing middleware
+// WrapHandler wraps a fasthttp.RequestHandler with tracing middleware. When
+// AppSec is enabled, requests are also monitored, and blocked or redirected
+// according to the security rules.
 func WrapHandler(h fasthttp.RequestHandler, opts ...Option) fasthttp.RequestHandler {
 	cfg := newConfig()
 	for _, fn := range opts {
@@ -50,7 +54,14 @@ func WrapHandler(h fasthttp.RequestHandler, opts ...Option) fasthttp.RequestHand
 		}
 		span := fasthttptrace.StartSpanFromContext(fctx, "http.request", spanOpts...)
 		defer span.Finish()
-		h(fctx)
+		if appsec.Enabled() {
+			fasthttptrace.MonitorAppSec(fctx, fctx, span, nil, nil, func(context.Context) error {
+				h(fctx)
+				return nil
+			})
+		} else {
+			h(fctx)
+		}
 		span.SetTag(ext.ResourceName, cfg.resourceNamer(fctx))
 		status := fctx.Response.StatusCode()
 		if cfg.isStatusError(status) {
@@ -77,6 +77,18 @@ type Span interface {
 	Context() SpanContext
 }
 
+// SpanWithEvents represents a Span which is able to record timestamped events
+// occurring during its lifetime, such as a cache miss or a retry. It is an
+// optional interface: callers should check whether a Span implements it.
+type SpanWithEvents interface {
+	Span
+
+	// AddEvent attaches an event with the given name to the span. By default,
+	// the event is timestamped with the current time. Events added after the
+	// span is finished are discarded.
+	AddEvent(name string, opts ...SpanEventOption)
+}
+
 // SpanContext represents a span state that can propagate to descendant spans
 // and across process boundaries. It contains all the information needed to
 // spawn a direct descendant of the span that it belongs to. It can be used
@@ -140,6 +152,20 @@ type FinishConfig struct {
 	SkipStackFrames uint
 }
 
+// SpanEventOption is a configuration option that can be used when adding an event to a Span.
+type SpanEventOption func(cfg *SpanEventConfig)
+
+// SpanEventConfig holds the configuration for adding an event to a span. It is usually passed
+// around by reference to one or more SpanEventOption functions which shape it into its final form.
+type SpanEventConfig struct {
+	// Time holds the time at which the event occurred. Implementations should use
+	// the current time when Time.IsZero().
+	Time time.Time
+
+	// Attributes holds a set of key/value pairs that should be attached to the event.
+	Attributes map[string]interface{}
+}
+
 // StartSpanConfig holds the configuration for starting a new span. It is usually passed
 // around by reference to one or more StartSpanOption functions which shape it into its
 // final form.
@@ -25,3 +25,20 @@ func Example() {
 
 	// Run assertions...
 }
+
+func Example_finishedTraces() {
+	// Start the mock tracer.
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	// ...run some code with generates spans.
+
+	// Query the mock tracer for finished traces, with their spans arranged as trees.
+	for _, trace := range mt.FinishedTraces() {
+		// Expect exactly one root span with resource "GET /" and an error.
+		roots := mocktracer.Match(trace.RootSpans(), mocktracer.MatchResource("GET /"), mocktracer.MatchError())
+		if len(roots) != 1 {
+			panic("expected 1 root span with an error:\n" + trace.String())
+		}
+	}
+}
@@ -6,6 +6,7 @@
 package mocktracer // import "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -16,6 +17,7 @@ import (
 )
 
 var _ ddtrace.Span = (*mockspan)(nil)
+var _ ddtrace.SpanWithEvents = (*mockspan)(nil)
 var _ Span = (*mockspan)(nil)
 
 // Span is an interface that allows querying a span returned by the mock tracer.
@@ -44,6 +46,23 @@ type Span interface {
 	// Tags returns a copy of all the tags in this span.
 	Tags() map[string]interface{}
 
+	// Events returns a copy of the events added to this span, in the order
+	// in which they were added.
+	Events() []ddtrace.SpanEvent
+
+	// Links returns a copy of the span links given to this span through
+	// tracer.WithSpanLinks when it was started.
+	Links() []ddtrace.SpanLink
+
+	// SamplingPriority returns the sampling priority of the span's trace, and
+	// whether one was set.
+	SamplingPriority() (priority int, ok bool)
+
+	// Error returns the error recorded on the span, or nil if the span has no
+	// error. Errors set as a boolean or a string are reported using the
+	// error message tag when there is one.
+	Error() error
+
 	// Context returns the span's SpanContext.
 	Context() ddtrace.SpanContext
 
@@ -93,6 +112,10 @@ func newSpan(t *mocktracer, operationName string, cfg *ddtrace.StartSpanConfig)
 	for k, v := range cfg.Tags {
 		s.SetTag(k, v)
 	}
+	if len(cfg.SpanLinks) > 0 {
+		s.links = make([]ddtrace.SpanLink, len(cfg.SpanLinks))
+		copy(s.links, cfg.SpanLinks)
+	}
 	return s
 }
 
@@ -108,6 +131,7 @@ type mockspan struct {
 	context   *spanContext
 	tracer    *mocktracer
 	links     []ddtrace.SpanLink
+	events    []ddtrace.SpanEvent
 }
 
 // SetTag sets a given tag on the span.
@@ -156,6 +180,78 @@ func (s *mockspan) Tags() map[string]interface{} {
 	return cp
 }
 
+// AddEvent attaches an event with the given name to the span.
+func (s *mockspan) AddEvent(name string, opts ...ddtrace.SpanEventOption) {
+	var cfg ddtrace.SpanEventConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	if cfg.Time.IsZero() {
+		cfg.Time = time.Now()
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.finished {
+		return
+	}
+	s.events = append(s.events, ddtrace.SpanEvent{
+		Name:         name,
+		TimeUnixNano: uint64(cfg.Time.UnixNano()),
+		Attributes:   cfg.Attributes,
+	})
+}
+
+// Events returns a copy of the events added to the span, in the order in which
+// they were added.
+func (s *mockspan) Events() []ddtrace.SpanEvent {
+	s.RLock()
+	defer s.RUnlock()
+	// copy
+	cp := make([]ddtrace.SpanEvent, len(s.events))
+	copy(cp, s.events)
+	return cp
+}
+
+func (s *mockspan) Links() []ddtrace.SpanLink {
+	s.RLock()
+	defer s.RUnlock()
+	// copy
+	cp := make([]ddtrace.SpanLink, len(s.links))
+	copy(cp, s.links)
+	return cp
+}
+
+func (s *mockspan) SamplingPriority() (int, bool) {
+	return s.context.samplingPriority(), s.context.hasSamplingPriority()
+}
+
+func (s *mockspan) Error() error {
+	s.RLock()
+	defer s.RUnlock()
+	msg, _ := s.tags[ext.ErrorMsg].(string)
+	switch v := s.tags[ext.Error].(type) {
+	case error:
+		return v
+	case bool:
+		if !v {
+			return nil
+		}
+	case string:
+		if v == "" || v == "false" {
+			return nil
+		}
+		if msg == "" {
+			msg = v
+		}
+	case nil:
+		return nil
+	}
+	if msg == "" {
+		msg = "error"
+	}
+	return errors.New(msg)
+}
+
 func (s *mockspan) TraceID() uint64 { return s.context.traceID }
 
 func (s *mockspan) SpanID() uint64 { return s.context.spanID }
@@ -230,7 +326,9 @@ id: %d
 parent: %d
 trace: %d
 baggage: %#v
-`, s.name, s.tags, s.startTime, s.finishTime, sc.spanID, s.parentID, sc.traceID, sc.baggage)
+events: %#v
+links: %#v
+`, s.name, s.tags, s.startTime, s.finishTime, sc.spanID, s.parentID, sc.traceID, sc.baggage, s.events, s.links)
 }
 
 // Context returns the SpanContext of this Span.
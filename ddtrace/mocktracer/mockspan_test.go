@@ -192,6 +192,80 @@ func TestSpanFinishTwice(t *testing.T) {
 	assert.Equal(len(s.tracer.finishedSpans), 1)
 }
 
+func TestSpanEvents(t *testing.T) {
+	s := basicSpan("http.request")
+	ts := time.Now().Add(-time.Second)
+	tracer.AddSpanEvent(s, "cache miss", tracer.EventTime(ts), tracer.EventAttributes(map[string]interface{}{"key": "a"}))
+	s.AddEvent("retry")
+	s.Finish()
+	s.AddEvent("ignored")
+
+	assert := assert.New(t)
+	events := s.Events()
+	require.Len(t, events, 2)
+	assert.Equal(ddtrace.SpanEvent{
+		Name:         "cache miss",
+		TimeUnixNano: uint64(ts.UnixNano()),
+		Attributes:   map[string]interface{}{"key": "a"},
+	}, events[0])
+	assert.Equal("retry", events[1].Name)
+	assert.NotZero(events[1].TimeUnixNano)
+
+	// the returned slice is a copy
+	events[0].Name = "changed"
+	assert.Equal("cache miss", s.Events()[0].Name)
+}
+
+func TestSpanLinks(t *testing.T) {
+	links := []ddtrace.SpanLink{{TraceID: 1, SpanID: 2, Attributes: map[string]string{"key": "a"}}}
+	s := newMockTracer().StartSpan("http.request", tracer.WithSpanLinks(links)).(*mockspan)
+
+	assert := assert.New(t)
+	assert.Equal(links, s.Links())
+
+	// the returned slice is a copy
+	s.Links()[0].SpanID = 3
+	assert.Equal(uint64(2), s.Links()[0].SpanID)
+	assert.Empty(basicSpan("http.request").Links())
+}
+
+func TestSpanSamplingPriority(t *testing.T) {
+	assert := assert.New(t)
+	s := basicSpan("http.request")
+	_, ok := s.SamplingPriority()
+	assert.False(ok)
+
+	s.SetTag(ext.SamplingPriority, ext.PriorityUserKeep)
+	p, ok := s.SamplingPriority()
+	assert.True(ok)
+	assert.Equal(ext.PriorityUserKeep, p)
+
+	// children inherit the priority of their parent
+	child := newSpan(&mocktracer{}, "child", &ddtrace.StartSpanConfig{Parent: s.Context()})
+	p, ok = child.SamplingPriority()
+	assert.True(ok)
+	assert.Equal(ext.PriorityUserKeep, p)
+}
+
+func TestSpanError(t *testing.T) {
+	assert := assert.New(t)
+	s := basicSpan("http.request")
+	assert.NoError(s.Error())
+
+	err := errors.New("some error")
+	s.Finish(tracer.WithError(err))
+	assert.Equal(err, s.Error())
+
+	s = basicSpan("http.request")
+	s.SetTag(ext.Error, true)
+	s.SetTag(ext.ErrorMsg, "boom")
+	assert.EqualError(s.Error(), "boom")
+
+	s = basicSpan("http.request")
+	s.SetTag(ext.Error, false)
+	assert.NoError(s.Error())
+}
+
 func TestSpanString(t *testing.T) {
 	s := basicSpan("http.request")
 	s.Finish(tracer.WithError(errors.New("some error")))
@@ -37,6 +37,11 @@ type Tracer interface {
 
 	// FinishedSpans returns the set of finished spans.
 	FinishedSpans() []Span
+
+	// FinishedTraces returns the finished spans grouped by trace ID and
+	// arranged as parent/child trees, in the order in which the traces
+	// had a first span finished.
+	FinishedTraces() []*Trace
 	SentDSMBacklogs() []datastreams.Backlog
 
 	// Reset resets the spans and services recorded in the tracer. This is
@@ -126,6 +131,10 @@ func (t *mocktracer) FinishedSpans() []Span {
 	return t.finishedSpans
 }
 
+func (t *mocktracer) FinishedTraces() []*Trace {
+	return BuildTraces(t.FinishedSpans())
+}
+
 func (t *mocktracer) Reset() {
 	t.Lock()
 	defer t.Unlock()
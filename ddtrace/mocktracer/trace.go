@@ -0,0 +1,176 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+)
+
+// Trace holds the finished spans sharing a trace ID, arranged as trees.
+type Trace struct {
+	// TraceID is the ID shared by the spans of the trace.
+	TraceID uint64
+
+	// Spans holds the finished spans of the trace, in the order in which
+	// they were finished.
+	Spans []Span
+
+	// Roots holds the trees of the trace, ordered by start time. A span is
+	// the root of a tree when it has no parent, or when its parent is not
+	// among the finished spans of the trace.
+	Roots []*SpanTree
+}
+
+// SpanTree is a finished span along with its finished children.
+type SpanTree struct {
+	Span
+
+	// Children holds the trees of the span's children, ordered by start time.
+	Children []*SpanTree
+}
+
+// RootSpans returns the spans at the root of the trees of the trace.
+func (t *Trace) RootSpans() []Span {
+	spans := make([]Span, len(t.Roots))
+	for i, r := range t.Roots {
+		spans[i] = r.Span
+	}
+	return spans
+}
+
+// Walk calls fn for every span of the trace, depth-first, along with its depth
+// in its tree. Walking stops when fn returns false.
+func (t *Trace) Walk(fn func(s *SpanTree, depth int) bool) {
+	for _, r := range t.Roots {
+		if !r.walk(fn, 0) {
+			return
+		}
+	}
+}
+
+func (st *SpanTree) walk(fn func(s *SpanTree, depth int) bool, depth int) bool {
+	if !fn(st, depth) {
+		return false
+	}
+	for _, c := range st.Children {
+		if !c.walk(fn, depth+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the trees of the trace, one span per line, indented by depth.
+// It is meant for debugging failed assertions.
+func (t *Trace) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "trace %d\n", t.TraceID)
+	t.Walk(func(s *SpanTree, depth int) bool {
+		fmt.Fprintf(&b, "%s- %s (resource: %v, id: %d)\n", strings.Repeat("  ", depth), s.OperationName(), s.Tag(ext.ResourceName), s.SpanID())
+		return true
+	})
+	return b.String()
+}
+
+// BuildTraces groups the given spans by trace ID, in the order in which the
+// traces were first seen, and arranges the spans of every trace as trees. It is
+// what FinishedTraces uses on the finished spans of the mock tracer.
+func BuildTraces(spans []Span) []*Trace {
+	var traces []*Trace
+	byID := make(map[uint64]*Trace)
+	for _, s := range spans {
+		t, ok := byID[s.TraceID()]
+		if !ok {
+			t = &Trace{TraceID: s.TraceID()}
+			byID[s.TraceID()] = t
+			traces = append(traces, t)
+		}
+		t.Spans = append(t.Spans, s)
+	}
+	for _, t := range traces {
+		nodes := make(map[uint64]*SpanTree, len(t.Spans))
+		for _, s := range t.Spans {
+			nodes[s.SpanID()] = &SpanTree{Span: s}
+		}
+		for _, s := range t.Spans {
+			n := nodes[s.SpanID()]
+			if parent, ok := nodes[s.ParentID()]; ok && s.ParentID() != s.SpanID() {
+				parent.Children = append(parent.Children, n)
+			} else {
+				t.Roots = append(t.Roots, n)
+			}
+		}
+		sortTrees(t.Roots)
+		for _, n := range nodes {
+			sortTrees(n.Children)
+		}
+	}
+	return traces
+}
+
+func sortTrees(trees []*SpanTree) {
+	sort.SliceStable(trees, func(i, j int) bool {
+		return trees[i].StartTime().Before(trees[j].StartTime())
+	})
+}
+
+// SpanMatcher reports whether a span satisfies a condition.
+type SpanMatcher func(s Span) bool
+
+// MatchOperationName matches the spans having the given operation name.
+func MatchOperationName(name string) SpanMatcher {
+	return func(s Span) bool { return s.OperationName() == name }
+}
+
+// MatchResource matches the spans having the given resource name.
+func MatchResource(resource string) SpanMatcher {
+	return MatchTag(ext.ResourceName, resource)
+}
+
+// MatchService matches the spans having the given service name.
+func MatchService(service string) SpanMatcher {
+	return MatchTag(ext.ServiceName, service)
+}
+
+// MatchTag matches the spans having the tag k set to v. The value v must be
+// comparable.
+func MatchTag(k string, v interface{}) SpanMatcher {
+	return func(s Span) bool { return s.Tag(k) == v }
+}
+
+// MatchError matches the spans having an error.
+func MatchError() SpanMatcher {
+	return func(s Span) bool { return s.Error() != nil }
+}
+
+// MatchRoot matches the spans having no parent.
+func MatchRoot() SpanMatcher {
+	return func(s Span) bool { return s.ParentID() == 0 }
+}
+
+// Match returns the spans satisfying all the given matchers.
+func Match(spans []Span, matchers ...SpanMatcher) []Span {
+	var matched []Span
+	for _, s := range spans {
+		if matchAll(s, matchers) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
+
+func matchAll(s Span, matchers []SpanMatcher) bool {
+	for _, m := range matchers {
+		if !m(s) {
+			return false
+		}
+	}
+	return true
+}
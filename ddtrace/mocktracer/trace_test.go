@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package mocktracer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinishedTraces(t *testing.T) {
+	mt := newMockTracer()
+	start := time.Now()
+
+	root := mt.StartSpan("http.request", tracer.ResourceName("GET /"), tracer.StartTime(start))
+	db := mt.StartSpan("db.query", tracer.ChildOf(root.Context()), tracer.StartTime(start.Add(2*time.Millisecond)))
+	cache := mt.StartSpan("cache.get", tracer.ChildOf(root.Context()), tracer.StartTime(start.Add(time.Millisecond)))
+	conn := mt.StartSpan("db.connect", tracer.ChildOf(db.Context()), tracer.StartTime(start.Add(3*time.Millisecond)))
+	other := mt.StartSpan("worker", tracer.ResourceName("job"))
+
+	conn.Finish()
+	db.Finish()
+	other.Finish(tracer.WithError(errors.New("failed")))
+	cache.Finish()
+	root.Finish()
+
+	traces := mt.FinishedTraces()
+	require.Len(t, traces, 2)
+
+	assert := assert.New(t)
+	tr := traces[0]
+	assert.Equal(root.Context().TraceID(), tr.TraceID)
+	assert.Len(tr.Spans, 4)
+	require.Len(t, tr.Roots, 1)
+	assert.Equal(root, tr.Roots[0].Span)
+	children := tr.Roots[0].Children
+	require.Len(t, children, 2)
+	// children are ordered by start time
+	assert.Equal(cache, children[0].Span)
+	assert.Equal(db, children[1].Span)
+	require.Len(t, children[1].Children, 1)
+	assert.Equal(conn, children[1].Children[0].Span)
+
+	var walked []string
+	tr.Walk(func(s *SpanTree, depth int) bool {
+		walked = append(walked, s.OperationName())
+		return true
+	})
+	assert.Equal([]string{"http.request", "cache.get", "db.query", "db.connect"}, walked)
+	assert.Contains(tr.String(), "\n    - db.connect")
+
+	assert.Equal([]Span{other.(Span)}, traces[1].RootSpans())
+}
+
+func TestFinishedTracesMissingParent(t *testing.T) {
+	mt := newMockTracer()
+	root := mt.StartSpan("root")
+	child := mt.StartSpan("child", tracer.ChildOf(root.Context()))
+	child.Finish()
+
+	// the parent is not finished, so the child is the root of its tree
+	traces := mt.FinishedTraces()
+	require.Len(t, traces, 1)
+	assert.Equal(t, []Span{child.(Span)}, traces[0].RootSpans())
+}
+
+func TestMatch(t *testing.T) {
+	mt := newMockTracer()
+	root := mt.StartSpan("http.request", tracer.ResourceName("GET /"), tracer.ServiceName("web"))
+	child := mt.StartSpan("db.query", tracer.ChildOf(root.Context()), tracer.ResourceName("SELECT"))
+	child.Finish()
+	root.Finish(tracer.WithError(errors.New("failed")))
+
+	assert := assert.New(t)
+	spans := mt.FinishedSpans()
+	assert.Equal([]Span{root.(Span)}, Match(spans, MatchRoot(), MatchResource("GET /"), MatchError()))
+	// the child inherits the service of its parent
+	assert.Len(Match(spans, MatchService("web")), 2)
+	assert.Equal([]Span{child.(Span)}, Match(spans, MatchOperationName("db.query")))
+	assert.Empty(Match(spans, MatchResource("SELECT"), MatchError()))
+	assert.Len(Match(spans), 2)
+	assert.Equal([]Span{child.(Span)}, Match(spans, MatchTag("resource.name", "SELECT")))
+}
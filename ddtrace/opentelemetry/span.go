@@ -8,6 +8,8 @@ package opentelemetry
 import (
 	"encoding/binary"
 	"errors"
+	"reflect"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,6 +35,8 @@ type span struct {
 	attributes map[string]interface{}
 	spanKind   oteltrace.SpanKind
 	finishOpts []tracer.FinishOption
+	// errorRecorded is true once RecordError has set the error details on DD.
+	errorRecorded bool
 	statusInfo
 	*oteltracer
 }
@@ -74,8 +78,14 @@ func (s *span) End(options ...oteltrace.SpanEndOption) {
 	var finishCfg = oteltrace.NewSpanEndConfig(options...)
 	var opts []tracer.FinishOption
 	if s.statusInfo.code == otelcodes.Error {
-		s.DD.SetTag(ext.ErrorMsg, s.statusInfo.description)
-		opts = append(opts, tracer.WithError(errors.New(s.statusInfo.description)))
+		if s.errorRecorded {
+			// keep the details of the error recorded with RecordError and
+			// only flag the span as erroneous
+			s.DD.SetTag(ext.Error, true)
+		} else {
+			s.DD.SetTag(ext.ErrorMsg, s.statusInfo.description)
+			opts = append(opts, tracer.WithError(errors.New(s.statusInfo.description)))
+		}
 	}
 	if t := finishCfg.Timestamp(); !t.IsZero() {
 		opts = append(opts, tracer.FinishTime(t))
@@ -152,6 +162,68 @@ func (s *span) IsRecording() bool {
 	return !s.finished
 }
 
+// AddEvent adds an event with the provided name and options to the underlying
+// Datadog span. The event attributes are kept as provided.
+func (s *span) AddEvent(name string, options ...oteltrace.EventOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	s.addEvent(name, oteltrace.NewEventConfig(options...))
+}
+
+// addEvent adds an event to the underlying Datadog span. s.mu must be held.
+func (s *span) addEvent(name string, cfg oteltrace.EventConfig) {
+	dds, ok := s.DD.(ddtrace.SpanWithEvents)
+	if !ok {
+		return
+	}
+	opts := []ddtrace.SpanEventOption{tracer.EventTime(cfg.Timestamp())}
+	if attrs := cfg.Attributes(); len(attrs) > 0 {
+		m := make(map[string]interface{}, len(attrs))
+		for _, kv := range attrs {
+			m[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		opts = append(opts, tracer.EventAttributes(m))
+	}
+	dds.AddEvent(name, opts...)
+}
+
+// RecordError sets the error.message and error.type tags of the underlying
+// Datadog span from err, along with the error.stack tag when the
+// oteltrace.WithStackTrace option is used, and records an "exception" event
+// following the OpenTelemetry semantic conventions. As per the OpenTelemetry
+// specification, it does not change the status of the span: SetStatus should
+// be used to mark the span as erroneous. It has no effect if err is nil.
+func (s *span) RecordError(err error, options ...oteltrace.EventOption) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.finished {
+		return
+	}
+	cfg := oteltrace.NewEventConfig(options...)
+	typ := reflect.TypeOf(err).String()
+	s.DD.SetTag(ext.ErrorMsg, err.Error())
+	s.DD.SetTag(ext.ErrorType, typ)
+	s.errorRecorded = true
+
+	attrs := []attribute.KeyValue{
+		attribute.String("exception.type", typ),
+		attribute.String("exception.message", err.Error()),
+	}
+	if cfg.StackTrace() {
+		stack := string(debug.Stack())
+		s.DD.SetTag(ext.ErrorStack, stack)
+		attrs = append(attrs, attribute.String("exception.stacktrace", stack))
+	}
+	options = append(options, oteltrace.WithAttributes(attrs...))
+	s.addEvent("exception", oteltrace.NewEventConfig(options...))
+}
+
 type statusInfo struct {
 	code        otelcodes.Code
 	description string
@@ -185,6 +185,110 @@ func TestSpanLink(t *testing.T) {
 	assert.Equal(uint32(0x80000001), spanLinks[0].Flags) // sampled and set
 }
 
+func TestSpanAddEvent(t *testing.T) {
+	assert := assert.New(t)
+	_, payloads, cleanup := mockTracerProvider(t)
+	tr := otel.Tracer("")
+	defer cleanup()
+
+	ts := time.Unix(1700000000, 0) // representable as float64 without loss of precision
+	_, sp := tr.Start(context.Background(), "span_with_events")
+	sp.AddEvent("cache miss", oteltrace.WithTimestamp(ts), oteltrace.WithAttributes(
+		attribute.String("cache.key", "user:1"),
+		attribute.Int("attempt", 2),
+	))
+	sp.AddEvent("retry")
+	sp.End()
+	// events added after the span has finished are ignored
+	sp.AddEvent("ignored")
+
+	tracer.Flush()
+	payload, err := waitForPayload(payloads)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	assert.Len(payload, 1)
+	assert.Len(payload[0], 1)
+
+	var events []ddtrace.SpanEvent
+	b, _ := json.Marshal(payload[0][0]["span_events"])
+	json.Unmarshal(b, &events)
+	if !assert.Len(events, 2) {
+		return
+	}
+	assert.Equal("cache miss", events[0].Name)
+	assert.Equal(uint64(ts.UnixNano()), events[0].TimeUnixNano)
+	assert.Equal(map[string]interface{}{"cache.key": "user:1", "attempt": 2.0}, events[0].Attributes)
+	assert.Equal("retry", events[1].Name)
+	assert.NotZero(events[1].TimeUnixNano)
+	assert.Empty(events[1].Attributes)
+}
+
+func TestSpanRecordError(t *testing.T) {
+	assert := assert.New(t)
+	_, payloads, cleanup := mockTracerProvider(t)
+	tr := otel.Tracer("")
+	defer cleanup()
+
+	_, sp := tr.Start(context.Background(), "span_with_error")
+	sp.RecordError(nil)
+	sp.RecordError(errors.New("boom"), oteltrace.WithStackTrace(true))
+	sp.SetStatus(codes.Error, "request failed") // doesn't override the recorded error
+	sp.End()
+
+	tracer.Flush()
+	payload, err := waitForPayload(payloads)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	p := payload[0][0]
+	assert.Equal(1.0, p["error"])
+	meta := p["meta"].(map[string]interface{})
+	assert.Equal("boom", meta[ext.ErrorMsg])
+	assert.Equal("*errors.errorString", meta[ext.ErrorType])
+	assert.Contains(meta[ext.ErrorStack], "TestSpanRecordError")
+
+	var events []ddtrace.SpanEvent
+	b, _ := json.Marshal(p["span_events"])
+	json.Unmarshal(b, &events)
+	if !assert.Len(events, 1) {
+		return
+	}
+	assert.Equal("exception", events[0].Name)
+	assert.Equal("boom", events[0].Attributes["exception.message"])
+	assert.Equal("*errors.errorString", events[0].Attributes["exception.type"])
+	assert.Contains(events[0].Attributes["exception.stacktrace"], "TestSpanRecordError")
+}
+
+func TestSpanRecordErrorNoStackTrace(t *testing.T) {
+	assert := assert.New(t)
+	_, payloads, cleanup := mockTracerProvider(t)
+	tr := otel.Tracer("")
+	defer cleanup()
+
+	_, sp := tr.Start(context.Background(), "span_with_error")
+	sp.RecordError(errors.New("boom"))
+	sp.End()
+
+	tracer.Flush()
+	payload, err := waitForPayload(payloads)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	p := payload[0][0]
+	meta := p["meta"].(map[string]interface{})
+	assert.Equal("boom", meta[ext.ErrorMsg])
+	assert.NotContains(meta, ext.ErrorStack)
+
+	var events []ddtrace.SpanEvent
+	b, _ := json.Marshal(p["span_events"])
+	json.Unmarshal(b, &events)
+	if !assert.Len(events, 1) {
+		return
+	}
+	assert.NotContains(events[0].Attributes, "exception.stacktrace")
+}
+
 func TestSpanEnd(t *testing.T) {
 	assert := assert.New(t)
 	_, payloads, cleanup := mockTracerProvider(t)
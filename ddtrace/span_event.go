@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+//go:generate msgp -unexported -marshal=false -o=span_event_msgp.go -tests=false
+
+package ddtrace
+
+// SpanEvent represents something that happened at a given point in time during
+// the lifetime of a span, such as a cache miss, a retry or an exception.
+type SpanEvent struct {
+	// Name is the name of the event. This field is required.
+	Name string `msg:"name" json:"name"`
+	// TimeUnixNano is the time at which the event occurred, in nanoseconds since epoch.
+	TimeUnixNano uint64 `msg:"time_unix_nano" json:"time_unix_nano"`
+	// Attributes is a mapping of keys to values adding context to the event. This field is optional.
+	Attributes map[string]interface{} `msg:"attributes,omitempty" json:"attributes,omitempty"`
+}
@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultAdaptiveRateLimitMin is the default minimum number of traces per
+	// second allowed for each (service, resource) pair by the adaptive limiter.
+	defaultAdaptiveRateLimitMin = 1.0
+
+	// adaptiveMaxKeys is the maximum number of (service, resource) pairs tracked
+	// by the adaptive limiter. Traces of other pairs share a single budget.
+	adaptiveMaxKeys = 1000
+
+	// adaptiveSmoothing is the weight of the last second in the demand of a pair,
+	// which rises as soon as more traces are seen, and otherwise decays as an
+	// exponential moving average of its traces per second.
+	adaptiveSmoothing = 0.5
+
+	// adaptiveMinDemand is the demand under which an idle pair stops being tracked.
+	adaptiveMinDemand = 0.01
+)
+
+// adaptiveKey identifies the traces sharing a part of the adaptive limiter's budget.
+type adaptiveKey struct {
+	service, resource string
+}
+
+// adaptiveOverflowKey is the key of the traces whose pair isn't tracked because
+// there are already adaptiveMaxKeys of them.
+var adaptiveOverflowKey = adaptiveKey{}
+
+// adaptiveBucket limits the traces of a single (service, resource) pair.
+type adaptiveBucket struct {
+	demand float64   // smoothed number of traces seen per second
+	limit  float64   // number of traces allowed per second
+	tokens float64   // number of traces which can currently be allowed
+	last   time.Time // time at which tokens was refilled
+
+	seen, allowed         float64 // number of traces seen and allowed in the current second
+	prevSeen, prevAllowed float64 // number of traces seen and allowed in the previous second
+}
+
+// adaptiveRateLimiter restricts the number of traces sampled per second to a
+// budget, which is shared fairly across (service, resource) pairs, so that a
+// noisy endpoint doesn't starve the others. Every second, the budget is split
+// according to the demand of each pair: the pairs needing less than an equal
+// share get all their traces, and the others split what remains equally. Each
+// pair is allowed at least a minimum number of traces per second, even though
+// the budget may then be exceeded. The budget left once all demands are met is
+// split equally, to absorb bursts.
+type adaptiveRateLimiter struct {
+	budget float64 // number of traces allowed per second across all pairs
+	min    float64 // minimum number of traces allowed per second for each pair
+
+	mu          sync.Mutex // guards below fields
+	windowStart time.Time  // time at which the budget was last split
+	buckets     map[adaptiveKey]*adaptiveBucket
+}
+
+func newAdaptiveRateLimiter(budget, min float64, now time.Time) *adaptiveRateLimiter {
+	if min < 0 {
+		min = 0
+	}
+	return &adaptiveRateLimiter{
+		budget:      budget,
+		min:         min,
+		windowStart: now,
+		buckets:     make(map[adaptiveKey]*adaptiveBucket),
+	}
+}
+
+// allowOne returns the limiter's decision to allow a trace of the given service
+// and resource to be sampled, and the effective rate of the pair at the time it
+// is called. As with rateLimiter, the effective rate averages the rate of the
+// previous second with the current one.
+func (r *adaptiveRateLimiter) allowOne(service, resource string, now time.Time) (bool, float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Sub(r.windowStart) >= time.Second {
+		r.rebalance(now)
+	}
+	b := r.bucket(adaptiveKey{service, resource}, now)
+	b.seen++
+	b.tokens = math.Min(b.tokens+now.Sub(b.last).Seconds()*b.limit, math.Max(b.limit, 1))
+	b.last = now
+	var sampled bool
+	if b.tokens >= 1 {
+		b.tokens--
+		b.allowed++
+		sampled = true
+	}
+	return sampled, (b.prevAllowed + b.allowed) / (b.prevSeen + b.seen)
+}
+
+// bucket returns the bucket of the key k, creating it if needed. A new pair is
+// allowed an equal share of the budget until the next split.
+func (r *adaptiveRateLimiter) bucket(k adaptiveKey, now time.Time) *adaptiveBucket {
+	if b, ok := r.buckets[k]; ok {
+		return b
+	}
+	if len(r.buckets) >= adaptiveMaxKeys {
+		k = adaptiveOverflowKey
+		if b, ok := r.buckets[k]; ok {
+			return b
+		}
+	}
+	limit := math.Max(r.budget/float64(len(r.buckets)+1), r.min)
+	b := &adaptiveBucket{
+		limit:  limit,
+		tokens: math.Max(limit, 1),
+		last:   now,
+	}
+	r.buckets[k] = b
+	return b
+}
+
+// rebalance updates the demand of each pair with the traces seen since the last
+// split, forgets the idle pairs, and splits the budget again.
+func (r *adaptiveRateLimiter) rebalance(now time.Time) {
+	elapsed := now.Sub(r.windowStart)
+	for k, b := range r.buckets {
+		seen := b.seen / elapsed.Seconds()
+		b.demand = math.Max(seen, adaptiveSmoothing*seen+(1-adaptiveSmoothing)*b.demand)
+		if b.seen == 0 && b.demand < adaptiveMinDemand {
+			delete(r.buckets, k)
+			continue
+		}
+		if elapsed.Truncate(time.Second) == time.Second {
+			// exactly one second, so update prev
+			b.prevSeen, b.prevAllowed = b.seen, b.allowed
+		} else {
+			b.prevSeen, b.prevAllowed = 0, 0
+		}
+		b.seen, b.allowed = 0, 0
+	}
+	r.windowStart = now
+
+	buckets := make([]*adaptiveBucket, 0, len(r.buckets))
+	for _, b := range r.buckets {
+		buckets = append(buckets, b)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].demand < buckets[j].demand })
+	remaining := r.budget
+	for i, b := range buckets {
+		share := remaining / float64(len(buckets)-i)
+		b.limit = math.Max(math.Min(b.demand, share), r.min)
+		remaining -= b.limit
+	}
+	if remaining > 0 {
+		for _, b := range buckets {
+			b.limit += remaining / float64(len(buckets))
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/telemetry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allowN sends n traces of the given resource to the limiter, evenly spread over
+// the second starting at now, and returns how many were allowed.
+func allowN(r *adaptiveRateLimiter, resource string, n int, now time.Time) (allowed int) {
+	for i := 0; i < n; i++ {
+		if ok, _ := r.allowOne("web", resource, now.Add(time.Duration(i)*time.Second/time.Duration(n))); ok {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestAdaptiveRateLimiter(t *testing.T) {
+	t.Run("fair", func(t *testing.T) {
+		now := time.Now()
+		r := newAdaptiveRateLimiter(10, 1, now)
+		for i := 0; i < 3; i++ {
+			second := now.Add(time.Duration(i) * time.Second)
+			noisy := allowN(r, "GET /noisy", 1000, second)
+			quiet := allowN(r, "GET /quiet", 2, second)
+			if i == 0 {
+				continue // the budget is split evenly until demands are known
+			}
+			assert.Equal(t, 2, quiet)
+			assert.InDelta(t, 8, noisy, 1)
+		}
+		_, rate := r.allowOne("web", "GET /quiet", now.Add(3*time.Second))
+		assert.Equal(t, 1.0, rate)
+		_, rate = r.allowOne("web", "GET /noisy", now.Add(3*time.Second))
+		assert.InDelta(t, 0.008, rate, 0.002)
+	})
+
+	t.Run("leftover", func(t *testing.T) {
+		now := time.Now()
+		r := newAdaptiveRateLimiter(10, 1, now)
+		allowN(r, "GET /a", 2, now)
+		allowN(r, "GET /b", 2, now)
+		r.allowOne("web", "GET /a", now.Add(time.Second))
+		assert.Equal(t, 5.0, r.buckets[adaptiveKey{"web", "GET /a"}].limit)
+		assert.Equal(t, 5.0, r.buckets[adaptiveKey{"web", "GET /b"}].limit)
+	})
+
+	t.Run("min", func(t *testing.T) {
+		now := time.Now()
+		r := newAdaptiveRateLimiter(2, 1, now)
+		for i := 0; i < 5; i++ {
+			allowN(r, fmt.Sprintf("GET /%d", i), 10, now)
+		}
+		r.allowOne("web", "GET /0", now.Add(time.Second))
+		require.Len(t, r.buckets, 5)
+		for k, b := range r.buckets {
+			assert.Equal(t, 1.0, b.limit, k)
+		}
+	})
+
+	t.Run("idle", func(t *testing.T) {
+		now := time.Now()
+		r := newAdaptiveRateLimiter(10, 1, now)
+		r.allowOne("web", "GET /idle", now)
+		for i := 1; i < 20; i++ {
+			r.allowOne("web", "GET /busy", now.Add(time.Duration(i)*time.Second))
+		}
+		assert.Len(t, r.buckets, 1)
+		assert.Contains(t, r.buckets, adaptiveKey{"web", "GET /busy"})
+	})
+
+	t.Run("max-keys", func(t *testing.T) {
+		now := time.Now()
+		r := newAdaptiveRateLimiter(10, 0, now)
+		for i := 0; i < adaptiveMaxKeys+10; i++ {
+			r.allowOne("web", fmt.Sprintf("GET /%d", i), now)
+		}
+		assert.Len(t, r.buckets, adaptiveMaxKeys+1)
+		assert.Equal(t, 10.0, r.buckets[adaptiveOverflowKey].seen)
+	})
+}
+
+func TestAdaptiveRateLimit(t *testing.T) {
+	t.Run("option", func(t *testing.T) {
+		tracer, _, _, stop := startTestTracer(t, WithAdaptiveRateLimit(100, 2), WithSamplingRules([]SamplingRule{RateRule(1)}))
+		defer stop()
+		require.NotNil(t, tracer.rulesSampling.traces.adaptive)
+		assert.Equal(t, 2.0, tracer.rulesSampling.traces.adaptive.min)
+		limit, ok := tracer.rulesSampling.TraceRateLimit()
+		assert.True(t, ok)
+		assert.Equal(t, 100.0, limit)
+
+		s := tracer.StartSpan("web.request", ResourceName("GET /")).(*span)
+		s.Finish()
+		assert.Equal(t, 1.0, s.Metrics[keyRulesSamplerAppliedRate])
+		assert.Equal(t, 1.0, s.Metrics[keyRulesSamplerLimiterRate])
+		assert.Equal(t, float64(ext.PriorityUserKeep), s.Metrics[keySamplingPriority])
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_ADAPTIVE_RATE_LIMIT", "50")
+		t.Setenv("DD_TRACE_ADAPTIVE_RATE_LIMIT_MIN", "0.5")
+		c := newConfig()
+		assert.Equal(t, 50.0, c.adaptiveRateLimit)
+		assert.Equal(t, 0.5, c.adaptiveRateLimitMin)
+	})
+
+	t.Run("without-rules", func(t *testing.T) {
+		tp := new(log.RecordLogger)
+		tp.Ignore("appsec: ", telemetry.LogPrefix)
+		tracer, _, _, stop := startTestTracer(t, WithAdaptiveRateLimit(100, 2), WithLogger(tp))
+		defer stop()
+		assert.NotNil(t, tracer.rulesSampling.traces.adaptive)
+		require.NotEmpty(t, tp.Logs())
+		assert.Contains(t, tp.Logs()[0], "WARN: Adaptive rate limit has no effect")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		tracer, _, _, stop := startTestTracer(t)
+		defer stop()
+		assert.Nil(t, tracer.rulesSampling.traces.adaptive)
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/datastreams/options"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	idatastreams "gopkg.in/DataDog/dd-trace-go.v1/internal/datastreams"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 )
 
 // dataStreamsContainer is an object that contains a data streams processor.
@@ -20,7 +21,42 @@ type dataStreamsContainer interface {
 
 // GetDataStreamsProcessor returns the processor tracking data streams stats
 func (t *tracer) GetDataStreamsProcessor() *idatastreams.Processor {
-	return t.dataStreams
+	return t.dataStreams.Load()
+}
+
+// newDataStreamsProcessor returns a new, unstarted, data streams processor.
+func (t *tracer) newDataStreamsProcessor() *idatastreams.Processor {
+	c := t.config
+	return idatastreams.NewProcessor(t.statsd, c.env, c.serviceName, c.version, c.agentURL, c.httpClient, func() bool {
+		f := loadAgentFeatures(c.logToStdout, c.agentURL, c.httpClient)
+		return f.DataStreams
+	})
+}
+
+// setDataStreams starts or stops data streams monitoring. A processor is never
+// started once the tracer is stopped. Always returns true.
+func (t *tracer) setDataStreams(enabled bool) bool {
+	t.togglesMu.Lock()
+	defer t.togglesMu.Unlock()
+	if !enabled {
+		if p := t.dataStreams.Swap(nil); p != nil {
+			p.Stop()
+		}
+		return true
+	}
+	if t.dataStreams.Load() != nil {
+		return true
+	}
+	select {
+	case <-t.stop:
+		return true
+	default:
+	}
+	log.Debug("Data streams monitoring enabled.")
+	p := t.newDataStreamsProcessor()
+	p.Start()
+	t.dataStreams.Store(p)
+	return true
 }
 
 // SetDataStreamsCheckpoint sets a consume or produce checkpoint in a Data Streams pathway.
@@ -0,0 +1,379 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"html/template"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// debugServerCapacity is the number of recently finished chunks kept by the debug server.
+const debugServerCapacity = 200
+
+// debugServer keeps the most recently finished chunks in a ring buffer and serves
+// them over HTTP, to help debugging an application locally without an agent.
+//
+// It serves the following endpoints:
+//
+//	GET /        an HTML waterfall of the traces
+//	GET /traces  the traces as JSON
+//
+// Both endpoints accept the service, resource (substring) and error (true or false)
+// query parameters to filter the traces, along with limit to cap their number.
+type debugServer struct {
+	mu    sync.Mutex
+	ring  []*debugTrace // finished chunks, in a ring buffer
+	next  int           // index of the next chunk to write in ring
+	count int           // number of chunks held by ring
+
+	srv *http.Server
+	ln  net.Listener
+}
+
+// debugTrace holds a copy of the spans of a chunk, as seen by the debug server.
+type debugTrace struct {
+	TraceID  string      `json:"trace_id"`
+	Start    int64       `json:"start"`
+	Duration int64       `json:"duration"`
+	Error    bool        `json:"error"`
+	Sampled  bool        `json:"sampled"`
+	Spans    []debugSpan `json:"spans"`
+}
+
+// debugSpan holds a copy of a finished span, as seen by the debug server.
+type debugSpan struct {
+	SpanID   uint64             `json:"span_id"`
+	ParentID uint64             `json:"parent_id"`
+	Name     string             `json:"name"`
+	Service  string             `json:"service"`
+	Resource string             `json:"resource"`
+	Type     string             `json:"type"`
+	Start    int64              `json:"start"`
+	Duration int64              `json:"duration"`
+	Error    bool               `json:"error"`
+	Meta     map[string]string  `json:"meta,omitempty"`
+	Metrics  map[string]float64 `json:"metrics,omitempty"`
+}
+
+// newDebugServer returns a debug server listening on addr. It must be started
+// with start.
+func newDebugServer(addr string) (*debugServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	ds := &debugServer{
+		ring: make([]*debugTrace, debugServerCapacity),
+		ln:   ln,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", ds.handleWaterfall)
+	mux.HandleFunc("/traces", ds.handleTraces)
+	ds.srv = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return ds, nil
+}
+
+// start serves HTTP requests until stop is called.
+func (ds *debugServer) start() {
+	log.Info("Debug server listening on http://%s", ds.ln.Addr())
+	if err := ds.srv.Serve(ds.ln); err != nil && err != http.ErrServerClosed {
+		log.Error("Debug server: %v", err)
+	}
+}
+
+// stop shuts the server down. It is safe to call on a nil server.
+func (ds *debugServer) stop() {
+	if ds == nil {
+		return
+	}
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), time.Second)
+	defer cancel()
+	ds.srv.Shutdown(ctx)
+}
+
+// record copies the spans of the chunk c into the ring buffer, evicting the
+// oldest chunk when the buffer is full. The spans are finished, hence read
+// without locking, the same way the trace writer reads them.
+func (ds *debugServer) record(c *chunk) {
+	if len(c.spans) == 0 {
+		return
+	}
+	dt := &debugTrace{
+		Sampled: c.willSend,
+		Spans:   make([]debugSpan, len(c.spans)),
+	}
+	if ctx := c.spans[0].context; ctx != nil {
+		dt.TraceID = ctx.TraceID128()
+	}
+	var end int64
+	for i, s := range c.spans {
+		sp := debugSpan{
+			SpanID:   s.SpanID,
+			ParentID: s.ParentID,
+			Name:     s.Name,
+			Service:  s.Service,
+			Resource: s.Resource,
+			Type:     s.Type,
+			Start:    s.Start,
+			Duration: s.Duration,
+			Error:    s.Error != 0,
+			Meta:     make(map[string]string, len(s.Meta)),
+			Metrics:  make(map[string]float64, len(s.Metrics)),
+		}
+		for k, v := range s.Meta {
+			sp.Meta[k] = v
+		}
+		for k, v := range s.Metrics {
+			sp.Metrics[k] = v
+		}
+		if i == 0 || sp.Start < dt.Start {
+			dt.Start = sp.Start
+		}
+		if e := sp.Start + sp.Duration; e > end {
+			end = e
+		}
+		dt.Error = dt.Error || sp.Error
+		dt.Spans[i] = sp
+	}
+	dt.Duration = end - dt.Start
+	sort.SliceStable(dt.Spans, func(i, j int) bool { return dt.Spans[i].Start < dt.Spans[j].Start })
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.ring[ds.next] = dt
+	ds.next = (ds.next + 1) % len(ds.ring)
+	if ds.count < len(ds.ring) {
+		ds.count++
+	}
+}
+
+// debugFilter selects the traces returned by the debug server.
+type debugFilter struct {
+	service  string
+	resource string
+	err      *bool
+	limit    int
+}
+
+func newDebugFilter(r *http.Request) (debugFilter, error) {
+	q := r.URL.Query()
+	f := debugFilter{
+		service:  q.Get("service"),
+		resource: q.Get("resource"),
+	}
+	if v := q.Get("error"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, err
+		}
+		f.err = &b
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return f, err
+		}
+		f.limit = n
+	}
+	return f, nil
+}
+
+// match reports whether the trace has a span matching both the service and
+// resource of the filter, and whether its error status matches the filter.
+func (f debugFilter) match(dt *debugTrace) bool {
+	if f.err != nil && *f.err != dt.Error {
+		return false
+	}
+	if f.service == "" && f.resource == "" {
+		return true
+	}
+	for _, s := range dt.Spans {
+		if f.service != "" && s.Service != f.service {
+			continue
+		}
+		if f.resource != "" && !strings.Contains(s.Resource, f.resource) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// traces returns the recorded traces matching f, most recent first.
+func (ds *debugServer) traces(f debugFilter) []*debugTrace {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	traces := make([]*debugTrace, 0, ds.count)
+	for i := 1; i <= ds.count; i++ {
+		dt := ds.ring[(ds.next-i+len(ds.ring))%len(ds.ring)]
+		if !f.match(dt) {
+			continue
+		}
+		traces = append(traces, dt)
+		if f.limit > 0 && len(traces) == f.limit {
+			break
+		}
+	}
+	return traces
+}
+
+func (ds *debugServer) handleTraces(w http.ResponseWriter, r *http.Request) {
+	f, err := newDebugFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ds.traces(f)); err != nil {
+		log.Error("Debug server: %v", err)
+	}
+}
+
+func (ds *debugServer) handleWaterfall(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := newDebugFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	traces := ds.traces(f)
+	page := waterfallPage{
+		Service:  f.service,
+		Resource: f.resource,
+		Traces:   make([]waterfallTrace, len(traces)),
+	}
+	if f.err != nil {
+		page.Error = strconv.FormatBool(*f.err)
+	}
+	for i, dt := range traces {
+		page.Traces[i] = newWaterfallTrace(dt)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := waterfallTemplate.Execute(w, page); err != nil {
+		log.Error("Debug server: %v", err)
+	}
+}
+
+// waterfallPage holds the data rendered by waterfallTemplate.
+type waterfallPage struct {
+	Service, Resource, Error string
+	Traces                   []waterfallTrace
+}
+
+type waterfallTrace struct {
+	*debugTrace
+	Time     time.Time
+	Duration time.Duration
+	Rows     []waterfallRow
+}
+
+type waterfallRow struct {
+	debugSpan
+	Depth         int
+	Duration      time.Duration
+	Offset, Width float64 // percentages of the trace duration
+}
+
+// newWaterfallTrace arranges the spans of dt depth-first, positioning each
+// span relative to the start and duration of the trace.
+func newWaterfallTrace(dt *debugTrace) waterfallTrace {
+	wt := waterfallTrace{
+		debugTrace: dt,
+		Time:       time.Unix(0, dt.Start),
+		Duration:   time.Duration(dt.Duration),
+	}
+	ids := make(map[uint64]bool, len(dt.Spans))
+	children := make(map[uint64][]debugSpan, len(dt.Spans))
+	for _, s := range dt.Spans {
+		ids[s.SpanID] = true
+	}
+	var roots []debugSpan
+	for _, s := range dt.Spans {
+		if ids[s.ParentID] && s.ParentID != s.SpanID {
+			children[s.ParentID] = append(children[s.ParentID], s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+	total := float64(dt.Duration)
+	if total <= 0 {
+		total = 1
+	}
+	var walk func(spans []debugSpan, depth int)
+	walk = func(spans []debugSpan, depth int) {
+		for _, s := range spans {
+			wt.Rows = append(wt.Rows, waterfallRow{
+				debugSpan: s,
+				Depth:     depth,
+				Duration:  time.Duration(s.Duration),
+				Offset:    100 * float64(s.Start-dt.Start) / total,
+				Width:     100 * float64(s.Duration) / total,
+			})
+			walk(children[s.SpanID], depth+1)
+		}
+	}
+	walk(roots, 0)
+	return wt
+}
+
+var waterfallTemplate = template.Must(template.New("waterfall").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Recent traces</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; margin: 1em; }
+form input { margin-right: 1em; }
+.trace { margin: 1em 0; border-top: 1px solid #ccc; }
+.trace h3 { font-size: 13px; }
+.row { display: flex; align-items: center; height: 20px; }
+.label { width: 40%; overflow: hidden; white-space: nowrap; text-overflow: ellipsis; }
+.lane { position: relative; width: 60%; height: 14px; background: #f4f4f4; }
+.bar { position: absolute; height: 14px; min-width: 1px; background: #632ca6; }
+.error .bar { background: #e0474c; }
+.error .label { color: #e0474c; }
+</style>
+</head>
+<body>
+<form method="get">
+<label>service <input name="service" value="{{.Service}}"></label>
+<label>resource <input name="resource" value="{{.Resource}}"></label>
+<label>error <input name="error" value="{{.Error}}" placeholder="true or false"></label>
+<button type="submit">Filter</button>
+<a href="traces">JSON</a>
+</form>
+{{range .Traces}}
+<div class="trace">
+<h3>trace {{.TraceID}} &middot; {{.Time.Format "15:04:05.000"}} &middot; {{.Duration}}{{if not .Sampled}} &middot; not sampled{{end}}</h3>
+{{range .Rows}}
+<div class="row{{if .Error}} error{{end}}" title="{{.Resource}}">
+<div class="label" style="padding-left: {{.Depth}}em">{{.Service}} {{.Name}} &middot; {{.Resource}} ({{.Duration}})</div>
+<div class="lane"><div class="bar" style="left: {{printf "%.3f" .Offset}}%; width: {{printf "%.3f" .Width}}%"></div></div>
+</div>
+{{end}}
+</div>
+{{else}}
+<p>No traces.</p>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugServer(t *testing.T) {
+	// traces dropped by span processors aren't served
+	dropHealth := &testProcessor{
+		onChunk: func(c *Chunk) bool { return c.Spans[0].OperationName() != "health.check" },
+	}
+	tracer, _, flush, stop := startTestTracer(t, WithDebugServer("127.0.0.1:0"), WithSpanProcessor(dropHealth))
+	defer stop()
+	require.NotNil(t, tracer.debugServer)
+	addr := "http://" + tracer.debugServer.ln.Addr().String()
+
+	root := tracer.StartSpan("http.request", ServiceName("web"), ResourceName("GET /users"))
+	child := tracer.StartSpan("db.query", ChildOf(root.Context()), ServiceName("db"), ResourceName("SELECT * FROM users"))
+	child.Finish()
+	root.Finish()
+	failed := tracer.StartSpan("http.request", ServiceName("web"), ResourceName("POST /users"))
+	failed.Finish(WithError(errors.New("boom")))
+	tracer.StartSpan("health.check", ServiceName("web")).Finish()
+	flush(2)
+
+	get := func(t *testing.T, path string) (int, string) {
+		resp, err := http.Get(addr + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+	getTraces := func(t *testing.T, query string) []debugTrace {
+		code, body := get(t, "/traces"+query)
+		require.Equal(t, http.StatusOK, code, body)
+		var traces []debugTrace
+		require.NoError(t, json.Unmarshal([]byte(body), &traces))
+		return traces
+	}
+
+	t.Run("all", func(t *testing.T) {
+		traces := getTraces(t, "")
+		require.Len(t, traces, 2)
+		// most recent first
+		assert.Equal(t, "POST /users", traces[0].Spans[0].Resource)
+		assert.True(t, traces[0].Error)
+		require.Len(t, traces[1].Spans, 2)
+		assert.Equal(t, "http.request", traces[1].Spans[0].Name)
+		assert.Equal(t, "db.query", traces[1].Spans[1].Name)
+		assert.Equal(t, traces[1].Spans[0].SpanID, traces[1].Spans[1].ParentID)
+		assert.Equal(t, root.Context().(*spanContext).TraceID128(), traces[1].TraceID)
+		assert.False(t, traces[1].Error)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		assert.Len(t, getTraces(t, "?service=db"), 1)
+		assert.Len(t, getTraces(t, "?service=none"), 0)
+		assert.Len(t, getTraces(t, "?resource=/users"), 2)
+		assert.Len(t, getTraces(t, "?resource=SELECT"), 1)
+		assert.Len(t, getTraces(t, "?service=web&resource=SELECT"), 0)
+		assert.Len(t, getTraces(t, "?error=true"), 1)
+		assert.Len(t, getTraces(t, "?error=false&service=db"), 1)
+		assert.Len(t, getTraces(t, "?limit=1"), 1)
+
+		code, _ := get(t, "/traces?error=maybe")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("waterfall", func(t *testing.T) {
+		code, body := get(t, "/?service=db")
+		require.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, "SELECT * FROM users")
+		assert.NotContains(t, body, "POST /users")
+
+		code, _ = get(t, "/unknown")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+}
+
+func TestDebugServerCapacity(t *testing.T) {
+	ds, err := newDebugServer("127.0.0.1:0")
+	require.NoError(t, err)
+	defer ds.ln.Close()
+
+	for i := 0; i < debugServerCapacity+10; i++ {
+		ds.record(&chunk{spans: []*span{{Name: "op", Resource: strings.Repeat("r", i), Start: int64(i), Duration: 1}}})
+	}
+	traces := ds.traces(debugFilter{})
+	require.Len(t, traces, debugServerCapacity)
+	assert.Len(t, traces[0].Spans[0].Resource, debugServerCapacity+9)
+	assert.Len(t, traces[len(traces)-1].Spans[0].Resource, 10)
+}
+
+func TestDebugServerDisabled(t *testing.T) {
+	tracer, _, _, stop := startTestTracer(t)
+	defer stop()
+	assert.Nil(t, tracer.debugServer)
+	tracer.StartSpan("op").Finish()
+}
+
+func TestNewWaterfallTrace(t *testing.T) {
+	dt := &debugTrace{
+		Start:    100,
+		Duration: 100,
+		Spans: []debugSpan{
+			{SpanID: 1, Start: 100, Duration: 100},
+			{SpanID: 2, ParentID: 1, Start: 125, Duration: 50},
+			{SpanID: 3, ParentID: 2, Start: 150, Duration: 10},
+			{SpanID: 4, ParentID: 1, Start: 180, Duration: 20},
+		},
+	}
+	wt := newWaterfallTrace(dt)
+	require.Len(t, wt.Rows, 4)
+	var ids []uint64
+	var depths []int
+	for _, r := range wt.Rows {
+		ids = append(ids, r.SpanID)
+		depths = append(depths, r.Depth)
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4}, ids)
+	assert.Equal(t, []int{0, 1, 2, 1}, depths)
+	assert.Equal(t, 25.0, wt.Rows[1].Offset)
+	assert.Equal(t, 50.0, wt.Rows[1].Width)
+}
@@ -19,8 +19,8 @@ import (
 const defaultMetricsReportInterval = 10 * time.Second
 
 // reportRuntimeMetrics periodically reports go runtime metrics at
-// the given interval.
-func (t *tracer) reportRuntimeMetrics(interval time.Duration) {
+// the given interval, until the tracer or stop is stopped.
+func (t *tracer) reportRuntimeMetrics(interval time.Duration, stop <-chan struct{}) {
 	var ms runtime.MemStats
 	gc := debug.GCStats{
 		// When len(stats.PauseQuantiles) is 5, it will be filled with the
@@ -81,8 +81,46 @@ func (t *tracer) reportRuntimeMetrics(interval time.Duration) {
 
 		case <-t.stop:
 			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+// setRuntimeMetrics starts or stops the runtime metrics reporter. The reporter
+// is never started once the tracer is stopped. Always returns true.
+func (t *tracer) setRuntimeMetrics(enabled bool) bool {
+	t.togglesMu.Lock()
+	defer t.togglesMu.Unlock()
+	if !enabled {
+		if t.runtimeMetricsStop != nil {
+			log.Debug("Runtime metrics disabled.")
+			close(t.runtimeMetricsStop)
+			t.runtimeMetricsStop = nil
 		}
+		return true
 	}
+	if t.runtimeMetricsStop != nil {
+		return true
+	}
+	select {
+	case <-t.stop:
+		return true
+	default:
+	}
+	log.Debug("Runtime metrics enabled.")
+	stop := make(chan struct{})
+	t.runtimeMetricsStop = stop
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		if t.config.runtimeMetricsV2 {
+			t.reportRuntimeMetricsV2(defaultMetricsReportInterval, stop)
+			return
+		}
+		t.reportRuntimeMetrics(defaultMetricsReportInterval, stop)
+	}()
+	return true
 }
 
 func (t *tracer) reportHealthMetrics(interval time.Duration) {
@@ -94,6 +132,9 @@ func (t *tracer) reportHealthMetrics(interval time.Duration) {
 			t.statsd.Count("datadog.tracer.spans_started", int64(atomic.SwapUint32(&t.spansStarted, 0)), nil, 1)
 			t.statsd.Count("datadog.tracer.spans_finished", int64(atomic.SwapUint32(&t.spansFinished, 0)), nil, 1)
 			t.statsd.Count("datadog.tracer.traces_dropped", int64(atomic.SwapUint32(&t.tracesDropped, 0)), []string{"reason:trace_too_large"}, 1)
+			if t.tailSampler != nil {
+				t.tailSampler.report(t.statsd)
+			}
 		case <-t.stop:
 			return
 		}
@@ -7,6 +7,9 @@ package tracer
 
 import (
 	"fmt"
+	"math"
+	"runtime"
+	"runtime/metrics"
 	"sync"
 	"testing"
 	"time"
@@ -14,6 +17,7 @@ import (
 	globalinternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type callType int64
@@ -23,6 +27,7 @@ const (
 	callTypeIncr
 	callTypeCount
 	callTypeTiming
+	callTypeDistribution
 )
 
 type testStatsdClient struct {
@@ -31,6 +36,7 @@ type testStatsdClient struct {
 	incrCalls   []testStatsdCall
 	countCalls  []testStatsdCall
 	timingCalls []testStatsdCall
+	distCalls   []testStatsdCall
 	counts      map[string]int64
 	tags        []string
 	n           int
@@ -99,6 +105,15 @@ func (tg *testStatsdClient) Timing(name string, value time.Duration, tags []stri
 	})
 }
 
+func (tg *testStatsdClient) Distribution(name string, value float64, tags []string, rate float64) error {
+	return tg.addMetric(callTypeDistribution, tags, testStatsdCall{
+		name:     name,
+		floatVal: value,
+		tags:     make([]string, len(tags)),
+		rate:     rate,
+	})
+}
+
 func (tg *testStatsdClient) addMetric(ct callType, tags []string, c testStatsdCall) error {
 	tg.mu.Lock()
 	defer tg.mu.Unlock()
@@ -112,6 +127,8 @@ func (tg *testStatsdClient) addMetric(ct callType, tags []string, c testStatsdCa
 		tg.countCalls = append(tg.countCalls, c)
 	case callTypeTiming:
 		tg.timingCalls = append(tg.timingCalls, c)
+	case callTypeDistribution:
+		tg.distCalls = append(tg.distCalls, c)
 	}
 	tg.tags = tags
 	tg.n++
@@ -154,6 +171,14 @@ func (tg *testStatsdClient) CountCalls() []testStatsdCall {
 	return c
 }
 
+func (tg *testStatsdClient) DistributionCalls() []testStatsdCall {
+	tg.mu.RLock()
+	defer tg.mu.RUnlock()
+	c := make([]testStatsdCall, len(tg.distCalls))
+	copy(c, tg.distCalls)
+	return c
+}
+
 func (tg *testStatsdClient) CallNames() []string {
 	tg.mu.RLock()
 	defer tg.mu.RUnlock()
@@ -170,6 +195,9 @@ func (tg *testStatsdClient) CallNames() []string {
 	for _, c := range tg.timingCalls {
 		n = append(n, c.name)
 	}
+	for _, c := range tg.distCalls {
+		n = append(n, c.name)
+	}
 	return n
 }
 
@@ -189,6 +217,9 @@ func (tg *testStatsdClient) CallsByName() map[string]int {
 	for _, c := range tg.timingCalls {
 		counts[c.name]++
 	}
+	for _, c := range tg.distCalls {
+		counts[c.name]++
+	}
 	return counts
 }
 
@@ -217,6 +248,7 @@ func (tg *testStatsdClient) Reset() {
 	tg.incrCalls = tg.incrCalls[:0]
 	tg.countCalls = tg.countCalls[:0]
 	tg.timingCalls = tg.timingCalls[:0]
+	tg.distCalls = tg.distCalls[:0]
 	tg.counts = make(map[string]int64)
 	tg.tags = tg.tags[:0]
 	tg.n = 0
@@ -246,7 +278,7 @@ func TestReportRuntimeMetrics(t *testing.T) {
 	trc.wg.Add(1)
 	go func() {
 		defer trc.wg.Done()
-		trc.reportRuntimeMetrics(time.Millisecond)
+		trc.reportRuntimeMetrics(time.Millisecond, nil)
 	}()
 	assert := assert.New(t)
 	err := tg.Wait(assert, 35, 1*time.Second)
@@ -259,6 +291,71 @@ func TestReportRuntimeMetrics(t *testing.T) {
 	assert.Contains(calls, "runtime.go.gc_stats.pause_quantiles.75p")
 }
 
+func TestReportRuntimeMetricsV2(t *testing.T) {
+	var tg testStatsdClient
+	trc := newUnstartedTracer(withStatsdClient(&tg), WithRuntimeMetricsV2())
+	defer trc.statsd.Close()
+	assert := assert.New(t)
+	assert.True(trc.config.runtimeMetrics)
+
+	trc.wg.Add(1)
+	go func() {
+		defer trc.wg.Done()
+		trc.reportRuntimeMetricsV2(time.Millisecond, nil)
+	}()
+	err := tg.Wait(assert, 35, 1*time.Second)
+	close(trc.stop)
+	trc.wg.Wait()
+	assert.NoError(err)
+	calls := tg.CallNames()
+	assert.Contains(calls, "runtime.go.num_cpu")
+	assert.Contains(calls, "runtime.go.num_goroutine")
+	assert.Contains(calls, "runtime.go.gomaxprocs")
+	assert.Contains(calls, "runtime.go.mem_stats.heap_alloc")
+	assert.Contains(calls, "runtime.go.mem_stats.next_gc")
+	assert.Contains(calls, "runtime.go.sync.mutex_wait_total_ns")
+}
+
+func TestRuntimeMetricsCollector(t *testing.T) {
+	var tg testStatsdClient
+	c := newRuntimeMetricsCollector()
+	runtime.GC()
+	c.report(&tg)
+	calls := tg.DistributionCalls()
+	require.NotEmpty(t, calls)
+	for _, call := range calls {
+		assert.True(t, call.floatVal > 0, call.name)
+	}
+	assert.Contains(t, tg.CallNames(), "runtime.go.gc_stats.pause_ns")
+}
+
+func TestRuntimeMetricsV2Env(t *testing.T) {
+	t.Setenv("DD_RUNTIME_METRICS_V2_ENABLED", "true")
+	c := newConfig()
+	assert.True(t, c.runtimeMetrics)
+	assert.True(t, c.runtimeMetricsV2)
+}
+
+func TestReportHistogram(t *testing.T) {
+	var tg testStatsdClient
+	h := &metrics.Float64Histogram{
+		Counts:  []uint64{1, 2, 3},
+		Buckets: []float64{math.Inf(-1), 0.001, 0.002, math.Inf(1)},
+	}
+	reportHistogram(&tg, "hist", h, []uint64{1, 1, 0})
+	var values []float64
+	for _, c := range tg.DistributionCalls() {
+		values = append(values, c.floatVal)
+	}
+	// only the events recorded since the previous counts are reported
+	assert.Equal(t, []float64{1.5e6, 2e6, 2e6, 2e6}, values)
+
+	tg.Reset()
+	h.Counts = []uint64{0, maxHistogramSamples, maxHistogramSamples}
+	reportHistogram(&tg, "hist", h, nil)
+	assert.Len(t, tg.DistributionCalls(), maxHistogramSamples)
+}
+
 func TestReportHealthMetrics(t *testing.T) {
 	assert := assert.New(t)
 	var tg testStatsdClient
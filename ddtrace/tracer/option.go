@@ -20,6 +20,7 @@ import (
 	"runtime/debug"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
@@ -84,6 +85,7 @@ var contribIntegrations = map[string]struct {
 	"gopkg.in/olivere/elastic.v5":                   {"Elasticsearch v5", false},
 	"gopkg.in/olivere/elastic.v3":                   {"Elasticsearch v3", false},
 	"github.com/redis/go-redis/v9":                  {"Redis v9", false},
+	"github.com/rs/zerolog":                         {"Zerolog", false},
 	"github.com/segmentio/kafka-go":                 {"Kafka v0", false},
 	"github.com/IBM/sarama":                         {"IBM sarama", false},
 	"github.com/Shopify/sarama":                     {"Shopify sarama", false},
@@ -94,6 +96,8 @@ var contribIntegrations = map[string]struct {
 	"github.com/urfave/negroni":                     {"Negroni", false},
 	"github.com/valyala/fasthttp":                   {"FastHTTP", false},
 	"github.com/zenazn/goji":                        {"Goji", false},
+	"go.uber.org/zap":                               {"Zap", false},
+	"log/slog":                                      {"log/slog", false},
 }
 
 var (
@@ -133,6 +137,20 @@ type config struct {
 	// failure.
 	sendRetries int
 
+	// spoolDir, when set, specifies a directory in which payloads that could
+	// not be sent to the agent are stored, to be replayed once it is reachable.
+	spoolDir string
+
+	// spoolMaxSize is the maximum number of bytes kept in spoolDir.
+	spoolMaxSize int64
+
+	// spoolMaxAge is the maximum amount of time a payload is kept in spoolDir.
+	spoolMaxAge time.Duration
+
+	// otlpEndpoint, when set, specifies the URL of an OTLP/HTTP receiver, such
+	// as an OpenTelemetry Collector, to which traces are sent instead of the agent.
+	otlpEndpoint string
+
 	// logStartup, when true, causes various startup info to be written
 	// when the tracer starts.
 	logStartup bool
@@ -183,6 +201,10 @@ type config struct {
 	// runtimeMetrics specifies whether collection of runtime metrics is enabled.
 	runtimeMetrics bool
 
+	// runtimeMetricsV2 specifies whether runtime metrics are collected through
+	// runtime/metrics instead of runtime.ReadMemStats.
+	runtimeMetricsV2 bool
+
 	// dogstatsdAddr specifies the address to connect for sending metrics to the
 	// Datadog Agent. If not set, it defaults to "localhost:8125" or to the
 	// combination of the environment variables DD_AGENT_HOST and DD_DOGSTATSD_PORT.
@@ -236,6 +258,26 @@ type config struct {
 	// misconfiguration
 	spanTimeout time.Duration
 
+	// spanProcessors holds the processors run on finished spans, in order of registration.
+	spanProcessors []SpanProcessor
+
+	// tailSampling configures the tail sampler. It is nil when tail sampling is disabled.
+	tailSampling *TailSamplingConfig
+
+	// adaptiveRateLimit is the number of traces per second sampled by the sampling rules,
+	// shared across (service, resource) pairs. Zero disables adaptive rate limiting, in
+	// which case DD_TRACE_RATE_LIMIT applies. Value from DD_TRACE_ADAPTIVE_RATE_LIMIT.
+	adaptiveRateLimit float64
+
+	// adaptiveRateLimitMin is the minimum number of traces per second sampled for each
+	// (service, resource) pair by the adaptive rate limiter. Value from
+	// DD_TRACE_ADAPTIVE_RATE_LIMIT_MIN, default 1.
+	adaptiveRateLimitMin float64
+
+	// debugServerAddr is the address of the debug server serving recently finished
+	// traces, or empty if the debug server is disabled.
+	debugServerAddr string
+
 	// partialFlushMinSpans is the number of finished spans in a single trace to trigger a
 	// partial flush, or 0 if partial flushing is disabled.
 	// Value from DD_TRACE_PARTIAL_FLUSH_MIN_SPANS, default 1000.
@@ -260,6 +302,35 @@ type config struct {
 
 	// headerAsTags holds the header as tags configuration.
 	headerAsTags dynamicConfig[[]string]
+
+	// traceSamplingRules holds the trace sampling rules, which can be updated through remote configuration.
+	traceSamplingRules dynamicConfig[samplingRules]
+
+	// spanSamplingRules holds the single span sampling rules, which can be updated through remote configuration.
+	spanSamplingRules dynamicConfig[samplingRules]
+
+	// tracingEnabled holds whether traces are sent. Tracing can only be disabled at
+	// runtime through remote configuration, as the tracer isn't started otherwise.
+	tracingEnabled dynamicConfig[bool]
+
+	// runtimeMetricsEnabled holds whether runtime metrics are reported.
+	runtimeMetricsEnabled dynamicConfig[bool]
+
+	// dataStreamsEnabled holds whether data streams monitoring is enabled.
+	dataStreamsEnabled dynamicConfig[bool]
+
+	// partialFlush holds whether partial flushing is enabled.
+	partialFlush dynamicConfig[bool]
+
+	// logsInjection holds whether log correlation integrations add trace information
+	// to log records. Value from DD_LOGS_INJECTION, default true.
+	logsInjection dynamicConfig[bool]
+
+	// redactionRules holds the rules redacting the tags of spans before they are written.
+	redactionRules dynamicConfig[redactionRules]
+
+	// redactor holds the compiled form of redactionRules, or nil if there are none.
+	redactor atomic.Pointer[redactor]
 }
 
 // orchestrionConfig contains Orchestrion configuration.
@@ -324,6 +395,14 @@ func newConfig(opts ...StartOption) *config {
 		internal.ForEachStringTag(v, func(key, val string) { WithServiceMapping(key, val)(c) })
 	}
 	c.headerAsTags = newDynamicConfig("trace_header_tags", nil, setHeaderTags, equalSlice[string])
+	c.redactionRules = newDynamicConfig[redactionRules]("trace_redaction_rules", nil, c.setRedactionRules, equalRedactionRules)
+	if v := os.Getenv("DD_TRACE_REDACTION_RULES"); v != "" {
+		if rules, err := parseRedactionRules(v); err != nil {
+			log.Warn("Invalid DD_TRACE_REDACTION_RULES: %v", err)
+		} else {
+			WithRedactionRules(rules...)(c)
+		}
+	}
 	if v := os.Getenv("DD_TRACE_HEADER_TAGS"); v != "" {
 		WithHeaderTags(strings.Split(v, ","))(c)
 	}
@@ -341,6 +420,10 @@ func newConfig(opts ...StartOption) *config {
 	}
 	c.logStartup = internal.BoolEnv("DD_TRACE_STARTUP_LOGS", true)
 	c.runtimeMetrics = internal.BoolEnv("DD_RUNTIME_METRICS_ENABLED", false)
+	c.runtimeMetricsV2 = internal.BoolEnv("DD_RUNTIME_METRICS_V2_ENABLED", false)
+	if c.runtimeMetricsV2 {
+		c.runtimeMetrics = true
+	}
 	c.debug = internal.BoolEnv("DD_TRACE_DEBUG", false)
 	c.enabled = internal.BoolEnv("DD_TRACE_ENABLED", true)
 	c.profilerEndpoints = internal.BoolEnv(traceprof.EndpointEnvVar, true)
@@ -353,6 +436,12 @@ func newConfig(opts ...StartOption) *config {
 	c.statsComputationEnabled = internal.BoolEnv("DD_TRACE_STATS_COMPUTATION_ENABLED", false)
 	c.dataStreamsMonitoringEnabled = internal.BoolEnv("DD_DATA_STREAMS_ENABLED", false)
 	c.partialFlushEnabled = internal.BoolEnv("DD_TRACE_PARTIAL_FLUSH_ENABLED", false)
+	c.logsInjection = newDynamicConfig("logs_injection_enabled", internal.BoolEnv("DD_LOGS_INJECTION", true), setLogsInjection, equal[bool])
+	c.adaptiveRateLimit = internal.FloatEnv("DD_TRACE_ADAPTIVE_RATE_LIMIT", 0)
+	c.adaptiveRateLimitMin = internal.FloatEnv("DD_TRACE_ADAPTIVE_RATE_LIMIT_MIN", defaultAdaptiveRateLimitMin)
+	c.spoolDir = os.Getenv("DD_TRACE_SPOOL_DIR")
+	c.spoolMaxSize = int64(internal.IntEnv("DD_TRACE_SPOOL_MAX_SIZE", defaultSpoolMaxSize))
+	c.spoolMaxAge = internal.DurationEnv("DD_TRACE_SPOOL_MAX_AGE", defaultSpoolMaxAge)
 	c.partialFlushMinSpans = internal.IntEnv("DD_TRACE_PARTIAL_FLUSH_MIN_SPANS", partialFlushMinSpansDefault)
 	if c.partialFlushMinSpans <= 0 {
 		log.Warn("DD_TRACE_PARTIAL_FLUSH_MIN_SPANS=%d is not a valid value, setting to default %d", c.partialFlushMinSpans, partialFlushMinSpansDefault)
@@ -425,6 +514,8 @@ func newConfig(opts ...StartOption) *config {
 			}
 		}
 	}
+	globalconfig.SetEnv(c.env)
+	globalconfig.SetVersion(c.version)
 	if c.serviceName == "" {
 		if v, ok := globalTags["service"]; ok {
 			if s, ok := v.(string); ok {
@@ -437,6 +528,14 @@ func newConfig(opts ...StartOption) *config {
 			c.serviceName = filepath.Base(os.Args[0])
 		}
 	}
+	if c.transport == nil && c.otlpEndpoint != "" {
+		client := c.httpClient
+		if strings.HasPrefix(c.agentURL.Host, "UDS_") {
+			// the UDS client always dials the agent's socket
+			client = defaultClient
+		}
+		c.transport = newOTLPTransport(c.otlpEndpoint, client, c.env, c.version)
+	}
 	if c.transport == nil {
 		c.transport = newHTTPTransport(c.agentURL.String(), c.httpClient)
 	}
@@ -686,6 +785,10 @@ func (c *config) loadContribIntegrations(deps []*debug.Module) {
 }
 
 func (c *config) canComputeStats() bool {
+	if c.otlpEndpoint != "" {
+		// stats are computed by the agent, which is bypassed by the OTLP exporter
+		return false
+	}
 	return c.agent.Stats && (c.HasFeature("discovery") || c.statsComputationEnabled)
 }
 
@@ -793,6 +896,40 @@ func WithSendRetries(retries int) StartOption {
 	}
 }
 
+// WithTraceSpool enables storing trace payloads on disk, in the directory dir,
+// when they can not be sent to the agent, for instance while it restarts. The
+// stored payloads are sent in order once the agent is reachable again. At most
+// maxSize bytes are kept, and payloads older than maxAge are discarded; zero
+// values select the defaults of 100MB and one hour. Payloads left in dir by a
+// previous run of the program are also sent. Only the payloads which failed
+// because of network errors, server errors or rate limiting are stored; the ones
+// rejected by the agent with another client error are dropped.
+// This can also be configured using DD_TRACE_SPOOL_DIR, DD_TRACE_SPOOL_MAX_SIZE
+// and DD_TRACE_SPOOL_MAX_AGE.
+func WithTraceSpool(dir string, maxSize int64, maxAge time.Duration) StartOption {
+	return func(c *config) {
+		c.spoolDir = dir
+		if maxSize > 0 {
+			c.spoolMaxSize = maxSize
+		}
+		if maxAge > 0 {
+			c.spoolMaxAge = maxAge
+		}
+	}
+}
+
+// WithOTLPExporter configures the tracer to send traces to an OTLP/HTTP
+// receiver, such as an OpenTelemetry Collector, instead of the Datadog Agent.
+// Traces are converted to OTLP protobuf and sent to the given endpoint, e.g.
+// "http://localhost:4318". When endpoint has no path, "/v1/traces" is used.
+// Client-side stats are not computed, and the agent is still used for runtime
+// metrics and other features which depend on it.
+func WithOTLPExporter(endpoint string) StartOption {
+	return func(c *config) {
+		c.otlpEndpoint = endpoint
+	}
+}
+
 // WithPropagator sets an alternative propagator to be used by the tracer.
 func WithPropagator(p Propagator) StartOption {
 	return func(c *config) {
@@ -966,6 +1103,18 @@ func WithRuntimeMetrics() StartOption {
 	}
 }
 
+// WithRuntimeMetricsV2 enables automatic collection of runtime metrics every 10 seconds
+// through runtime/metrics, which doesn't stop the world, unlike runtime.ReadMemStats.
+// In addition to the metrics reported by WithRuntimeMetrics, it reports GC pause and
+// scheduler latency distributions, GOMAXPROCS, mutex wait time and the memory limit
+// of the process, taking its cgroup into account.
+func WithRuntimeMetricsV2() StartOption {
+	return func(cfg *config) {
+		cfg.runtimeMetrics = true
+		cfg.runtimeMetricsV2 = true
+	}
+}
+
 // WithDogstatsdAddress specifies the address to connect to for sending metrics to the Datadog
 // Agent. It should be a "host:port" string, or the path to a unix domain socket.If not set, it
 // attempts to determine the address of the statsd service according to the following rules:
@@ -1075,6 +1224,58 @@ func WithDebugSpansMode(timeout time.Duration) StartOption {
 	}
 }
 
+// WithSpanProcessor registers a processor that can modify or drop spans once they
+// are finished and before they are written. Processors are run in the order in which
+// they are registered, and WithSpanProcessor may be used several times. See
+// SpanProcessor for details about when processors are run.
+func WithSpanProcessor(p SpanProcessor) StartOption {
+	return func(c *config) {
+		c.spanProcessors = append(c.spanProcessors, p)
+	}
+}
+
+// WithTailSampling enables a tail sampler, which holds the finished local traces
+// and decides whether to keep them once complete, based on their errors, latency
+// and tags. See TailSamplingConfig for details.
+func WithTailSampling(cfg TailSamplingConfig) StartOption {
+	return func(c *config) {
+		c.tailSampling = &cfg
+	}
+}
+
+// WithAdaptiveRateLimit replaces the global limit of traces sampled per second by the
+// sampling rules, set by DD_TRACE_RATE_LIMIT, with an adaptive one. The budget of
+// tracesPerSecond is shared fairly across the (service, resource) pairs of the traces,
+// with at least minPerPair traces per second for each pair, so that a noisy endpoint
+// doesn't starve the quiet ones. The effective rate of each pair is recorded in the
+// _dd.limit_psr metric. It can also be enabled using the DD_TRACE_ADAPTIVE_RATE_LIMIT
+// and DD_TRACE_ADAPTIVE_RATE_LIMIT_MIN environment variables.
+//
+// Like DD_TRACE_RATE_LIMIT, it only applies to the traces sampled by trace sampling
+// rules or by a global sample rate, and has no effect when neither is set, in which
+// case a warning is logged at startup.
+func WithAdaptiveRateLimit(tracesPerSecond, minPerPair float64) StartOption {
+	return func(c *config) {
+		c.adaptiveRateLimit = tracesPerSecond
+		c.adaptiveRateLimitMin = minPerPair
+	}
+}
+
+// WithDebugServer starts an HTTP server listening on addr which serves the most
+// recently finished traces, to help debugging an application locally, e.g. without
+// an agent. The traces are served as JSON at /traces and as an HTML waterfall at /,
+// and can be filtered by service, resource and error status using the service,
+// resource and error query parameters. Traces are recorded as they are written,
+// after sampling, span processors and redaction, so the traces they drop aren't
+// served. The server only keeps a bounded number of traces in memory, and should
+// only be enabled for debugging purposes, on a local address which doesn't collide
+// with the agent, such as "localhost:8080".
+func WithDebugServer(addr string) StartOption {
+	return func(c *config) {
+		c.debugServerAddr = addr
+	}
+}
+
 // WithPartialFlushing enables flushing of partially finished traces.
 // This is done after "numSpans" have finished in a single local trace at
 // which point all finished spans in that trace will be flushed, freeing up
@@ -1198,6 +1399,33 @@ func AnalyticsRate(rate float64) StartSpanOption {
 	return Tag(ext.EventSampleRate, rate)
 }
 
+// SpanEventOption is a configuration option for adding an event to a span. It is
+// aliased in order to help godoc group all the functions returning it together. It
+// is considered more correct to refer to it as the type as the origin,
+// ddtrace.SpanEventOption.
+type SpanEventOption = ddtrace.SpanEventOption
+
+// EventTime sets the time at which a span event occurred. By default, the
+// current time is used.
+func EventTime(t time.Time) SpanEventOption {
+	return func(cfg *ddtrace.SpanEventConfig) {
+		cfg.Time = t
+	}
+}
+
+// EventAttributes sets the given key/value pairs as attributes of a span event.
+// It may be used multiple times.
+func EventAttributes(attrs map[string]interface{}) SpanEventOption {
+	return func(cfg *ddtrace.SpanEventConfig) {
+		if cfg.Attributes == nil {
+			cfg.Attributes = make(map[string]interface{}, len(attrs))
+		}
+		for k, v := range attrs {
+			cfg.Attributes[k] = v
+		}
+	}
+}
+
 // FinishOption is a configuration option for FinishSpan. It is aliased in order
 // to help godoc group all the functions returning it together. It is considered
 // more correct to refer to it as the type as the origin, ddtrace.FinishOption.
@@ -1251,6 +1479,33 @@ func WithHeaderTags(headerAsTags []string) StartOption {
 	}
 }
 
+// WithRedactionRules sets the rules redacting the tags of spans when they finish,
+// e.g. to mask secrets found in custom tags, header tags, SQL resources or error
+// messages. See RedactionRule for details. Rules can also be set through
+// the DD_TRACE_REDACTION_RULES environment variable, as a JSON array of rules:
+//
+//	[{"key": "http.request.headers.*", "action": "mask"}, {"value": "(?i)password=[^&]*", "action": "hash"}]
+func WithRedactionRules(rules ...RedactionRule) StartOption {
+	return func(c *config) {
+		c.redactionRules = newDynamicConfig[redactionRules]("trace_redaction_rules", rules, c.setRedactionRules, equalRedactionRules)
+		c.setRedactionRules(rules)
+	}
+}
+
+// setRedactionRules compiles the given redaction rules and sets them as the ones
+// applied to spans. Always returns true.
+func (c *config) setRedactionRules(rules redactionRules) bool {
+	c.redactor.Store(newRedactor(rules))
+	return true
+}
+
+// setLogsInjection sets whether log correlation integrations add trace information
+// to log records. Always returns true.
+func setLogsInjection(enabled bool) bool {
+	globalconfig.SetLogsInjection(enabled)
+	return true
+}
+
 // setHeaderTags sets the global header tags.
 // Always resets the global value and returns true.
 func setHeaderTags(headerAsTags []string) bool {
@@ -252,7 +252,7 @@ func TestAgentIntegration(t *testing.T) {
 		defer clearIntegrationsForTests()
 
 		cfg.loadContribIntegrations(nil)
-		assert.Equal(t, len(cfg.integrations), 55)
+		assert.Equal(t, len(cfg.integrations), 58)
 		for integrationName, v := range cfg.integrations {
 			assert.False(t, v.Instrumented, "integrationName=%s", integrationName)
 		}
@@ -1160,6 +1160,19 @@ func TestWithTraceEnabled(t *testing.T) {
 	})
 }
 
+func TestLogsInjection(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("DD_LOGS_INJECTION", "false")
+	c := newConfig()
+	assert.False(c.logsInjection.get())
+	assert.True(globalconfig.LogsInjection())
+
+	tracer := newTracer()
+	assert.False(globalconfig.LogsInjection())
+	tracer.Stop()
+	assert.True(globalconfig.LogsInjection())
+}
+
 func TestWithLogStartup(t *testing.T) {
 	c := newConfig()
 	assert.True(t, c.logStartup)
@@ -0,0 +1,481 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package tracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+
+	"github.com/richardartoul/molecule"
+	"github.com/tinylib/msgp/msgp"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// otlpTracesPath is the default path of the OTLP/HTTP traces endpoint.
+const otlpTracesPath = "/v1/traces"
+
+// otlpScopeName is the name of the instrumentation scope reported in OTLP payloads.
+const otlpScopeName = "gopkg.in/DataDog/dd-trace-go.v1"
+
+// OTLP protobuf field numbers, as defined by the messages of the
+// opentelemetry.proto.collector.trace.v1 and opentelemetry.proto.trace.v1
+// packages. See https://github.com/open-telemetry/opentelemetry-proto.
+const (
+	otlpRequestResourceSpans = 1 // ExportTraceServiceRequest.resource_spans
+
+	otlpResourceSpansResource   = 1 // ResourceSpans.resource
+	otlpResourceSpansScopeSpans = 2 // ResourceSpans.scope_spans
+	otlpResourceAttributes      = 1 // Resource.attributes
+
+	otlpScopeSpansScope             = 1 // ScopeSpans.scope
+	otlpScopeSpansSpans             = 2 // ScopeSpans.spans
+	otlpInstrumentationScopeName    = 1 // InstrumentationScope.name
+	otlpInstrumentationScopeVersion = 2 // InstrumentationScope.version
+
+	otlpSpanTraceID      = 1  // Span.trace_id
+	otlpSpanSpanID       = 2  // Span.span_id
+	otlpSpanParentSpanID = 4  // Span.parent_span_id
+	otlpSpanName         = 5  // Span.name
+	otlpSpanKind         = 6  // Span.kind
+	otlpSpanStart        = 7  // Span.start_time_unix_nano
+	otlpSpanEnd          = 8  // Span.end_time_unix_nano
+	otlpSpanAttributes   = 9  // Span.attributes
+	otlpSpanEvents       = 11 // Span.events
+	otlpSpanLinks        = 13 // Span.links
+	otlpSpanStatus       = 15 // Span.status
+	otlpSpanFlags        = 16 // Span.flags
+
+	otlpEventTime       = 1 // Span.Event.time_unix_nano
+	otlpEventName       = 2 // Span.Event.name
+	otlpEventAttributes = 3 // Span.Event.attributes
+
+	otlpLinkTraceID    = 1 // Span.Link.trace_id
+	otlpLinkSpanID     = 2 // Span.Link.span_id
+	otlpLinkTraceState = 3 // Span.Link.trace_state
+	otlpLinkAttributes = 4 // Span.Link.attributes
+	otlpLinkFlags      = 6 // Span.Link.flags
+
+	otlpStatusMessage = 2 // Status.message
+	otlpStatusCode    = 3 // Status.code
+
+	otlpKeyValueKey   = 1 // KeyValue.key
+	otlpKeyValueValue = 2 // KeyValue.value
+
+	otlpAnyValueString = 1 // AnyValue.string_value
+	otlpAnyValueBool   = 2 // AnyValue.bool_value
+	otlpAnyValueInt    = 3 // AnyValue.int_value
+	otlpAnyValueDouble = 4 // AnyValue.double_value
+	otlpAnyValueArray  = 5 // AnyValue.array_value
+	otlpArrayValues    = 1 // ArrayValue.values
+)
+
+// OTLP span kinds (Span.SpanKind).
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+	otlpSpanKindProducer = 4
+	otlpSpanKindConsumer = 5
+)
+
+// otlpStatusCodeError is the OTLP status code of erroneous spans (Status.StatusCode).
+const otlpStatusCodeError = 2
+
+// otlpTraceFlagSampled is the W3C sampled trace flag.
+const otlpTraceFlagSampled = 0x01
+
+// otlpTransport is a transport which sends traces to an OpenTelemetry Collector,
+// or any other receiver supporting OTLP/HTTP with protobuf encoding, instead of
+// the Datadog Agent. It converts the spans of each encoded payload into an
+// OTLP ExportTraceServiceRequest.
+type otlpTransport struct {
+	traceURL string            // the delivery URL for traces
+	client   *http.Client      // the HTTP client used in the POST
+	headers  map[string]string // the Transport headers
+	env      string            // reported as the deployment.environment resource attribute
+	version  string            // reported as the service.version resource attribute
+}
+
+// newOTLPTransport returns a new transport sending traces to the OTLP/HTTP
+// receiver at endpoint. When endpoint has no path, the standard /v1/traces
+// path is used.
+func newOTLPTransport(endpoint string, client *http.Client, env, version string) *otlpTransport {
+	traceURL := endpoint
+	if u, err := url.Parse(endpoint); err == nil && (u.Path == "" || u.Path == "/") {
+		u.Path = otlpTracesPath
+		traceURL = u.String()
+	}
+	return &otlpTransport{
+		traceURL: traceURL,
+		client:   client,
+		headers: map[string]string{
+			"Content-Type": "application/x-protobuf",
+		},
+		env:     env,
+		version: version,
+	}
+}
+
+func (t *otlpTransport) send(p *payload) (body io.ReadCloser, err error) {
+	var traces spanLists
+	if err := msgp.Decode(p, &traces); err != nil {
+		return nil, fmt.Errorf("cannot decode payload: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := t.encode(&buf, traces); err != nil {
+		return nil, fmt.Errorf("cannot encode OTLP request: %v", err)
+	}
+	req, err := http.NewRequest("POST", t.traceURL, &buf)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create http request: %v", err)
+	}
+	for header, value := range t.headers {
+		req.Header.Set(header, value)
+	}
+	response, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if code := response.StatusCode; code >= 400 {
+		// error, check the body for context information and
+		// return a nice error.
+		msg := make([]byte, 1000)
+		n, _ := response.Body.Read(msg)
+		txt := http.StatusText(code)
+		if n > 0 {
+			return nil, fmt.Errorf("%s (Status: %s)", msg[:n], txt)
+		}
+		return nil, fmt.Errorf("%s", txt)
+	}
+	// OTLP receivers don't provide sampling rates, so the priority sampler is
+	// handed an empty set of rates.
+	return io.NopCloser(strings.NewReader("{}")), nil
+}
+
+// sendStats is a no-op: client-side stats are specific to the Datadog Agent.
+func (t *otlpTransport) sendStats(_ *statsPayload) error {
+	return nil
+}
+
+func (t *otlpTransport) endpoint() string {
+	return t.traceURL
+}
+
+// encode writes traces into w as an OTLP ExportTraceServiceRequest. Spans are
+// grouped into one ResourceSpans per service.
+func (t *otlpTransport) encode(w io.Writer, traces spanLists) error {
+	type otlpSpan struct {
+		*span
+		traceIDUpper uint64
+	}
+	var services []string
+	byService := make(map[string][]otlpSpan)
+	for _, trace := range traces {
+		var upper uint64
+		for _, s := range trace {
+			if v, ok := s.Meta[keyTraceID128]; ok {
+				upper, _ = strconv.ParseUint(v, 16, 64)
+				break
+			}
+		}
+		for _, s := range trace {
+			if _, ok := byService[s.Service]; !ok {
+				services = append(services, s.Service)
+			}
+			byService[s.Service] = append(byService[s.Service], otlpSpan{s, upper})
+		}
+	}
+	ps := molecule.NewProtoStream(w)
+	for _, service := range services {
+		err := ps.Embedded(otlpRequestResourceSpans, func(ps *molecule.ProtoStream) error {
+			err := ps.Embedded(otlpResourceSpansResource, func(ps *molecule.ProtoStream) error {
+				attrs := []struct{ k, v string }{
+					{"service.name", service},
+					{"deployment.environment", t.env},
+					{"service.version", t.version},
+					{"telemetry.sdk.name", "datadog"},
+					{"telemetry.sdk.language", "go"},
+					{"telemetry.sdk.version", version.Tag},
+				}
+				for _, a := range attrs {
+					if a.v == "" {
+						continue
+					}
+					if err := otlpEncodeKeyValue(ps, otlpResourceAttributes, a.k, a.v); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			return ps.Embedded(otlpResourceSpansScopeSpans, func(ps *molecule.ProtoStream) error {
+				err := ps.Embedded(otlpScopeSpansScope, func(ps *molecule.ProtoStream) error {
+					if err := ps.String(otlpInstrumentationScopeName, otlpScopeName); err != nil {
+						return err
+					}
+					return ps.String(otlpInstrumentationScopeVersion, version.Tag)
+				})
+				if err != nil {
+					return err
+				}
+				for _, s := range byService[service] {
+					s := s
+					err := ps.Embedded(otlpScopeSpansSpans, func(ps *molecule.ProtoStream) error {
+						return otlpEncodeSpan(ps, s.span, s.traceIDUpper)
+					})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// otlpEncodeSpan encodes s as an OTLP Span. traceIDUpper holds the upper 64
+// bits of the trace ID, or 0 if it is a 64-bit trace ID.
+func otlpEncodeSpan(ps *molecule.ProtoStream, s *span, traceIDUpper uint64) error {
+	if err := ps.Bytes(otlpSpanTraceID, otlpTraceID(traceIDUpper, s.TraceID)); err != nil {
+		return err
+	}
+	if err := ps.Bytes(otlpSpanSpanID, otlpSpanID(s.SpanID)); err != nil {
+		return err
+	}
+	if s.ParentID != 0 {
+		if err := ps.Bytes(otlpSpanParentSpanID, otlpSpanID(s.ParentID)); err != nil {
+			return err
+		}
+	}
+	name := s.Resource
+	if name == "" {
+		name = s.Name
+	}
+	if err := ps.String(otlpSpanName, name); err != nil {
+		return err
+	}
+	if err := ps.Int32(otlpSpanKind, otlpKind(s.Meta[ext.SpanKind])); err != nil {
+		return err
+	}
+	if err := ps.Fixed64(otlpSpanStart, uint64(s.Start)); err != nil {
+		return err
+	}
+	if err := ps.Fixed64(otlpSpanEnd, uint64(s.Start+s.Duration)); err != nil {
+		return err
+	}
+	for _, kv := range []struct{ k, v string }{
+		{"operation.name", s.Name},
+		{"resource.name", s.Resource},
+		{"span.type", s.Type},
+	} {
+		if kv.v == "" {
+			continue
+		}
+		if err := otlpEncodeKeyValue(ps, otlpSpanAttributes, kv.k, kv.v); err != nil {
+			return err
+		}
+	}
+	for _, k := range sortedKeys(s.Meta) {
+		if err := otlpEncodeKeyValue(ps, otlpSpanAttributes, k, s.Meta[k]); err != nil {
+			return err
+		}
+	}
+	for _, k := range sortedKeys(s.Metrics) {
+		if err := otlpEncodeKeyValue(ps, otlpSpanAttributes, k, s.Metrics[k]); err != nil {
+			return err
+		}
+	}
+	priority, hasPriority := s.Metrics[keySamplingPriority]
+	if hasPriority {
+		if err := otlpEncodeKeyValue(ps, otlpSpanAttributes, "sampling.priority", int64(priority)); err != nil {
+			return err
+		}
+	}
+	for _, e := range s.SpanEvents {
+		e := e
+		err := ps.Embedded(otlpSpanEvents, func(ps *molecule.ProtoStream) error {
+			if err := ps.Fixed64(otlpEventTime, e.TimeUnixNano); err != nil {
+				return err
+			}
+			if err := ps.String(otlpEventName, e.Name); err != nil {
+				return err
+			}
+			for _, k := range sortedKeys(e.Attributes) {
+				if err := otlpEncodeKeyValue(ps, otlpEventAttributes, k, e.Attributes[k]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, l := range s.SpanLinks {
+		l := l
+		err := ps.Embedded(otlpSpanLinks, func(ps *molecule.ProtoStream) error {
+			if err := ps.Bytes(otlpLinkTraceID, otlpTraceID(l.TraceIDHigh, l.TraceID)); err != nil {
+				return err
+			}
+			if err := ps.Bytes(otlpLinkSpanID, otlpSpanID(l.SpanID)); err != nil {
+				return err
+			}
+			if err := ps.String(otlpLinkTraceState, l.Tracestate); err != nil {
+				return err
+			}
+			for _, k := range sortedKeys(l.Attributes) {
+				if err := otlpEncodeKeyValue(ps, otlpLinkAttributes, k, l.Attributes[k]); err != nil {
+					return err
+				}
+			}
+			// the highest bit is used by Datadog to tell whether the flags are
+			// set, which has no equivalent in OTLP.
+			return ps.Fixed32(otlpLinkFlags, l.Flags&0xff)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if s.Error != 0 {
+		err := ps.Embedded(otlpSpanStatus, func(ps *molecule.ProtoStream) error {
+			if err := ps.String(otlpStatusMessage, s.Meta[ext.ErrorMsg]); err != nil {
+				return err
+			}
+			return ps.Int32(otlpStatusCode, otlpStatusCodeError)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if hasPriority && priority > 0 {
+		return ps.Fixed32(otlpSpanFlags, otlpTraceFlagSampled)
+	}
+	return nil
+}
+
+// otlpEncodeKeyValue encodes the attribute k=v as a KeyValue in the given field.
+func otlpEncodeKeyValue(ps *molecule.ProtoStream, field int, k string, v interface{}) error {
+	return ps.Embedded(field, func(ps *molecule.ProtoStream) error {
+		if err := ps.String(otlpKeyValueKey, k); err != nil {
+			return err
+		}
+		return ps.Embedded(otlpKeyValueValue, func(ps *molecule.ProtoStream) error {
+			return otlpEncodeAnyValue(ps, v)
+		})
+	})
+}
+
+// otlpEncodeAnyValue encodes v as the contents of an AnyValue. Unlike the
+// ProtoStream methods, zero values are written, as they are part of a oneof.
+func otlpEncodeAnyValue(ps *molecule.ProtoStream, v interface{}) error {
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = protowire.AppendTag(b, otlpAnyValueString, protowire.BytesType)
+		b = protowire.AppendString(b, v)
+	case bool:
+		b = protowire.AppendTag(b, otlpAnyValueBool, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(v))
+	case float64:
+		b = protowire.AppendTag(b, otlpAnyValueDouble, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(v))
+	case float32:
+		return otlpEncodeAnyValue(ps, float64(v))
+	case int, int8, int16, int32, int64:
+		b = protowire.AppendTag(b, otlpAnyValueInt, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(reflect.ValueOf(v).Int()))
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(v).Uint()
+		if u > math.MaxInt64 {
+			return otlpEncodeAnyValue(ps, strconv.FormatUint(u, 10))
+		}
+		return otlpEncodeAnyValue(ps, int64(u))
+	case []interface{}:
+		return ps.Embedded(otlpAnyValueArray, func(ps *molecule.ProtoStream) error {
+			for _, e := range v {
+				e := e
+				err := ps.Embedded(otlpArrayValues, func(ps *molecule.ProtoStream) error {
+					return otlpEncodeAnyValue(ps, e)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	default:
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+			vals := make([]interface{}, rv.Len())
+			for i := range vals {
+				vals[i] = rv.Index(i).Interface()
+			}
+			return otlpEncodeAnyValue(ps, vals)
+		}
+		return otlpEncodeAnyValue(ps, fmt.Sprint(v))
+	}
+	_, err := ps.Write(b)
+	return err
+}
+
+// otlpTraceID returns the 16 bytes of the trace ID made of the given upper and lower bits.
+func otlpTraceID(upper, lower uint64) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], upper)
+	binary.BigEndian.PutUint64(b[8:], lower)
+	return b
+}
+
+// otlpSpanID returns the 8 bytes of the span ID id.
+func otlpSpanID(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
+
+// otlpKind returns the OTLP span kind corresponding to the span.kind tag value k.
+func otlpKind(k string) int32 {
+	switch k {
+	case ext.SpanKindServer:
+		return otlpSpanKindServer
+	case ext.SpanKindClient:
+		return otlpSpanKindClient
+	case ext.SpanKindProducer:
+		return otlpSpanKindProducer
+	case ext.SpanKindConsumer:
+		return otlpSpanKindConsumer
+	default:
+		return otlpSpanKindInternal
+	}
+}
+
+// sortedKeys returns the keys of m in lexical order, so that the encoding is deterministic.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,285 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package tracer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/version"
+
+	"github.com/richardartoul/molecule"
+	"github.com/richardartoul/molecule/src/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// otlpCollector is a stand-in for an OpenTelemetry Collector, receiving
+// OTLP/HTTP trace requests.
+type otlpCollector struct {
+	*httptest.Server
+	requests chan []byte
+}
+
+func newOTLPCollector(t *testing.T) *otlpCollector {
+	c := &otlpCollector{requests: make(chan []byte, 10)}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != otlpTracesPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		b, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		c.requests <- b
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *otlpCollector) next(t *testing.T) []byte {
+	select {
+	case b := <-c.requests:
+		return b
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an OTLP request")
+		return nil
+	}
+}
+
+// protoFields decodes the protobuf message b into its values, by field number.
+func protoFields(t *testing.T, b []byte) map[int32][]molecule.Value {
+	fields := make(map[int32][]molecule.Value)
+	err := molecule.MessageEach(codec.NewBuffer(b), func(n int32, v molecule.Value) (bool, error) {
+		fields[n] = append(fields[n], v)
+		return true, nil
+	})
+	require.NoError(t, err)
+	return fields
+}
+
+// protoMessages decodes the embedded messages in vals.
+func protoMessages(t *testing.T, vals []molecule.Value) []map[int32][]molecule.Value {
+	var msgs []map[int32][]molecule.Value
+	for _, v := range vals {
+		b, err := v.AsBytesUnsafe()
+		require.NoError(t, err)
+		msgs = append(msgs, protoFields(t, b))
+	}
+	return msgs
+}
+
+func protoString(t *testing.T, m map[int32][]molecule.Value, field int32) string {
+	if len(m[field]) == 0 {
+		return ""
+	}
+	s, err := m[field][0].AsStringSafe()
+	require.NoError(t, err)
+	return s
+}
+
+func protoBytes(t *testing.T, m map[int32][]molecule.Value, field int32) []byte {
+	if len(m[field]) == 0 {
+		return nil
+	}
+	b, err := m[field][0].AsBytesSafe()
+	require.NoError(t, err)
+	return b
+}
+
+func protoUint(t *testing.T, m map[int32][]molecule.Value, field int32) uint64 {
+	if len(m[field]) == 0 {
+		return 0
+	}
+	return m[field][0].Number
+}
+
+// protoAttributes decodes the KeyValue messages in vals.
+func protoAttributes(t *testing.T, vals []molecule.Value) map[string]interface{} {
+	attrs := make(map[string]interface{})
+	for _, kv := range protoMessages(t, vals) {
+		any := protoMessages(t, kv[otlpKeyValueValue])[0]
+		var v interface{}
+		switch {
+		case len(any[otlpAnyValueString]) > 0:
+			v = protoString(t, any, otlpAnyValueString)
+		case len(any[otlpAnyValueBool]) > 0:
+			v, _ = any[otlpAnyValueBool][0].AsBool()
+		case len(any[otlpAnyValueInt]) > 0:
+			v, _ = any[otlpAnyValueInt][0].AsInt64()
+		case len(any[otlpAnyValueDouble]) > 0:
+			v, _ = any[otlpAnyValueDouble][0].AsDouble()
+		}
+		attrs[protoString(t, kv, otlpKeyValueKey)] = v
+	}
+	return attrs
+}
+
+func TestOTLPTransport(t *testing.T) {
+	collector := newOTLPCollector(t)
+	trans := newOTLPTransport(collector.URL, defaultClient, "prod", "1.2.3")
+	assert.Equal(t, collector.URL+otlpTracesPath, trans.endpoint())
+
+	root := newBasicSpan("http.request")
+	root.Service = "web"
+	root.Resource = "GET /users"
+	root.TraceID = 0x1111
+	root.SpanID = 0x1111
+	root.Start = 1000
+	root.Duration = 500
+	root.Error = 1
+	root.Meta[keyTraceID128] = "6560f26a00000001"
+	root.Meta[ext.SpanKind] = ext.SpanKindServer
+	root.Meta[ext.ErrorMsg] = "boom"
+	root.Metrics[keySamplingPriority] = 2
+	root.SpanLinks = []ddtrace.SpanLink{{
+		TraceID:     0x2,
+		TraceIDHigh: 0x1,
+		SpanID:      0x3,
+		Attributes:  map[string]string{"link.kind": "follows"},
+		Flags:       1 | 1<<31,
+	}}
+	root.SpanEvents = []ddtrace.SpanEvent{{
+		Name:         "cache miss",
+		TimeUnixNano: 1200,
+		Attributes:   map[string]interface{}{"hit": false, "count": int64(0), "key": "a"},
+	}}
+	child := newBasicSpan("db.query")
+	child.Service = "db"
+	child.TraceID = 0x1111
+	child.SpanID = 0x2222
+	child.ParentID = 0x1111
+	child.Meta[ext.SpanKind] = ext.SpanKindClient
+
+	p, err := encode([][]*span{{root, child}})
+	require.NoError(t, err)
+	body, err := trans.send(p)
+	require.NoError(t, err)
+	body.Close()
+
+	assert := assert.New(t)
+	req := protoFields(t, collector.next(t))
+	resourceSpans := protoMessages(t, req[otlpRequestResourceSpans])
+	require.Len(t, resourceSpans, 2)
+
+	// web
+	resource := protoMessages(t, resourceSpans[0][otlpResourceSpansResource])[0]
+	assert.Equal(map[string]interface{}{
+		"service.name":           "web",
+		"deployment.environment": "prod",
+		"service.version":        "1.2.3",
+		"telemetry.sdk.name":     "datadog",
+		"telemetry.sdk.language": "go",
+		"telemetry.sdk.version":  version.Tag,
+	}, protoAttributes(t, resource[otlpResourceAttributes]))
+	scopeSpans := protoMessages(t, resourceSpans[0][otlpResourceSpansScopeSpans])[0]
+	scope := protoMessages(t, scopeSpans[otlpScopeSpansScope])[0]
+	assert.Equal(otlpScopeName, protoString(t, scope, otlpInstrumentationScopeName))
+	spans := protoMessages(t, scopeSpans[otlpScopeSpansSpans])
+	require.Len(t, spans, 1)
+	s := spans[0]
+	assert.Equal([]byte{0x65, 0x60, 0xf2, 0x6a, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0x11, 0x11}, protoBytes(t, s, otlpSpanTraceID))
+	assert.Equal([]byte{0, 0, 0, 0, 0, 0, 0x11, 0x11}, protoBytes(t, s, otlpSpanSpanID))
+	assert.Nil(protoBytes(t, s, otlpSpanParentSpanID))
+	assert.Equal("GET /users", protoString(t, s, otlpSpanName))
+	assert.Equal(uint64(otlpSpanKindServer), protoUint(t, s, otlpSpanKind))
+	assert.Equal(uint64(1000), protoUint(t, s, otlpSpanStart))
+	assert.Equal(uint64(1500), protoUint(t, s, otlpSpanEnd))
+	assert.Equal(uint64(otlpTraceFlagSampled), protoUint(t, s, otlpSpanFlags))
+	attrs := protoAttributes(t, s[otlpSpanAttributes])
+	assert.Equal("http.request", attrs["operation.name"])
+	assert.Equal("GET /users", attrs["resource.name"])
+	assert.Equal(int64(2), attrs["sampling.priority"])
+	assert.Equal(2.0, attrs[keySamplingPriority])
+	assert.Equal("boom", attrs[ext.ErrorMsg])
+
+	status := protoMessages(t, s[otlpSpanStatus])[0]
+	assert.Equal(uint64(otlpStatusCodeError), protoUint(t, status, otlpStatusCode))
+	assert.Equal("boom", protoString(t, status, otlpStatusMessage))
+
+	events := protoMessages(t, s[otlpSpanEvents])
+	require.Len(t, events, 1)
+	assert.Equal("cache miss", protoString(t, events[0], otlpEventName))
+	assert.Equal(uint64(1200), protoUint(t, events[0], otlpEventTime))
+	assert.Equal(map[string]interface{}{"hit": false, "count": int64(0), "key": "a"}, protoAttributes(t, events[0][otlpEventAttributes]))
+
+	links := protoMessages(t, s[otlpSpanLinks])
+	require.Len(t, links, 1)
+	assert.Equal([]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}, protoBytes(t, links[0], otlpLinkTraceID))
+	assert.Equal([]byte{0, 0, 0, 0, 0, 0, 0, 3}, protoBytes(t, links[0], otlpLinkSpanID))
+	assert.Equal(uint64(1), protoUint(t, links[0], otlpLinkFlags))
+	assert.Equal(map[string]interface{}{"link.kind": "follows"}, protoAttributes(t, links[0][otlpLinkAttributes]))
+
+	// db
+	resource = protoMessages(t, resourceSpans[1][otlpResourceSpansResource])[0]
+	assert.Equal("db", protoAttributes(t, resource[otlpResourceAttributes])["service.name"])
+	scopeSpans = protoMessages(t, resourceSpans[1][otlpResourceSpansScopeSpans])[0]
+	spans = protoMessages(t, scopeSpans[otlpScopeSpansSpans])
+	require.Len(t, spans, 1)
+	s = spans[0]
+	// the upper bits of the trace ID are taken from the chunk's first span
+	assert.Equal([]byte{0x65, 0x60, 0xf2, 0x6a, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0x11, 0x11}, protoBytes(t, s, otlpSpanTraceID))
+	assert.Equal([]byte{0, 0, 0, 0, 0, 0, 0x11, 0x11}, protoBytes(t, s, otlpSpanParentSpanID))
+	assert.Equal("db.query", protoString(t, s, otlpSpanName))
+	assert.Equal(uint64(otlpSpanKindClient), protoUint(t, s, otlpSpanKind))
+	assert.Equal(uint64(0), protoUint(t, s, otlpSpanFlags))
+	assert.Empty(s[otlpSpanStatus])
+}
+
+func TestOTLPTransportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid request"))
+	}))
+	defer srv.Close()
+	trans := newOTLPTransport(srv.URL, defaultClient, "", "")
+	p, err := encode([][]*span{{newBasicSpan("a")}})
+	require.NoError(t, err)
+	_, err = trans.send(p)
+	assert.EqualError(t, err, "invalid request (Status: Bad Request)")
+}
+
+func TestOTLPTransportEndpoint(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://localhost:4318":         "http://localhost:4318/v1/traces",
+		"http://localhost:4318/":        "http://localhost:4318/v1/traces",
+		"https://collector/custom/path": "https://collector/custom/path",
+	} {
+		assert.Equal(t, want, newOTLPTransport(in, defaultClient, "", "").endpoint())
+	}
+}
+
+func TestWithOTLPExporter(t *testing.T) {
+	collector := newOTLPCollector(t)
+	tr := newTracer(WithOTLPExporter(collector.URL), WithService("otlp-svc"), WithEnv("test"))
+	internal.SetGlobalTracer(tr)
+	defer internal.SetGlobalTracer(&internal.NoopTracer{})
+	assert.IsType(t, &otlpTransport{}, tr.config.transport)
+	assert.False(t, tr.config.canComputeStats())
+
+	sp := tr.StartSpan("op", ResourceName("res"))
+	sp.Finish()
+	tr.Stop()
+
+	req := protoFields(t, collector.next(t))
+	resourceSpans := protoMessages(t, req[otlpRequestResourceSpans])
+	require.Len(t, resourceSpans, 1)
+	resource := protoMessages(t, resourceSpans[0][otlpResourceSpansResource])[0]
+	attrs := protoAttributes(t, resource[otlpResourceAttributes])
+	assert.Equal(t, "otlp-svc", attrs["service.name"])
+	assert.Equal(t, "test", attrs["deployment.environment"])
+	scopeSpans := protoMessages(t, resourceSpans[0][otlpResourceSpansScopeSpans])[0]
+	spans := protoMessages(t, scopeSpans[otlpScopeSpansSpans])
+	require.Len(t, spans, 1)
+	assert.Equal(t, "res", protoString(t, spans[0], otlpSpanName))
+	assert.Equal(t, uint64(otlpTraceFlagSampled), protoUint(t, spans[0], otlpSpanFlags))
+}
@@ -70,6 +70,16 @@ func newPayload() *payload {
 	return p
 }
 
+// newPayloadFromBytes returns a payload holding the given count of msgpack-encoded
+// items, as previously read from the buffer of another payload.
+func newPayloadFromBytes(b []byte, count uint32) *payload {
+	p := newPayload()
+	p.buf = *bytes.NewBuffer(b)
+	p.count = count
+	p.updateHeader()
+	return p
+}
+
 // push pushes a new item into the stream.
 func (p *payload) push(t spanList) error {
 	p.buf.Grow(t.Msgsize())
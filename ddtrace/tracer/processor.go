@@ -0,0 +1,236 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	ginternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// SpanProcessor processes spans once they are finished and before they are
+// written, e.g. to scrub sensitive tags, to add computed tags or to drop traces.
+// Processors are registered using WithSpanProcessor.
+type SpanProcessor interface {
+	// OnFinish is called synchronously by the Finish method of every span, once
+	// its duration is known. It is called before the span is used to compute
+	// client-side stats and before it is involved in any sampling decision, so
+	// that these reflect the changes made by the processor. The span must not be
+	// retained nor used once OnFinish returns.
+	OnFinish(s ReadWriteSpan)
+
+	// OnChunk is called from the tracer's worker goroutine with every chunk of
+	// finished spans, after the sampling rules have been applied to the chunk and
+	// right before it is written. A chunk holds the spans of a trace, or a part of
+	// them when partial flushing is enabled. Spans may be removed from c.Spans,
+	// and the whole chunk is dropped when OnChunk returns false or removes all of
+	// its spans. The trace-level tags, set on the first span of the chunk, are
+	// moved to the new first span when it is removed. The tags set by OnChunk
+	// are redacted by the redaction rules once it returns. Dropped spans are
+	// still accounted for in client-side stats. The spans must not be retained nor
+	// used once OnChunk returns.
+	OnChunk(c *Chunk) (keep bool)
+}
+
+// Chunk holds the finished spans of a trace handed to a SpanProcessor.
+type Chunk struct {
+	// Spans holds the spans of the chunk, in the order in which they were started.
+	Spans []ReadWriteSpan
+}
+
+// ReadWriteSpan is a finished span handed to a SpanProcessor. Unlike the
+// span returned by StartSpan, it can be read and modified after it was
+// finished. It is not safe for concurrent use.
+type ReadWriteSpan interface {
+	// Context returns the span's context, holding its span and trace IDs.
+	Context() ddtrace.SpanContext
+
+	// ParentID returns the ID of the span's parent, or 0 if it has none.
+	ParentID() uint64
+
+	// OperationName returns the span's operation name.
+	OperationName() string
+
+	// SetOperationName sets the span's operation name.
+	SetOperationName(name string)
+
+	// StartTime returns the time at which the span was started.
+	StartTime() time.Time
+
+	// Duration returns the duration of the span.
+	Duration() time.Duration
+
+	// IsError reports whether the span is flagged as an error.
+	IsError() bool
+
+	// Tag returns the value of the tag key, which may be ext.ServiceName,
+	// ext.ResourceName or ext.SpanType, and whether it is set.
+	Tag(key string) (value interface{}, ok bool)
+
+	// Tags returns a copy of the span's string and numeric tags.
+	Tags() map[string]interface{}
+
+	// SetTag sets a tag on the span, the same way Span.SetTag does.
+	SetTag(key string, value interface{})
+
+	// DeleteTag removes the tag key from the span.
+	DeleteTag(key string)
+}
+
+// processedSpan implements ReadWriteSpan. The span is accessed without locking,
+// as it is either locked by Finish or finished and only accessed by the worker.
+type processedSpan struct {
+	s *span
+	// set, when not nil, records the keys of the tags set on the span.
+	set map[string]struct{}
+}
+
+var _ ReadWriteSpan = processedSpan{}
+
+func (p processedSpan) Context() ddtrace.SpanContext { return p.s.context }
+
+func (p processedSpan) ParentID() uint64 { return p.s.ParentID }
+
+func (p processedSpan) OperationName() string { return p.s.Name }
+
+func (p processedSpan) SetOperationName(name string) { p.s.Name = name }
+
+func (p processedSpan) StartTime() time.Time { return time.Unix(0, p.s.Start) }
+
+func (p processedSpan) Duration() time.Duration { return time.Duration(p.s.Duration) }
+
+func (p processedSpan) IsError() bool { return p.s.Error != 0 }
+
+func (p processedSpan) Tag(key string) (interface{}, bool) {
+	switch key {
+	case ext.SpanName:
+		return p.s.Name, true
+	case ext.ServiceName:
+		return p.s.Service, true
+	case ext.ResourceName:
+		return p.s.Resource, true
+	case ext.SpanType:
+		return p.s.Type, true
+	}
+	if v, ok := p.s.Meta[key]; ok {
+		return v, true
+	}
+	if v, ok := p.s.Metrics[key]; ok {
+		return v, true
+	}
+	return nil, false
+}
+
+func (p processedSpan) Tags() map[string]interface{} {
+	tags := make(map[string]interface{}, len(p.s.Meta)+len(p.s.Metrics))
+	for k, v := range p.s.Meta {
+		tags[k] = v
+	}
+	for k, v := range p.s.Metrics {
+		tags[k] = v
+	}
+	return tags
+}
+
+func (p processedSpan) SetTag(key string, value interface{}) {
+	p.s.setTagLocked(key, value)
+	if p.set != nil {
+		p.set[key] = struct{}{}
+	}
+}
+
+func (p processedSpan) DeleteTag(key string) {
+	delete(p.s.Meta, key)
+	delete(p.s.Metrics, key)
+}
+
+// processFinishedSpan runs the OnFinish method of the configured processors on s.
+func (t *tracer) processFinishedSpan(s *span) {
+	for _, p := range t.config.spanProcessors {
+		p.OnFinish(processedSpan{s: s})
+	}
+}
+
+// processChunk runs the OnChunk method of the configured processors on c, in
+// order, and reports whether the chunk should be kept. The spans removed by the
+// processors are removed from c, and the tags they set are redacted.
+func (t *tracer) processChunk(c *chunk) bool {
+	if len(t.config.spanProcessors) == 0 {
+		return true
+	}
+	r := t.config.redactor.Load()
+	pc := &Chunk{Spans: make([]ReadWriteSpan, len(c.spans))}
+	for i, s := range c.spans {
+		ps := processedSpan{s: s}
+		if r != nil {
+			ps.set = make(map[string]struct{})
+		}
+		pc.Spans[i] = ps
+	}
+	for _, p := range t.config.spanProcessors {
+		if !p.OnChunk(pc) {
+			log.Debug("Span processor dropped a chunk of %d spans", len(c.spans))
+			atomic.AddUint32(&t.droppedProcessorChunks, 1)
+			return false
+		}
+	}
+	first := c.spans[0]
+	spans := make([]*span, 0, len(pc.Spans))
+	for _, s := range pc.Spans {
+		if ps, ok := s.(processedSpan); ok {
+			for k := range ps.set {
+				r.redactTag(ps.s, k)
+			}
+			spans = append(spans, ps.s)
+		}
+	}
+	if len(spans) == 0 {
+		log.Debug("Span processors removed all %d spans of a chunk", len(c.spans))
+		atomic.AddUint32(&t.droppedProcessorChunks, 1)
+		return false
+	}
+	if spans[0] != first {
+		moveTraceTags(first, spans[0])
+	}
+	c.spans = spans
+	return true
+}
+
+// moveTraceTags copies the trace-level tags, which are only set on the first
+// span of a chunk, from the former first span of a chunk to the new one, once
+// the processors removed it.
+func moveTraceTags(from, to *span) {
+	if v, ok := from.Metrics[keySamplingPriority]; ok {
+		to.setMetric(keySamplingPriority, v)
+	}
+	for k, v := range from.Meta {
+		if strings.HasPrefix(k, "_dd.p.") {
+			to.setMeta(k, v)
+		}
+	}
+	keys := []string{keyTracerHostname}
+	if from.context != nil && from.context.trace != nil {
+		tr := from.context.trace
+		tr.mu.RLock()
+		for k := range tr.tags {
+			keys = append(keys, k)
+		}
+		tr.mu.RUnlock()
+	}
+	for k := range ginternal.GetTracerGitMetadataTags() {
+		keys = append(keys, k)
+	}
+	for _, k := range keys {
+		if v, ok := from.Meta[k]; ok {
+			to.setMeta(k, v)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProcessor calls the given functions, when set.
+type testProcessor struct {
+	onFinish func(s ReadWriteSpan)
+	onChunk  func(c *Chunk) bool
+}
+
+func (p *testProcessor) OnFinish(s ReadWriteSpan) {
+	if p.onFinish != nil {
+		p.onFinish(s)
+	}
+}
+
+func (p *testProcessor) OnChunk(c *Chunk) bool {
+	if p.onChunk != nil {
+		return p.onChunk(c)
+	}
+	return true
+}
+
+func TestSpanProcessor(t *testing.T) {
+	t.Run("on-finish", func(t *testing.T) {
+		redact := &testProcessor{
+			onFinish: func(s ReadWriteSpan) {
+				for k := range s.Tags() {
+					if strings.HasPrefix(k, "user.") {
+						s.SetTag(k, "redacted")
+					}
+				}
+				s.DeleteTag("secret")
+				if v, ok := s.Tag(ext.HTTPCode); ok && v == "500" {
+					s.SetTag("computed", true)
+				}
+			},
+		}
+		tracer, transport, flush, stop := startTestTracer(t, WithSpanProcessor(redact))
+		defer stop()
+
+		sp := tracer.StartSpan("web.request", Tag("user.email", "a@b.c"), Tag("secret", 42), Tag(ext.HTTPCode, "500"))
+		sp.Finish()
+		flush(1)
+
+		spans := transport.Traces()[0]
+		require.Len(t, spans, 1)
+		assert.Equal(t, "redacted", spans[0].Meta["user.email"])
+		assert.Equal(t, "true", spans[0].Meta["computed"])
+		assert.NotContains(t, spans[0].Metrics, "secret")
+	})
+
+	t.Run("on-chunk", func(t *testing.T) {
+		dropHealth := &testProcessor{
+			onChunk: func(c *Chunk) bool {
+				for _, s := range c.Spans {
+					if r, _ := s.Tag(ext.ResourceName); r == "GET /health" {
+						return false
+					}
+				}
+				return true
+			},
+		}
+		dropCache := &testProcessor{
+			onChunk: func(c *Chunk) bool {
+				kept := c.Spans[:0]
+				for _, s := range c.Spans {
+					if s.OperationName() != "cache.get" {
+						kept = append(kept, s)
+					}
+				}
+				c.Spans = kept
+				return true
+			},
+		}
+		tracer, transport, flush, stop := startTestTracer(t, WithSpanProcessor(dropHealth), WithSpanProcessor(dropCache))
+		defer stop()
+
+		tracer.StartSpan("web.request", ResourceName("GET /health")).Finish()
+		root := tracer.StartSpan("web.request", ResourceName("GET /users"))
+		tracer.StartSpan("cache.get", ChildOf(root.Context())).Finish()
+		tracer.StartSpan("db.query", ChildOf(root.Context())).Finish()
+		root.Finish()
+		flush(1)
+
+		traces := transport.Traces()
+		require.Len(t, traces, 1)
+		require.Len(t, traces[0], 2)
+		assert.Equal(t, "GET /users", traces[0][0].Resource)
+		assert.Equal(t, "db.query", traces[0][1].Name)
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		p := &testProcessor{
+			onChunk: func(c *Chunk) bool {
+				if c.Spans[0].OperationName() == "drop" {
+					return false
+				}
+				c.Spans = c.Spans[:0]
+				return true
+			},
+		}
+		tracer, transport, flush, stop := startTestTracer(t, WithSpanProcessor(p))
+		defer stop()
+
+		tracer.StartSpan("drop").Finish()
+		tracer.StartSpan("empty").Finish()
+		assert.Eventually(t, func() bool {
+			return atomic.LoadUint32(&tracer.droppedProcessorChunks) == 2
+		}, time.Second, 10*time.Millisecond)
+		flush(0)
+		assert.Empty(t, transport.Traces())
+	})
+
+	t.Run("trace-tags", func(t *testing.T) {
+		dropRoot := &testProcessor{
+			onChunk: func(c *Chunk) bool {
+				c.Spans = c.Spans[1:]
+				return true
+			},
+		}
+		tracer, transport, flush, stop := startTestTracer(t, WithSpanProcessor(dropRoot), WithGlobalTag("env", "test"))
+		defer stop()
+
+		root := tracer.StartSpan("web.request")
+		root.(*span).context.trace.setPropagatingTag("_dd.p.usr", "user")
+		tracer.StartSpan("db.query", ChildOf(root.Context())).Finish()
+		root.Finish()
+		flush(1)
+
+		spans := transport.Traces()[0]
+		require.Len(t, spans, 1)
+		assert.Equal(t, "db.query", spans[0].Name)
+		assert.Contains(t, spans[0].Metrics, keySamplingPriority)
+		assert.Equal(t, "user", spans[0].Meta["_dd.p.usr"])
+		assert.Contains(t, spans[0].Meta, keyDecisionMaker)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		var got []ReadWriteSpan
+		p := &testProcessor{
+			onChunk: func(c *Chunk) bool {
+				got = append(got, c.Spans...)
+				return true
+			},
+		}
+		tracer, _, flush, stop := startTestTracer(t, WithSpanProcessor(p))
+		defer stop()
+
+		root := tracer.StartSpan("web.request", ServiceName("web"), ResourceName("GET /"), SpanType(ext.SpanTypeWeb))
+		child := tracer.StartSpan("db.query", ChildOf(root.Context()))
+		child.Finish(WithError(errors.New("boom")))
+		root.Finish()
+		flush(1)
+
+		require.Len(t, got, 2)
+		assert.Equal(t, "web.request", got[0].OperationName())
+		assert.Equal(t, uint64(0), got[0].ParentID())
+		assert.False(t, got[0].IsError())
+		assert.False(t, got[0].StartTime().IsZero())
+		for k, want := range map[string]string{ext.ServiceName: "web", ext.ResourceName: "GET /", ext.SpanType: ext.SpanTypeWeb} {
+			v, ok := got[0].Tag(k)
+			assert.True(t, ok)
+			assert.Equal(t, want, v)
+		}
+		_, ok := got[0].Tag("missing")
+		assert.False(t, ok)
+		assert.Equal(t, root.Context().SpanID(), got[1].ParentID())
+		assert.True(t, got[1].IsError())
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		// OnFinish runs before sampling decisions are locked down, so they can
+		// be made by the processor.
+		keep := &testProcessor{
+			onFinish: func(s ReadWriteSpan) {
+				if s.IsError() {
+					s.SetTag(ext.ManualKeep, true)
+				}
+			},
+		}
+		tracer, transport, flush, stop := startTestTracer(t, WithSpanProcessor(keep))
+		defer stop()
+
+		tracer.StartSpan("op").Finish(WithError(errors.New("boom")))
+		flush(1)
+
+		spans := transport.Traces()[0]
+		require.Len(t, spans, 1)
+		assert.Equal(t, float64(ext.PriorityUserKeep), spans[0].Metrics[keySamplingPriority])
+	})
+}
@@ -0,0 +1,259 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// RedactionAction specifies how a RedactionRule redacts the tags it matches.
+type RedactionAction string
+
+const (
+	// RedactionMask replaces the redacted value with the rule's replacement, or
+	// with DefaultRedactionReplacement if the rule has none.
+	RedactionMask RedactionAction = "mask"
+
+	// RedactionHash replaces the redacted value with a hash of it, so that equal
+	// values can still be correlated.
+	RedactionHash RedactionAction = "hash"
+
+	// RedactionDrop removes the tag from the span. The resource name can't be
+	// removed and is masked instead.
+	RedactionDrop RedactionAction = "drop"
+)
+
+// DefaultRedactionReplacement is the value replacing masked values when the
+// rule doesn't specify a replacement.
+const DefaultRedactionReplacement = "<redacted>"
+
+// RedactionRule describes which span tags to redact and how. Rules are applied to
+// the string tags of every span, including error messages and header tags, to the
+// string attributes of its span events, as well as to the resource name through the
+// ext.ResourceName key. Spans are redacted when they finish, right after the OnFinish
+// method of span processors, so that client-side stats never see the original values.
+// The tags set by the OnChunk method of span processors are redacted once it returns.
+// Tags starting with "_dd." are reserved and never redacted.
+//
+// Rules can be set using WithRedactionRules, through the DD_TRACE_REDACTION_RULES
+// environment variable as a JSON array, or through remote configuration.
+type RedactionRule struct {
+	// Key is a glob matching the keys of the redacted tags, in which '*' matches any
+	// sequence of characters. An empty key matches every tag.
+	Key string `json:"key,omitempty"`
+
+	// Value is a regular expression matching the redacted parts of the tag values.
+	// When empty, values are redacted as a whole. When set, only the matching parts
+	// are masked or hashed, and the tags having a matching value are dropped.
+	Value string `json:"value,omitempty"`
+
+	// Action specifies how matching tags are redacted. It defaults to RedactionMask.
+	Action RedactionAction `json:"action,omitempty"`
+
+	// Replacement replaces masked values. It defaults to DefaultRedactionReplacement.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// redactionRules is a list of redaction rules, reported to telemetry as JSON.
+type redactionRules []RedactionRule
+
+// String implements fmt.Stringer.
+func (r redactionRules) String() string {
+	b, err := json.Marshal([]RedactionRule(r))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func equalRedactionRules(x, y redactionRules) bool {
+	return equalSlice(x, y)
+}
+
+// compiledRule is the compiled form of a RedactionRule.
+type compiledRule struct {
+	key         *regexp.Regexp // nil matches every key
+	value       *regexp.Regexp // nil matches values as a whole
+	action      RedactionAction
+	replacement string
+}
+
+// redactor applies a set of redaction rules to spans.
+type redactor struct {
+	rules []compiledRule
+}
+
+// newRedactor compiles the given rules. Invalid rules are logged and skipped. It
+// returns nil if there are no valid rules.
+func newRedactor(rules redactionRules) *redactor {
+	var r redactor
+	for _, rule := range rules {
+		cr, err := compileRule(rule)
+		if err != nil {
+			log.Warn("Ignoring redaction rule %+v: %v", rule, err)
+			continue
+		}
+		r.rules = append(r.rules, cr)
+	}
+	if len(r.rules) == 0 {
+		return nil
+	}
+	return &r
+}
+
+func compileRule(rule RedactionRule) (compiledRule, error) {
+	cr := compiledRule{
+		action:      rule.Action,
+		replacement: rule.Replacement,
+	}
+	if rule.Key == "" && rule.Value == "" {
+		return cr, fmt.Errorf("rule has neither a key nor a value")
+	}
+	switch cr.action {
+	case "":
+		cr.action = RedactionMask
+	case RedactionMask, RedactionHash, RedactionDrop:
+	default:
+		return cr, fmt.Errorf("unknown action %q", rule.Action)
+	}
+	if cr.replacement == "" {
+		cr.replacement = DefaultRedactionReplacement
+	}
+	if rule.Key != "" {
+		glob := strings.ReplaceAll(regexp.QuoteMeta(rule.Key), `\*`, ".*")
+		cr.key = regexp.MustCompile("^" + glob + "$")
+	}
+	if rule.Value != "" {
+		re, err := regexp.Compile(rule.Value)
+		if err != nil {
+			return cr, err
+		}
+		cr.value = re
+	}
+	return cr, nil
+}
+
+// redact applies the rules to the tags, span events and resource name of s. The
+// span must be locked.
+func (r *redactor) redact(s *span) {
+	for k := range s.Meta {
+		r.redactTag(s, k)
+	}
+	r.redactTag(s, ext.ResourceName)
+	for i, e := range s.SpanEvents {
+		s.SpanEvents[i].Attributes = r.redactAttributes(e.Attributes)
+	}
+}
+
+// redactTag applies the rules to the string tag k of s, which may be the resource
+// name through the ext.ResourceName key. The span must be locked.
+func (r *redactor) redactTag(s *span, k string) {
+	if k == ext.ResourceName {
+		if v, keep := r.apply(k, s.Resource); keep {
+			s.Resource = v
+		} else {
+			s.Resource = DefaultRedactionReplacement
+		}
+		return
+	}
+	v, ok := s.Meta[k]
+	if !ok || strings.HasPrefix(k, "_dd.") {
+		return
+	}
+	if v, keep := r.apply(k, v); keep {
+		s.Meta[k] = v
+	} else {
+		delete(s.Meta, k)
+	}
+}
+
+// redactAttributes returns a copy of the span event attributes attrs, in which the
+// rules were applied to the string values. The attributes are copied as they may
+// be shared with the caller of AddEvent.
+func (r *redactor) redactAttributes(attrs map[string]interface{}) map[string]interface{} {
+	if len(attrs) == 0 {
+		return attrs
+	}
+	redacted := make(map[string]interface{}, len(attrs))
+	for k, v := range attrs {
+		if strings.HasPrefix(k, "_dd.") {
+			redacted[k] = v
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			if v, keep := r.apply(k, v); keep {
+				redacted[k] = v
+			}
+		case []string:
+			vs := make([]string, 0, len(v))
+			for _, v := range v {
+				if v, keep := r.apply(k, v); keep {
+					vs = append(vs, v)
+				}
+			}
+			redacted[k] = vs
+		default:
+			redacted[k] = v
+		}
+	}
+	return redacted
+}
+
+// apply returns the value v of the tag k once redacted by the rules, and whether
+// the tag should be kept.
+func (r *redactor) apply(k, v string) (string, bool) {
+	for _, rule := range r.rules {
+		if rule.key != nil && !rule.key.MatchString(k) {
+			continue
+		}
+		if rule.value == nil {
+			switch rule.action {
+			case RedactionDrop:
+				return "", false
+			case RedactionHash:
+				v = redactionHash(v)
+			default:
+				v = rule.replacement
+			}
+			continue
+		}
+		switch rule.action {
+		case RedactionDrop:
+			if rule.value.MatchString(v) {
+				return "", false
+			}
+		case RedactionHash:
+			v = rule.value.ReplaceAllStringFunc(v, redactionHash)
+		default:
+			v = rule.value.ReplaceAllLiteralString(v, rule.replacement)
+		}
+	}
+	return v, true
+}
+
+// redactionHash returns a short, stable hash of v.
+func redactionHash(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// parseRedactionRules parses the rules held by the JSON array v.
+func parseRedactionRules(v string) (redactionRules, error) {
+	var rules redactionRules
+	if err := json.Unmarshal([]byte(v), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
@@ -0,0 +1,252 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactorApply(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		rules redactionRules
+		key   string
+		in    string
+		out   string
+		keep  bool
+	}{
+		{
+			name:  "mask-key",
+			rules: redactionRules{{Key: "http.request.headers.*"}},
+			key:   "http.request.headers.authorization",
+			in:    "Bearer abc",
+			out:   DefaultRedactionReplacement,
+			keep:  true,
+		},
+		{
+			name:  "mask-key-replacement",
+			rules: redactionRules{{Key: "user.email", Replacement: "***"}},
+			key:   "user.email",
+			in:    "a@b.c",
+			out:   "***",
+			keep:  true,
+		},
+		{
+			name:  "key-mismatch",
+			rules: redactionRules{{Key: "http.request.headers.*"}},
+			key:   "http.url",
+			in:    "http://example.com",
+			out:   "http://example.com",
+			keep:  true,
+		},
+		{
+			name:  "glob-is-anchored",
+			rules: redactionRules{{Key: "secret"}},
+			key:   "not.a.secret.key",
+			in:    "value",
+			out:   "value",
+			keep:  true,
+		},
+		{
+			name:  "mask-value",
+			rules: redactionRules{{Value: `password=[^&]*`, Replacement: "password=?"}},
+			key:   "http.url",
+			in:    "http://example.com/?user=a&password=b&c=d",
+			out:   "http://example.com/?user=a&password=?&c=d",
+			keep:  true,
+		},
+		{
+			name:  "hash-key",
+			rules: redactionRules{{Key: "user.id", Action: RedactionHash}},
+			key:   "user.id",
+			in:    "1234",
+			out:   redactionHash("1234"),
+			keep:  true,
+		},
+		{
+			name:  "hash-value",
+			rules: redactionRules{{Value: `\d{4}-\d{4}`, Action: RedactionHash}},
+			key:   "error.message",
+			in:    "invalid card 1234-5678",
+			out:   "invalid card " + redactionHash("1234-5678"),
+			keep:  true,
+		},
+		{
+			name:  "drop-key",
+			rules: redactionRules{{Key: "db.*", Action: RedactionDrop}},
+			key:   "db.password",
+			in:    "secret",
+			keep:  false,
+		},
+		{
+			name:  "drop-value",
+			rules: redactionRules{{Value: "secret", Action: RedactionDrop}},
+			key:   "custom",
+			in:    "my secret",
+			keep:  false,
+		},
+		{
+			name:  "drop-value-mismatch",
+			rules: redactionRules{{Value: "secret", Action: RedactionDrop}},
+			key:   "custom",
+			in:    "public",
+			out:   "public",
+			keep:  true,
+		},
+		{
+			name: "rules-in-order",
+			rules: redactionRules{
+				{Value: "token=[a-z]+", Replacement: "token=?"},
+				{Key: "http.url", Value: `\?.*`, Action: RedactionHash},
+			},
+			key:  "http.url",
+			in:   "/path?token=abc",
+			out:  "/path" + redactionHash("?token=?"),
+			keep: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRedactor(tt.rules)
+			require.NotNil(t, r)
+			out, keep := r.apply(tt.key, tt.in)
+			assert.Equal(t, tt.keep, keep)
+			if keep {
+				assert.Equal(t, tt.out, out)
+			}
+		})
+	}
+}
+
+func TestNewRedactorInvalid(t *testing.T) {
+	assert.Nil(t, newRedactor(nil))
+	assert.Nil(t, newRedactor(redactionRules{
+		{},
+		{Key: "a", Action: "unknown"},
+		{Value: "("},
+	}))
+	r := newRedactor(redactionRules{{Value: "("}, {Key: "a"}})
+	require.NotNil(t, r)
+	assert.Len(t, r.rules, 1)
+}
+
+func TestRedactSpan(t *testing.T) {
+	r := newRedactor(redactionRules{
+		{Key: "*", Value: `(?i)password=\S+`, Replacement: "password=?"},
+		{Key: "_dd.*"},
+		{Key: "drop.me", Action: RedactionDrop},
+	})
+	s := &span{
+		Resource: "SELECT * FROM users WHERE password=hunter2",
+		Meta: map[string]string{
+			"error.message": "login failed with password=hunter2",
+			"_dd.p.dm":      "-1",
+			"drop.me":       "value",
+			"other":         "value",
+		},
+	}
+	attrs := map[string]interface{}{"query": "password=hunter2", "drop.me": "value", "args": []string{"password=hunter2"}, "count": 1}
+	s.SpanEvents = []ddtrace.SpanEvent{{Name: "login", Attributes: attrs}}
+	r.redact(s)
+	assert.Equal(t, "SELECT * FROM users WHERE password=?", s.Resource)
+	assert.Equal(t, map[string]interface{}{"query": "password=?", "args": []string{"password=?"}, "count": 1}, s.SpanEvents[0].Attributes)
+	assert.Equal(t, "password=hunter2", attrs["query"], "the attributes of the caller must not be modified")
+	assert.Equal(t, map[string]string{
+		"error.message": "login failed with password=?",
+		"_dd.p.dm":      "-1",
+		"other":         "value",
+	}, s.Meta)
+
+	newRedactor(redactionRules{{Key: ext.ResourceName, Action: RedactionDrop}}).redact(s)
+	assert.Equal(t, DefaultRedactionReplacement, s.Resource)
+}
+
+func TestRedactionRules(t *testing.T) {
+	t.Run("option", func(t *testing.T) {
+		tracer, transport, flush, stop := startTestTracer(t, WithRedactionRules(
+			RedactionRule{Key: "user.*", Action: RedactionHash},
+			RedactionRule{Value: "secret", Replacement: "?"},
+		))
+		defer stop()
+
+		sp := tracer.StartSpan("op", Tag("user.email", "a@b.c"), Tag("custom", "my secret"))
+		sp.Finish(WithError(errors.New("secret leaked")))
+		flush(1)
+
+		spans := transport.Traces()[0]
+		require.Len(t, spans, 1)
+		assert.Equal(t, redactionHash("a@b.c"), spans[0].Meta["user.email"])
+		assert.Equal(t, "my ?", spans[0].Meta["custom"])
+		assert.Equal(t, "? leaked", spans[0].Meta[ext.ErrorMsg])
+	})
+
+	t.Run("finish", func(t *testing.T) {
+		tracer, _, _, stop := startTestTracer(t, WithRedactionRules(RedactionRule{Key: ext.ResourceName, Value: "secret", Replacement: "?"}))
+		defer stop()
+		tracer.stats.Stop()
+		tracer.config.featureFlags = map[string]struct{}{"discovery": {}}
+		tracer.config.agent.Stats = true
+
+		sp := tracer.StartSpan("op", ResourceName("GET /secret"))
+		sp.Finish()
+
+		// spans are redacted before client-side stats are computed
+		assert.Equal(t, "GET /?", sp.(*span).Resource)
+		select {
+		case s := <-tracer.stats.In:
+			assert.Equal(t, "GET /?", s.key.Resource)
+		default:
+			t.Fatal("the span was not aggregated")
+		}
+	})
+
+	t.Run("on-chunk", func(t *testing.T) {
+		p := &testProcessor{
+			onChunk: func(c *Chunk) bool {
+				c.Spans[0].SetTag("user.token", "abc")
+				return true
+			},
+		}
+		tracer, transport, flush, stop := startTestTracer(t, WithSpanProcessor(p), WithRedactionRules(
+			RedactionRule{Key: "user.*", Action: RedactionHash},
+		))
+		defer stop()
+
+		tracer.StartSpan("op", Tag("user.email", "a@b.c")).Finish()
+		flush(1)
+
+		// the tags set by OnChunk are redacted, and the other ones only once
+		spans := transport.Traces()[0]
+		require.Len(t, spans, 1)
+		assert.Equal(t, redactionHash("abc"), spans[0].Meta["user.token"])
+		assert.Equal(t, redactionHash("a@b.c"), spans[0].Meta["user.email"])
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("DD_TRACE_REDACTION_RULES", `[{"key": "http.request.headers.*", "action": "drop"}]`)
+		c := newConfig()
+		assert.Equal(t, redactionRules{{Key: "http.request.headers.*", Action: RedactionDrop}}, c.redactionRules.get())
+		assert.NotNil(t, c.redactor.Load())
+	})
+
+	t.Run("env-invalid", func(t *testing.T) {
+		t.Setenv("DD_TRACE_REDACTION_RULES", `{"key": "a"}`)
+		c := newConfig()
+		assert.Empty(t, c.redactionRules.get())
+		assert.Nil(t, c.redactor.Load())
+	})
+
+	t.Run("string", func(t *testing.T) {
+		rules := redactionRules{{Key: "a", Action: RedactionMask}}
+		assert.Equal(t, `[{"key":"a","action":"mask"}]`, rules.String())
+	})
+}
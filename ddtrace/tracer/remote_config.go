@@ -7,6 +7,7 @@ package tracer
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
@@ -31,6 +32,43 @@ type libConfig struct {
 	SamplingRate *float64    `json:"tracing_sampling_rate,omitempty"`
 	HeaderTags   *headerTags `json:"tracing_header_tags,omitempty"`
 	Tags         *tags       `json:"tracing_tags,omitempty"`
+
+	RedactionRules *redactionRules `json:"tracing_redaction_rules,omitempty"`
+
+	// TraceSamplingRules and SpanSamplingRules hold rules in the same JSON format as
+	// DD_TRACE_SAMPLING_RULES and DD_SPAN_SAMPLING_RULES.
+	TraceSamplingRules json.RawMessage `json:"tracing_sampling_rules,omitempty"`
+	SpanSamplingRules  json.RawMessage `json:"span_sampling_rules,omitempty"`
+
+	TracingEnabled        *bool `json:"tracing_enabled,omitempty"`
+	RuntimeMetricsEnabled *bool `json:"runtime_metrics_enabled,omitempty"`
+	// DataStreamsEnabled only starts or stops the data streams processor. The
+	// integrations decide whether to set checkpoints when they wrap a client,
+	// from DD_DATA_STREAMS_ENABLED or their WithDataStreams option, so enabling
+	// it remotely doesn't add checkpoints to clients wrapped without them.
+	DataStreamsEnabled   *bool `json:"data_streams_enabled,omitempty"`
+	PartialFlushEnabled  *bool `json:"tracing_partial_flush_enabled,omitempty"`
+	LogsInjectionEnabled *bool `json:"log_injection_enabled,omitempty"`
+}
+
+// samplingRules parses the trace and single span sampling rules of the library
+// configuration. A nil list means that the rules are not set remotely.
+func (lc libConfig) samplingRules() (trace, span *samplingRules, err error) {
+	if lc.TraceSamplingRules != nil {
+		rules, err := unmarshalSamplingRules(lc.TraceSamplingRules, SamplingRuleTrace)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid trace sampling rules: %v", err)
+		}
+		trace = (*samplingRules)(&rules)
+	}
+	if lc.SpanSamplingRules != nil {
+		rules, err := unmarshalSamplingRules(lc.SpanSamplingRules, SamplingRuleSpan)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid span sampling rules: %v", err)
+		}
+		span = (*samplingRules)(&rules)
+	}
+	return trace, span, nil
 }
 
 type headerTags []headerTag
@@ -110,6 +148,38 @@ func (t *tracer) onRemoteConfigUpdate(u remoteconfig.ProductUpdate) map[string]s
 		if updated {
 			telemConfigs = append(telemConfigs, t.config.globalTags.toTelemetry())
 		}
+		updated = t.config.redactionRules.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.redactionRules.toTelemetry())
+		}
+		updated = t.config.traceSamplingRules.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.traceSamplingRules.toTelemetry())
+		}
+		updated = t.config.spanSamplingRules.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.spanSamplingRules.toTelemetry())
+		}
+		updated = t.config.tracingEnabled.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.tracingEnabled.toTelemetry())
+		}
+		updated = t.config.runtimeMetricsEnabled.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.runtimeMetricsEnabled.toTelemetry())
+		}
+		updated = t.config.dataStreamsEnabled.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.dataStreamsEnabled.toTelemetry())
+		}
+		updated = t.config.partialFlush.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.partialFlush.toTelemetry())
+		}
+		updated = t.config.logsInjection.reset()
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.logsInjection.toTelemetry())
+		}
 		if len(telemConfigs) > 0 {
 			log.Debug("Reporting %d configuration changes to telemetry", len(telemConfigs))
 			telemetry.GlobalClient.ConfigChange(telemConfigs)
@@ -137,6 +207,14 @@ func (t *tracer) onRemoteConfigUpdate(u remoteconfig.ProductUpdate) map[string]s
 			statuses[path] = state.ApplyStatus{State: state.ApplyStateError, Error: "env mismatch"}
 			continue
 		}
+		// Sampling rules are parsed upfront, so that the configuration is either
+		// fully applied or not at all.
+		traceRules, spanRules, err := c.LibConfig.samplingRules()
+		if err != nil {
+			log.Debug("Error while parsing sampling rules for %s: %v. Configuration won't be applied.", path, err)
+			statuses[path] = state.ApplyStatus{State: state.ApplyStateError, Error: err.Error()}
+			continue
+		}
 		statuses[path] = state.ApplyStatus{State: state.ApplyStateAcknowledged}
 		updated := t.config.traceSampleRate.handleRC(c.LibConfig.SamplingRate)
 		if updated {
@@ -150,6 +228,38 @@ func (t *tracer) onRemoteConfigUpdate(u remoteconfig.ProductUpdate) map[string]s
 		if updated {
 			telemConfigs = append(telemConfigs, t.config.globalTags.toTelemetry())
 		}
+		updated = t.config.redactionRules.handleRC(c.LibConfig.RedactionRules)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.redactionRules.toTelemetry())
+		}
+		updated = t.config.traceSamplingRules.handleRC(traceRules)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.traceSamplingRules.toTelemetry())
+		}
+		updated = t.config.spanSamplingRules.handleRC(spanRules)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.spanSamplingRules.toTelemetry())
+		}
+		updated = t.config.tracingEnabled.handleRC(c.LibConfig.TracingEnabled)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.tracingEnabled.toTelemetry())
+		}
+		updated = t.config.runtimeMetricsEnabled.handleRC(c.LibConfig.RuntimeMetricsEnabled)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.runtimeMetricsEnabled.toTelemetry())
+		}
+		updated = t.config.dataStreamsEnabled.handleRC(c.LibConfig.DataStreamsEnabled)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.dataStreamsEnabled.toTelemetry())
+		}
+		updated = t.config.partialFlush.handleRC(c.LibConfig.PartialFlushEnabled)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.partialFlush.toTelemetry())
+		}
+		updated = t.config.logsInjection.handleRC(c.LibConfig.LogsInjectionEnabled)
+		if updated {
+			telemConfigs = append(telemConfigs, t.config.logsInjection.toTelemetry())
+		}
 	}
 	if len(telemConfigs) > 0 {
 		log.Debug("Reporting %d configuration changes to telemetry", len(telemConfigs))
@@ -171,5 +281,9 @@ func (t *tracer) startRemoteConfig(rcConfig remoteconfig.ClientConfig) error {
 		remoteconfig.APMTracingSampleRate,
 		remoteconfig.APMTracingHTTPHeaderTags,
 		remoteconfig.APMTracingCustomTags,
+		remoteconfig.APMTracingSampleRules,
+		remoteconfig.APMTracingEnabled,
+		remoteconfig.APMTracingDataStreamsEnabled,
+		remoteconfig.APMTracingLogsInjection,
 	)
 }
@@ -20,6 +20,103 @@ import (
 )
 
 func TestOnRemoteConfigUpdate(t *testing.T) {
+	t.Run("RC sampling rules are applied and can be reverted", func(t *testing.T) {
+		telemetryClient := new(telemetrytest.MockClient)
+		defer telemetry.MockGlobalClient(telemetryClient)()
+
+		t.Setenv("DD_TRACE_SAMPLING_RULES", `[{"service": "my-service", "sample_rate": 0.1}]`)
+		tracer, _, _, stop := startTestTracer(t, WithService("my-service"), WithEnv("my-env"))
+		defer stop()
+
+		s := tracer.StartSpan("web.request").(*span)
+		s.Finish()
+		require.Equal(t, 0.1, s.Metrics[keyRulesSamplerAppliedRate])
+		require.False(t, tracer.rulesSampling.HasSpanRules())
+
+		input := remoteconfig.ProductUpdate{
+			"path": []byte(`{"lib_config": {"tracing_sampling_rules": [{"name": "web.*", "sample_rate": 0.5}], "span_sampling_rules": [{"service": "my-service"}]}, "service_target": {"service": "my-service", "env": "my-env"}}`),
+		}
+		applyStatus := tracer.onRemoteConfigUpdate(input)
+		require.Equal(t, state.ApplyStateAcknowledged, applyStatus["path"].State)
+		s = tracer.StartSpan("web.request").(*span)
+		s.Finish()
+		require.Equal(t, 0.5, s.Metrics[keyRulesSamplerAppliedRate])
+		require.True(t, tracer.rulesSampling.HasSpanRules())
+
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 1)
+		telemetryClient.AssertCalled(t, "ConfigChange", []telemetry.Configuration{
+			{Name: "trace_sample_rules", Value: `[{"name":"^web\\..*$","sample_rate":0.5,"type":"trace(0)"}]`, Origin: "remote_config"},
+			{Name: "span_sample_rules", Value: `[{"service":"^my-service$","sample_rate":1,"type":"span(1)"}]`, Origin: "remote_config"},
+		})
+
+		// Applying the same rules again is a no-op.
+		tracer.onRemoteConfigUpdate(input)
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 1)
+
+		// Unset RC. The local rules apply again.
+		input = remoteconfig.ProductUpdate{"path": []byte(`{"lib_config": {}, "service_target": {"service": "my-service", "env": "my-env"}}`)}
+		applyStatus = tracer.onRemoteConfigUpdate(input)
+		require.Equal(t, state.ApplyStateAcknowledged, applyStatus["path"].State)
+		s = tracer.StartSpan("web.request").(*span)
+		s.Finish()
+		require.Equal(t, 0.1, s.Metrics[keyRulesSamplerAppliedRate])
+		require.False(t, tracer.rulesSampling.HasSpanRules())
+
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 2)
+		telemetryClient.AssertCalled(t, "ConfigChange", []telemetry.Configuration{
+			{Name: "trace_sample_rules", Value: `[{"service":"^my-service$","sample_rate":0.1,"type":"trace(0)"}]`, Origin: ""},
+			{Name: "span_sample_rules", Value: "null", Origin: ""},
+		})
+	})
+
+	t.Run("RC invalid sampling rules are not applied", func(t *testing.T) {
+		telemetryClient := new(telemetrytest.MockClient)
+		defer telemetry.MockGlobalClient(telemetryClient)()
+
+		tracer, _, _, stop := startTestTracer(t, WithService("my-service"), WithEnv("my-env"))
+		defer stop()
+
+		input := remoteconfig.ProductUpdate{
+			"path": []byte(`{"lib_config": {"tracing_sampling_rate": 0.5, "tracing_sampling_rules": [{"name": "web.*", "sample_rate": 2}]}, "service_target": {"service": "my-service", "env": "my-env"}}`),
+		}
+		applyStatus := tracer.onRemoteConfigUpdate(input)
+		require.Equal(t, state.ApplyStateError, applyStatus["path"].State)
+		require.Contains(t, applyStatus["path"].Error, "invalid trace sampling rules")
+		s := tracer.StartSpan("web.request").(*span)
+		s.Finish()
+		require.NotContains(t, s.Metrics, keyRulesSamplerAppliedRate)
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 0)
+	})
+
+	t.Run("RC redaction rules are applied and can be reverted", func(t *testing.T) {
+		telemetryClient := new(telemetrytest.MockClient)
+		defer telemetry.MockGlobalClient(telemetryClient)()
+
+		tracer, transport, flush, stop := startTestTracer(t, WithService("my-service"), WithEnv("my-env"))
+		defer stop()
+
+		input := remoteconfig.ProductUpdate{
+			"path": []byte(`{"lib_config": {"tracing_redaction_rules": [{"key": "user.email"}]}, "service_target": {"service": "my-service", "env": "my-env"}}`),
+		}
+		applyStatus := tracer.onRemoteConfigUpdate(input)
+		require.Equal(t, state.ApplyStateAcknowledged, applyStatus["path"].State)
+		tracer.StartSpan("web.request", Tag("user.email", "a@b.c")).Finish()
+		flush(1)
+		require.Equal(t, DefaultRedactionReplacement, transport.Traces()[0][0].Meta["user.email"])
+
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 1)
+		telemetryClient.AssertCalled(t, "ConfigChange", []telemetry.Configuration{{Name: "trace_redaction_rules", Value: `[{"key":"user.email"}]`, Origin: "remote_config"}})
+
+		input = remoteconfig.ProductUpdate{"path": nil}
+		applyStatus = tracer.onRemoteConfigUpdate(input)
+		require.Equal(t, state.ApplyStateAcknowledged, applyStatus["path"].State)
+		tracer.StartSpan("web.request", Tag("user.email", "a@b.c")).Finish()
+		flush(1)
+		require.Equal(t, "a@b.c", transport.Traces()[0][0].Meta["user.email"])
+
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 2)
+	})
+
 	t.Run("RC sampling rate = 0.5 is applied and can be reverted", func(t *testing.T) {
 		telemetryClient := new(telemetrytest.MockClient)
 		defer telemetry.MockGlobalClient(telemetryClient)()
@@ -288,6 +385,57 @@ func TestOnRemoteConfigUpdate(t *testing.T) {
 		telemetryClient.AssertCalled(t, "ConfigChange", []telemetry.Configuration{{Name: "trace_tags", Value: "key0:val0,key1:val1,key2:val2," + runtimeIDTag, Origin: ""}})
 	})
 
+	t.Run("RC toggles are applied and can be reverted", func(t *testing.T) {
+		telemetryClient := new(telemetrytest.MockClient)
+		defer telemetry.MockGlobalClient(telemetryClient)()
+
+		tracer, transport, flush, stop := startTestTracer(t, WithService("my-service"), WithEnv("my-env"))
+		defer stop()
+		require.True(t, globalconfig.LogsInjection())
+
+		input := remoteconfig.ProductUpdate{
+			"path": []byte(`{"lib_config": {"tracing_enabled": false, "runtime_metrics_enabled": true, "data_streams_enabled": true, "tracing_partial_flush_enabled": true, "log_injection_enabled": false}, "service_target": {"service": "my-service", "env": "my-env"}}`),
+		}
+		applyStatus := tracer.onRemoteConfigUpdate(input)
+		require.Equal(t, state.ApplyStateAcknowledged, applyStatus["path"].State)
+		tracer.StartSpan("dropped").Finish()
+		assert.NotNil(t, tracer.runtimeMetricsStop)
+		assert.NotNil(t, tracer.GetDataStreamsProcessor())
+		assert.True(t, tracer.config.partialFlush.get())
+		assert.False(t, globalconfig.LogsInjection())
+
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 1)
+		telemetryClient.AssertCalled(t, "ConfigChange", []telemetry.Configuration{
+			{Name: "trace_enabled", Value: false, Origin: "remote_config"},
+			{Name: "runtime_metrics_enabled", Value: true, Origin: "remote_config"},
+			{Name: "data_streams_enabled", Value: true, Origin: "remote_config"},
+			{Name: "trace_partial_flush_enabled", Value: true, Origin: "remote_config"},
+			{Name: "logs_injection_enabled", Value: false, Origin: "remote_config"},
+		})
+
+		// Remove RC. Assert configuration is reset to the original values.
+		applyStatus = tracer.onRemoteConfigUpdate(remoteconfig.ProductUpdate{"path": nil})
+		require.Equal(t, state.ApplyStateAcknowledged, applyStatus["path"].State)
+		tracer.StartSpan("kept").Finish()
+		flush(1)
+		traces := transport.Traces()
+		require.Len(t, traces, 1)
+		assert.Equal(t, "kept", traces[0][0].Name)
+		assert.Nil(t, tracer.runtimeMetricsStop)
+		assert.Nil(t, tracer.GetDataStreamsProcessor())
+		assert.False(t, tracer.config.partialFlush.get())
+		assert.True(t, globalconfig.LogsInjection())
+
+		telemetryClient.AssertNumberOfCalls(t, "ConfigChange", 2)
+		telemetryClient.AssertCalled(t, "ConfigChange", []telemetry.Configuration{
+			{Name: "trace_enabled", Value: true, Origin: ""},
+			{Name: "runtime_metrics_enabled", Value: false, Origin: ""},
+			{Name: "data_streams_enabled", Value: false, Origin: ""},
+			{Name: "trace_partial_flush_enabled", Value: false, Origin: ""},
+			{Name: "logs_injection_enabled", Value: true, Origin: ""},
+		})
+	})
+
 	t.Run("Deleted config", func(t *testing.T) {
 		defer globalconfig.ClearHeaderTags()
 		telemetryClient := new(telemetrytest.MockClient)
@@ -361,4 +509,12 @@ func TestStartRemoteConfig(t *testing.T) {
 	found, err = remoteconfig.HasCapability(remoteconfig.APMTracingCustomTags)
 	require.NoError(t, err)
 	require.True(t, found)
+
+	found, err = remoteconfig.HasCapability(remoteconfig.APMTracingEnabled)
+	require.NoError(t, err)
+	require.True(t, found)
+
+	found, err = remoteconfig.HasCapability(remoteconfig.APMTracingLogsInjection)
+	require.NoError(t, err)
+	require.True(t, found)
 }
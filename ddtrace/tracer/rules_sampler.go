@@ -243,11 +243,14 @@ func SpanNameServiceMPSRule(name, service string, rate, limit float64) SamplingR
 // limit can be defined using the DD_TRACE_RATE_LIMIT environment variable.
 // Its value is the number of spans to sample per second.
 // Spans that matched the rules but exceeded the rate limit are not sampled.
+// When adaptive rate limiting is enabled, the limit is instead shared across
+// the (service, resource) pairs of the sampled spans, see adaptiveRateLimiter.
 type traceRulesSampler struct {
 	m          sync.RWMutex
-	rules      []SamplingRule // the rules to match spans with
-	globalRate float64        // a rate to apply when no rules match a span
-	limiter    *rateLimiter   // used to limit the volume of spans sampled
+	rules      []SamplingRule       // the rules to match spans with
+	globalRate float64              // a rate to apply when no rules match a span
+	limiter    *rateLimiter         // used to limit the volume of spans sampled
+	adaptive   *adaptiveRateLimiter // replaces limiter when adaptive rate limiting is enabled
 }
 
 // newTraceRulesSampler configures a *traceRulesSampler instance using the given set of rules.
@@ -280,6 +283,15 @@ func globalSampleRate() float64 {
 	return defaultRate
 }
 
+// setRules replaces the trace sampling rules with the given ones.
+// Always returns true.
+func (rs *traceRulesSampler) setRules(rules samplingRules) bool {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+	rs.rules = rules
+	return true
+}
+
 func (rs *traceRulesSampler) enabled() bool {
 	rs.m.RLock()
 	defer rs.m.RUnlock()
@@ -340,8 +352,9 @@ func (rs *traceRulesSampler) sampleRules(span *span) bool {
 	var matched bool
 	rs.m.RLock()
 	rate := rs.globalRate
+	rules := rs.rules
 	rs.m.RUnlock()
-	for _, rule := range rs.rules {
+	for _, rule := range rules {
 		if rule.match(span) {
 			matched = true
 			rate = rule.Rate
@@ -365,7 +378,12 @@ func (rs *traceRulesSampler) applyRate(span *span, rate float64, now time.Time)
 		return
 	}
 
-	sampled, rate := rs.limiter.allowOne(now)
+	var sampled bool
+	if rs.adaptive != nil {
+		sampled, rate = rs.adaptive.allowOne(span.Service, span.Resource, now)
+	} else {
+		sampled, rate = rs.limiter.allowOne(now)
+	}
 	if sampled {
 		span.setSamplingPriority(ext.PriorityUserKeep, samplernames.RuleRate)
 	} else {
@@ -374,9 +392,12 @@ func (rs *traceRulesSampler) applyRate(span *span, rate float64, now time.Time)
 	span.SetTag(keyRulesSamplerLimiterRate, rate)
 }
 
-// limit returns the rate limit set in the rules sampler, controlled by DD_TRACE_RATE_LIMIT, and
-// true if rules sampling is enabled. If not present it returns math.NaN() and false.
+// limit returns the rate limit set in the rules sampler, controlled by DD_TRACE_RATE_LIMIT or by
+// the adaptive rate limit when enabled, and true if rules sampling is enabled. If not present it returns math.NaN() and false.
 func (rs *traceRulesSampler) limit() (float64, bool) {
+	if rs.enabled() && rs.adaptive != nil {
+		return rs.adaptive.budget, true
+	}
 	if rs.enabled() {
 		return float64(rs.limiter.limiter.Limit()), true
 	}
@@ -422,6 +443,7 @@ func newRateLimiter() *rateLimiter {
 // Its value is the max number of spans to sample per second.
 // Spans that matched the rules but exceeded the rate limit are not sampled.
 type singleSpanRulesSampler struct {
+	m     sync.RWMutex
 	rules []SamplingRule // the rules to match spans with
 }
 
@@ -434,14 +456,28 @@ func newSingleSpanRulesSampler(rules []SamplingRule) *singleSpanRulesSampler {
 }
 
 func (rs *singleSpanRulesSampler) enabled() bool {
+	rs.m.RLock()
+	defer rs.m.RUnlock()
 	return len(rs.rules) > 0
 }
 
+// setRules replaces the single span sampling rules with the given ones.
+// Always returns true.
+func (rs *singleSpanRulesSampler) setRules(rules samplingRules) bool {
+	rs.m.Lock()
+	defer rs.m.Unlock()
+	rs.rules = rules
+	return true
+}
+
 // apply uses the sampling rules to determine the sampling rate for the
 // provided span. If the rules don't match, then it returns false and the span is not
 // modified.
 func (rs *singleSpanRulesSampler) apply(span *span) bool {
-	for _, rule := range rs.rules {
+	rs.m.RLock()
+	rules := rs.rules
+	rs.m.RUnlock()
+	for _, rule := range rules {
 		if rule.match(span) {
 			rate := rule.Rate
 			span.setMetric(keyRulesSamplerAppliedRate, rate)
@@ -664,6 +700,34 @@ func unmarshalSamplingRules(b []byte, spanType SamplingRuleType) ([]SamplingRule
 	return rules, nil
 }
 
+// samplingRules is a list of sampling rules, which can be updated through remote
+// configuration and is reported to telemetry as JSON.
+type samplingRules []SamplingRule
+
+// String implements fmt.Stringer.
+func (sr samplingRules) String() string {
+	b, err := json.Marshal([]SamplingRule(sr))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// equalSamplingRules reports whether x and y hold the same rules, in the same order.
+func equalSamplingRules(x, y samplingRules) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		a, errA := x[i].MarshalJSON()
+		b, errB := y[i].MarshalJSON()
+		if errA != nil || errB != nil || string(a) != string(b) {
+			return false
+		}
+	}
+	return true
+}
+
 // MarshalJSON implements the json.Marshaler interface.
 func (sr *SamplingRule) MarshalJSON() ([]byte, error) {
 	s := struct {
@@ -0,0 +1,272 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"math"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// runtimeGauges maps the runtime/metrics keys reported as gauges to their metric
+// names. The names are those used by reportRuntimeMetrics for the matching
+// runtime.MemStats fields, so that both collectors can feed the same dashboards.
+var runtimeGauges = []struct {
+	name  string
+	key   string
+	scale float64
+}{
+	{"runtime.go.num_goroutine", "/sched/goroutines:goroutines", 1},
+	{"runtime.go.gomaxprocs", "/sched/gomaxprocs:threads", 1},
+	{"runtime.go.mem_stats.sys", "/memory/classes/total:bytes", 1},
+	{"runtime.go.mem_stats.total_alloc", "/gc/heap/allocs:bytes", 1},
+	{"runtime.go.mem_stats.mallocs", "/gc/heap/allocs:objects", 1},
+	{"runtime.go.mem_stats.frees", "/gc/heap/frees:objects", 1},
+	{"runtime.go.mem_stats.heap_alloc", "/memory/classes/heap/objects:bytes", 1},
+	{"runtime.go.mem_stats.heap_objects", "/gc/heap/objects:objects", 1},
+	{"runtime.go.mem_stats.heap_released", "/memory/classes/heap/released:bytes", 1},
+	{"runtime.go.mem_stats.stack_inuse", "/memory/classes/heap/stacks:bytes", 1},
+	{"runtime.go.mem_stats.next_gc", "/gc/heap/goal:bytes", 1},
+	{"runtime.go.mem_stats.num_gc", "/gc/cycles/total:gc-cycles", 1},
+	{"runtime.go.mem_stats.num_forced_gc", "/gc/cycles/forced:gc-cycles", 1},
+	{"runtime.go.sync.mutex_wait_total_ns", "/sync/mutex/wait/total:seconds", 1e9},
+}
+
+// runtimeHistograms maps the runtime/metrics histograms reported as distributions
+// to their metric names. The first key supported by the running Go version is
+// used. Values are reported in nanoseconds.
+var runtimeHistograms = []struct {
+	name string
+	keys []string
+}{
+	{"runtime.go.gc_stats.pause_ns", []string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}},
+	{"runtime.go.sched.latency_ns", []string{"/sched/latencies:seconds"}},
+}
+
+// gomemlimitKey is the runtime/metrics key holding the soft memory limit of the
+// runtime, as set by GOMEMLIMIT or debug.SetMemoryLimit.
+const gomemlimitKey = "/gc/gomemlimit:bytes"
+
+// maxHistogramSamples bounds the number of distribution points sent for a single
+// histogram on each report. When more events were recorded since the previous
+// report, the count of every bucket is scaled down, which keeps the shape of
+// the distribution. Each non-empty bucket still sends at least one point.
+//
+// The counts can't be carried by the sample rate instead, since the statsd
+// client randomly drops the points sent with a rate below 1.
+const maxHistogramSamples = 100
+
+// runtimeMetricsCollector collects runtime metrics through runtime/metrics, which
+// unlike runtime.ReadMemStats doesn't stop the world.
+type runtimeMetricsCollector struct {
+	samples    []metrics.Sample
+	gauges     map[int]int      // index in runtimeGauges -> index in samples
+	histograms map[int]int      // index in runtimeHistograms -> index in samples
+	memlimit   int              // index in samples, or -1 if not supported
+	previous   map[int][]uint64 // index in runtimeHistograms -> bucket counts at the previous report
+
+	// gomaxprocs reports whether GOMAXPROCS is available in runtime/metrics,
+	// which is not the case before Go 1.20.
+	gomaxprocs bool
+
+	// cgroupLimit is the memory limit of the cgroup of the process, or 0 when
+	// it is not limited or can't be determined. It is read once.
+	cgroupLimit uint64
+}
+
+func newRuntimeMetricsCollector() *runtimeMetricsCollector {
+	supported := make(map[string]bool)
+	for _, d := range metrics.All() {
+		supported[d.Name] = true
+	}
+	c := &runtimeMetricsCollector{
+		gauges:      make(map[int]int),
+		histograms:  make(map[int]int),
+		memlimit:    -1,
+		previous:    make(map[int][]uint64),
+		cgroupLimit: cgroupMemoryLimit(),
+		gomaxprocs:  supported["/sched/gomaxprocs:threads"],
+	}
+	for i, g := range runtimeGauges {
+		if supported[g.key] {
+			c.gauges[i] = len(c.samples)
+			c.samples = append(c.samples, metrics.Sample{Name: g.key})
+		}
+	}
+	for i, h := range runtimeHistograms {
+		for _, k := range h.keys {
+			if supported[k] {
+				c.histograms[i] = len(c.samples)
+				c.samples = append(c.samples, metrics.Sample{Name: k})
+				break
+			}
+		}
+	}
+	if supported[gomemlimitKey] {
+		c.memlimit = len(c.samples)
+		c.samples = append(c.samples, metrics.Sample{Name: gomemlimitKey})
+	}
+	// The first report only sends the events recorded after this point.
+	metrics.Read(c.samples)
+	for i, j := range c.histograms {
+		if h := c.samples[j].Value; h.Kind() == metrics.KindFloat64Histogram {
+			c.previous[i] = append([]uint64(nil), h.Float64Histogram().Counts...)
+		}
+	}
+	return c
+}
+
+// report reads the runtime metrics and sends them to statsd.
+func (c *runtimeMetricsCollector) report(statsd internal.StatsdClient) {
+	metrics.Read(c.samples)
+	statsd.Gauge("runtime.go.num_cpu", float64(runtime.NumCPU()), nil, 1)
+	statsd.Gauge("runtime.go.num_cgo_call", float64(runtime.NumCgoCall()), nil, 1)
+	if !c.gomaxprocs {
+		statsd.Gauge("runtime.go.gomaxprocs", float64(runtime.GOMAXPROCS(0)), nil, 1)
+	}
+	for i, j := range c.gauges {
+		if v, ok := sampleValue(c.samples[j].Value); ok {
+			statsd.Gauge(runtimeGauges[i].name, v*runtimeGauges[i].scale, nil, 1)
+		}
+	}
+	if limit := c.memoryLimit(); limit > 0 {
+		statsd.Gauge("runtime.go.memory_limit", float64(limit), nil, 1)
+	}
+	for i, j := range c.histograms {
+		v := c.samples[j].Value
+		if v.Kind() != metrics.KindFloat64Histogram {
+			continue
+		}
+		h := v.Float64Histogram()
+		reportHistogram(statsd, runtimeHistograms[i].name, h, c.previous[i])
+		c.previous[i] = append(c.previous[i][:0], h.Counts...)
+	}
+}
+
+// memoryLimit returns the lowest of the runtime soft memory limit and the
+// cgroup memory limit, or 0 if neither is set.
+func (c *runtimeMetricsCollector) memoryLimit() uint64 {
+	limit := c.cgroupLimit
+	if c.memlimit < 0 {
+		return limit
+	}
+	v := c.samples[c.memlimit].Value
+	if v.Kind() != metrics.KindUint64 {
+		return limit
+	}
+	// The soft memory limit defaults to math.MaxInt64, meaning no limit.
+	if gomemlimit := v.Uint64(); gomemlimit < math.MaxInt64 && (limit == 0 || gomemlimit < limit) {
+		limit = gomemlimit
+	}
+	return limit
+}
+
+// sampleValue returns the value of a scalar sample.
+func sampleValue(v metrics.Value) (float64, bool) {
+	switch v.Kind() {
+	case metrics.KindUint64:
+		return float64(v.Uint64()), true
+	case metrics.KindFloat64:
+		return v.Float64(), true
+	default:
+		return 0, false
+	}
+}
+
+// reportHistogram sends the events recorded in h since the previous bucket counts
+// as distribution points, each one having the value of the middle of its bucket.
+func reportHistogram(statsd internal.StatsdClient, name string, h *metrics.Float64Histogram, previous []uint64) {
+	deltas := make([]uint64, len(h.Counts))
+	var total uint64
+	for i, n := range h.Counts {
+		if i < len(previous) && previous[i] <= n {
+			n -= previous[i]
+		}
+		deltas[i] = n
+		total += n
+	}
+	for i, n := range deltas {
+		if n == 0 {
+			continue
+		}
+		if total > maxHistogramSamples {
+			n = uint64(math.Ceil(float64(n) * maxHistogramSamples / float64(total)))
+		}
+		v := bucketValue(h.Buckets[i], h.Buckets[i+1]) * float64(time.Second)
+		for ; n > 0; n-- {
+			statsd.Distribution(name, v, nil, 1)
+		}
+	}
+}
+
+// bucketValue returns the value representing the bucket [lo, hi).
+func bucketValue(lo, hi float64) float64 {
+	switch {
+	case math.IsInf(lo, -1):
+		return hi
+	case math.IsInf(hi, 1):
+		return lo
+	default:
+		return (lo + hi) / 2
+	}
+}
+
+// cgroupMemoryLimit returns the memory limit of the cgroup of the process, looking
+// at cgroup v2 first and v1 next. It returns 0 when there is no limit or when it
+// can't be determined, e.g. on platforms other than Linux.
+func cgroupMemoryLimit() uint64 {
+	for _, path := range []string{
+		"/sys/fs/cgroup/memory.max",
+		"/sys/fs/cgroup/memory/memory.limit_in_bytes",
+	} {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		s := strings.TrimSpace(string(b))
+		if s == "max" {
+			return 0
+		}
+		limit, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			log.Debug("Unable to parse cgroup memory limit %q: %v", s, err)
+			return 0
+		}
+		// cgroup v1 reports a page-aligned math.MaxInt64 when there is no limit.
+		if limit >= math.MaxInt64/2 {
+			return 0
+		}
+		return limit
+	}
+	return 0
+}
+
+// reportRuntimeMetricsV2 periodically reports go runtime metrics collected
+// through runtime/metrics at the given interval, until the tracer or stop is
+// stopped.
+func (t *tracer) reportRuntimeMetricsV2(interval time.Duration, stop <-chan struct{}) {
+	c := newRuntimeMetricsCollector()
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			log.Debug("Reporting runtime metrics...")
+			c.report(t.statsd)
+		case <-t.stop:
+			return
+		case <-stop:
+			return
+		}
+	}
+}
@@ -42,14 +42,15 @@ type (
 	spanList []*span
 
 	// spanLists implements msgp.Decodable on top of a slice of spanList.
-	// This type is only used in tests.
+	// It is used to decode payloads in the OTLP transport and in tests.
 	spanLists []spanList
 )
 
 var (
-	_ ddtrace.Span   = (*span)(nil)
-	_ msgp.Encodable = (*spanList)(nil)
-	_ msgp.Decodable = (*spanLists)(nil)
+	_ ddtrace.Span           = (*span)(nil)
+	_ ddtrace.SpanWithEvents = (*span)(nil)
+	_ msgp.Encodable         = (*spanList)(nil)
+	_ msgp.Decodable         = (*spanLists)(nil)
 )
 
 // errorConfig holds customization options for setting error tags.
@@ -78,6 +79,8 @@ type span struct {
 	Error     int32              `msg:"error"`             // error status of the span; 0 means no errors
 	SpanLinks []ddtrace.SpanLink `msg:"span_links"`        // links to other spans
 
+	SpanEvents []ddtrace.SpanEvent `msg:"span_events,omitempty"` // timestamped events which occurred during the span
+
 	goExecTraced bool         `msg:"-"`
 	noDebugStack bool         `msg:"-"` // disables debug stack traces
 	finished     bool         `msg:"-"` // true if the span has been submitted to a tracer. Can only be read/modified if the trace is locked.
@@ -117,6 +120,12 @@ func (s *span) SetTag(key string, value interface{}) {
 	if s.finished {
 		return
 	}
+	s.setTagLocked(key, value)
+}
+
+// setTagLocked sets the given key/value pair as a tag on the span. The span
+// must be locked, or otherwise not accessed concurrently.
+func (s *span) setTagLocked(key string, value interface{}) {
 	switch key {
 	case ext.Error:
 		s.setTagError(value, errorConfig{
@@ -185,6 +194,33 @@ func (s *span) SetTag(key string, value interface{}) {
 	s.setMeta(key, fmt.Sprint(value))
 }
 
+// AddEvent attaches an event with the given name to the span. By default the
+// event is timestamped with the current time; use EventTime and EventAttributes
+// to customize it.
+func (s *span) AddEvent(name string, opts ...ddtrace.SpanEventOption) {
+	var cfg ddtrace.SpanEventConfig
+	for _, fn := range opts {
+		fn(&cfg)
+	}
+	t := now()
+	if !cfg.Time.IsZero() {
+		t = cfg.Time.UnixNano()
+	}
+	s.Lock()
+	defer s.Unlock()
+	// We don't lock spans when flushing, so we could have a data race when
+	// modifying a span as it's being flushed. This protects us against that
+	// race, since spans are marked `finished` before we flush them.
+	if s.finished {
+		return
+	}
+	s.SpanEvents = append(s.SpanEvents, ddtrace.SpanEvent{
+		Name:         name,
+		TimeUnixNano: uint64(t),
+		Attributes:   cfg.Attributes,
+	})
+}
+
 // setSamplingPriority locks then span, then updates the sampling priority.
 // It also updates the trace's sampling priority.
 func (s *span) setSamplingPriority(priority int, sampler samplernames.SamplerName) {
@@ -530,7 +566,15 @@ func (s *span) finish(finishTime int64) {
 	keep := true
 	if t, ok := internal.GetGlobalTracer().(*tracer); ok {
 		// we have an active tracer
-		if t.config.canComputeStats() && shouldComputeStats(s) {
+		if len(t.config.spanProcessors) > 0 {
+			// processors run first, so that stats and sampling see their changes
+			t.processFinishedSpan(s)
+		}
+		if r := t.config.redactor.Load(); r != nil {
+			// redaction runs before stats, sampling and the debug server see the span
+			r.redact(s)
+		}
+		if t.config.canComputeStats() && shouldComputeStats(s) && !t.tracingDisabled.Load() {
 			// the agent supports computed stats
 			select {
 			case t.stats.In <- newAggregableSpan(s, t.obfuscator):
@@ -15,6 +15,7 @@ import (
 	"testing"
 	"time"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
@@ -90,6 +91,31 @@ func TestSpanFinish(t *testing.T) {
 	assert.True(span.finished)
 }
 
+func TestSpanAddEvent(t *testing.T) {
+	assert := assert.New(t)
+	tracer := newTracer(withTransport(newDefaultTransport()))
+	defer tracer.Stop()
+	span := tracer.newRootSpan("pylons.request", "pylons", "/")
+
+	ts := time.Unix(1700000000, 42)
+	span.AddEvent("cache miss", EventTime(ts), EventAttributes(map[string]interface{}{"key": "a"}), EventAttributes(map[string]interface{}{"size": 3}))
+	span.AddEvent("retry")
+	span.Finish()
+	span.AddEvent("ignored")
+
+	if !assert.Len(span.SpanEvents, 2) {
+		return
+	}
+	assert.Equal(ddtrace.SpanEvent{
+		Name:         "cache miss",
+		TimeUnixNano: uint64(ts.UnixNano()),
+		Attributes:   map[string]interface{}{"key": "a", "size": 3},
+	}, span.SpanEvents[0])
+	assert.Equal("retry", span.SpanEvents[1].Name)
+	assert.GreaterOrEqual(int64(span.SpanEvents[1].TimeUnixNano), span.Start)
+	assert.Nil(span.SpanEvents[1].Attributes)
+}
+
 func TestSpanFinishTwice(t *testing.T) {
 	assert := assert.New(t)
 	wait := time.Millisecond * 2
@@ -85,6 +85,10 @@ func (t *traceID) UpperHex() string {
 type spanContext struct {
 	updated bool // updated is tracking changes for priority / origin / x-datadog-tags
 
+	// baggageOnly reports whether the context was extracted from the W3C
+	// baggage header alone and carries no trace information.
+	baggageOnly bool
+
 	// the below group should propagate only locally
 
 	trace  *trace // reference to the trace that this span belongs too
@@ -345,6 +349,22 @@ func (t *trace) setSamplingPriorityLocked(p int, sampler samplernames.SamplerNam
 	return updatedPriority
 }
 
+// setTailSamplingPriority sets the sampling priority decided by the tail sampler,
+// even though the trace is finished and its priority locked. The decision maker
+// is reset to the given sampler. It returns the resulting decision maker tag, if
+// any.
+func (t *trace) setTailSamplingPriority(p int, sampler samplernames.SamplerName) (dm string, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	locked := t.locked
+	t.locked = false
+	delete(t.propagatingTags, keyDecisionMaker)
+	t.setSamplingPriorityLocked(p, sampler)
+	t.locked = locked
+	dm, ok = t.propagatingTags[keyDecisionMaker]
+	return dm, ok
+}
+
 func (t *trace) isLocked() bool {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -460,7 +480,7 @@ func (t *trace) finishedOne(s *span) {
 		return
 	}
 
-	doPartialFlush := tr.config.partialFlushEnabled && t.finished >= tr.config.partialFlushMinSpans
+	doPartialFlush := tr.config.partialFlush.get() && t.finished >= tr.config.partialFlushMinSpans
 	if !doPartialFlush {
 		return // The trace hasn't completed and partial flushing will not occur
 	}
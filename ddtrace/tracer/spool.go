@@ -0,0 +1,377 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package tracer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	globalinternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+const (
+	// defaultSpoolMaxSize is the default maximum number of bytes the spool
+	// keeps on disk.
+	defaultSpoolMaxSize = 100 * 1024 * 1024 // 100 MB
+
+	// defaultSpoolMaxAge is the default maximum age of a spooled payload.
+	defaultSpoolMaxAge = time.Hour
+
+	// spoolFileExt is the extension of the files holding spooled payloads.
+	spoolFileExt = ".spool"
+
+	// spoolTmpExt is the extension of spool files which are still being written.
+	// They are renamed to spoolFileExt once fully synced to disk.
+	spoolTmpExt = ".tmp"
+
+	// spoolHeaderLen is the length of the header of a spool file, holding the
+	// number of traces in the payload as a big endian uint32.
+	spoolHeaderLen = 4
+)
+
+// spool persists encoded trace payloads on disk when they can not be delivered
+// to the agent, and replays them in the order they were stored once the agent
+// becomes reachable again. The spool is bounded in size and age: the oldest
+// payloads are evicted first.
+//
+// Each payload is stored in its own file, which is first written under a
+// temporary name, synced and then renamed, so that a crash never leaves a
+// partially written payload behind. Payloads left over by a previous process
+// using the same directory are picked up on start.
+//
+// spool is safe for concurrent use.
+type spool struct {
+	dir     string
+	maxSize int64
+	maxAge  time.Duration
+	statsd  globalinternal.StatsdClient
+
+	mu      sync.Mutex   // guards below fields
+	entries []spoolEntry // spooled payloads, oldest first
+	size    int64        // total size of entries in bytes
+	seq     uint64       // sequence number of the last stored payload
+
+	// draining is set to 1 while a goroutine replays the spool.
+	draining int32
+}
+
+// spoolEntry describes a payload stored on disk.
+type spoolEntry struct {
+	name    string    // file name, relative to the spool directory
+	created time.Time // time at which the payload was stored
+	size    int64     // size of the file in bytes
+}
+
+// newSpool returns a spool storing its payloads in dir, creating the directory
+// if needed. Payloads previously stored in dir are loaded.
+func newSpool(dir string, maxSize int64, maxAge time.Duration, statsd globalinternal.StatsdClient) (*spool, error) {
+	if maxSize <= 0 {
+		maxSize = defaultSpoolMaxSize
+	}
+	if maxAge <= 0 {
+		maxAge = defaultSpoolMaxAge
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create spool directory: %v", err)
+	}
+	s := &spool{
+		dir:     dir,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		statsd:  statsd,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the payloads found in the spool directory, discarding any file
+// which was not completely written.
+func (s *spool) load() error {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("unable to read spool directory: %v", err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, spoolTmpExt) {
+			// leftover from a crash while writing
+			os.Remove(filepath.Join(s.dir, name))
+			continue
+		}
+		created, seq, ok := parseSpoolName(name)
+		if !ok {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		s.entries = append(s.entries, spoolEntry{name: name, created: created, size: info.Size()})
+		s.size += info.Size()
+		if seq > s.seq {
+			s.seq = seq
+		}
+	}
+	// file names sort in the order in which the payloads were stored
+	sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].name < s.entries[j].name })
+	if n := len(s.entries); n > 0 {
+		log.Info("Found %d spooled trace payloads (%d bytes) in %s", n, s.size, s.dir)
+	}
+	s.mu.Lock()
+	s.evictLocked(time.Now(), 0)
+	s.reportLocked()
+	s.mu.Unlock()
+	return nil
+}
+
+// spoolName returns the file name of a payload stored at t with sequence number seq.
+func spoolName(t time.Time, seq uint64) string {
+	return fmt.Sprintf("%020d-%020d%s", t.UnixNano(), seq, spoolFileExt)
+}
+
+// parseSpoolName parses a file name created by spoolName.
+func parseSpoolName(name string) (created time.Time, seq uint64, ok bool) {
+	if !strings.HasSuffix(name, spoolFileExt) {
+		return time.Time{}, 0, false
+	}
+	ts, sq, found := strings.Cut(strings.TrimSuffix(name, spoolFileExt), "-")
+	if !found {
+		return time.Time{}, 0, false
+	}
+	nsec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	seq, err = strconv.ParseUint(sq, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return time.Unix(0, nsec), seq, true
+}
+
+// len returns the number of payloads in the spool.
+func (s *spool) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// push stores the contents of p on disk, evicting older payloads if needed to
+// stay within the size limit.
+func (s *spool) push(p *payload) error {
+	count := p.itemCount()
+	b := make([]byte, spoolHeaderLen, spoolHeaderLen+p.buf.Len())
+	binary.BigEndian.PutUint32(b, uint32(count))
+	b = append(b, p.buf.Bytes()...)
+	size := int64(len(b))
+	if size > s.maxSize {
+		s.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:spool_full"}, 1)
+		return errors.New("payload exceeds the spool size limit")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.evictLocked(now, size)
+	s.seq++
+	name := spoolName(now, s.seq)
+	if err := s.writeFile(name, b); err != nil {
+		s.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:spool_error"}, 1)
+		return err
+	}
+	s.entries = append(s.entries, spoolEntry{name: name, created: now, size: size})
+	s.size += size
+	s.statsd.Count("datadog.tracer.spool.stored", int64(count), nil, 1)
+	s.reportLocked()
+	return nil
+}
+
+// writeFile atomically writes b into the file name within the spool directory.
+func (s *spool) writeFile(name string, b []byte) error {
+	path := filepath.Join(s.dir, name)
+	tmp := path + spoolTmpExt
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// Sync the directory so that the rename survives a crash. This is not
+	// supported on every platform, so errors are ignored.
+	if d, err := os.Open(s.dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// evictLocked removes payloads older than the maximum age, as well as the
+// oldest payloads until incoming bytes fit within the size limit.
+// s.mu must be held.
+func (s *spool) evictLocked(now time.Time, incoming int64) {
+	evicted := false
+	defer func() {
+		if evicted {
+			s.reportLocked()
+		}
+	}()
+	for len(s.entries) > 0 {
+		e := s.entries[0]
+		var reason string
+		switch {
+		case now.Sub(e.created) > s.maxAge:
+			reason = "reason:age"
+		case s.size+incoming > s.maxSize:
+			reason = "reason:size"
+		default:
+			return
+		}
+		n := s.countTraces(e.name)
+		s.removeLocked(e.name)
+		evicted = true
+		log.Warn("Evicted spooled trace payload %s (%s), %d traces lost", e.name, reason, n)
+		s.statsd.Count("datadog.tracer.spool.evicted", 1, []string{reason}, 1)
+		s.statsd.Count("datadog.tracer.traces_dropped", int64(n), []string{"reason:spool_evicted"}, 1)
+	}
+}
+
+// countTraces returns the number of traces stored in the file name, by reading
+// its header.
+func (s *spool) countTraces(name string) int {
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	var hdr [spoolHeaderLen]byte
+	if _, err := f.Read(hdr[:]); err != nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(hdr[:]))
+}
+
+// removeLocked deletes the payload stored in the file name. s.mu must be held.
+func (s *spool) removeLocked(name string) {
+	for i, e := range s.entries {
+		if e.name != name {
+			continue
+		}
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		s.size -= e.size
+		break
+	}
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !os.IsNotExist(err) {
+		log.Error("Unable to remove spooled trace payload %s: %v", name, err)
+	}
+}
+
+// reportLocked sends the spool depth metrics. s.mu must be held.
+func (s *spool) reportLocked() {
+	s.statsd.Gauge("datadog.tracer.spool.depth", float64(len(s.entries)), nil, 1)
+	s.statsd.Gauge("datadog.tracer.spool.bytes", float64(s.size), nil, 1)
+}
+
+// peek returns the oldest payload in the spool, or false if it is empty.
+func (s *spool) peek() (spoolEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictLocked(time.Now(), 0)
+	if len(s.entries) == 0 {
+		return spoolEntry{}, false
+	}
+	return s.entries[0], true
+}
+
+// remove deletes the payload stored in the file name.
+func (s *spool) remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.removeLocked(name)
+	s.reportLocked()
+}
+
+// read returns the payload stored in the file name.
+func (s *spool) read(name string) (*payload, error) {
+	b, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < spoolHeaderLen {
+		return nil, errors.New("truncated spool file")
+	}
+	return newPayloadFromBytes(b[spoolHeaderLen:], binary.BigEndian.Uint32(b)), nil
+}
+
+// drain replays the spooled payloads using send, oldest first, until the spool
+// is empty or send fails with an error worth retrying later. The payloads failing
+// with other errors are discarded. Only one goroutine drains the spool at a time;
+// drain returns immediately if another one is already doing it.
+func (s *spool) drain(send func(p *payload) error) {
+	if !atomic.CompareAndSwapInt32(&s.draining, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.draining, 0)
+	for {
+		e, ok := s.peek()
+		if !ok {
+			return
+		}
+		p, err := s.read(e.name)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Error("Unable to read spooled trace payload %s, discarding it: %v", e.name, err)
+			}
+			s.remove(e.name)
+			continue
+		}
+		count := p.itemCount()
+		err = send(p)
+		p.clear()
+		if err != nil && !isRetriableSendError(err) {
+			log.Error("lost %d traces: unable to replay spooled trace payload %s: %v", count, e.name, err)
+			s.remove(e.name)
+			s.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:send_failed"}, 1)
+			continue
+		}
+		if err != nil {
+			log.Debug("Unable to replay spooled trace payload %s: %v", e.name, err)
+			return
+		}
+		s.remove(e.name)
+		s.statsd.Count("datadog.tracer.spool.replayed", int64(count), nil, 1)
+	}
+}
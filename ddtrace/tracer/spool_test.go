@@ -0,0 +1,233 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2023 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toggleTransport is a transport which fails to send while down is true, and
+// records the names of the root spans of the traces it sent. The payloads
+// holding a trace named reject are rejected by the agent.
+type toggleTransport struct {
+	dummyTransport
+	mu     sync.Mutex
+	down   bool
+	reject string
+	sent   []string
+}
+
+func (t *toggleTransport) setDown(down bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.down = down
+}
+
+func (t *toggleTransport) send(p *payload) (io.ReadCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.down {
+		return nil, errors.New("agent unreachable")
+	}
+	traces, err := decode(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, trace := range traces {
+		if trace[0].Name == t.reject {
+			return nil, &statusError{code: http.StatusRequestEntityTooLarge, msg: "payload too large"}
+		}
+	}
+	for _, trace := range traces {
+		t.sent = append(t.sent, trace[0].Name)
+	}
+	return io.NopCloser(strings.NewReader("{}")), nil
+}
+
+func (t *toggleTransport) sentNames() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.sent...)
+}
+
+func spoolPayload(t *testing.T, names ...string) *payload {
+	var traces [][]*span
+	for _, n := range names {
+		s := newBasicSpan(n)
+		traces = append(traces, []*span{s})
+	}
+	p, err := encode(traces)
+	require.NoError(t, err)
+	return p
+}
+
+func TestSpool(t *testing.T) {
+	t.Run("replay-order", func(t *testing.T) {
+		assert := assert.New(t)
+		var statsd testStatsdClient
+		s, err := newSpool(t.TempDir(), 0, 0, &statsd)
+		require.NoError(t, err)
+		require.NoError(t, s.push(spoolPayload(t, "a", "b")))
+		require.NoError(t, s.push(spoolPayload(t, "c")))
+		assert.Equal(2, s.len())
+
+		tr := &toggleTransport{}
+		s.drain(func(p *payload) error {
+			_, err := tr.send(p)
+			return err
+		})
+		assert.Equal([]string{"a", "b", "c"}, tr.sentNames())
+		assert.Equal(0, s.len())
+		files, err := os.ReadDir(s.dir)
+		require.NoError(t, err)
+		assert.Len(files, 0)
+		assert.Equal(int64(3), statsd.Counts()["datadog.tracer.spool.replayed"])
+	})
+
+	t.Run("drain-stops-on-error", func(t *testing.T) {
+		assert := assert.New(t)
+		s, err := newSpool(t.TempDir(), 0, 0, &testStatsdClient{})
+		require.NoError(t, err)
+		require.NoError(t, s.push(spoolPayload(t, "a")))
+		require.NoError(t, s.push(spoolPayload(t, "b")))
+
+		tr := &toggleTransport{down: true}
+		send := func(p *payload) error {
+			_, err := tr.send(p)
+			return err
+		}
+		s.drain(send)
+		assert.Equal(2, s.len())
+		tr.setDown(false)
+		s.drain(send)
+		assert.Equal([]string{"a", "b"}, tr.sentNames())
+	})
+
+	t.Run("drain-discards-rejected", func(t *testing.T) {
+		assert := assert.New(t)
+		var statsd testStatsdClient
+		s, err := newSpool(t.TempDir(), 0, 0, &statsd)
+		require.NoError(t, err)
+		require.NoError(t, s.push(spoolPayload(t, "bad")))
+		require.NoError(t, s.push(spoolPayload(t, "a")))
+
+		// the payload rejected by the agent doesn't block the ones behind it
+		tr := &toggleTransport{reject: "bad"}
+		s.drain(func(p *payload) error {
+			_, err := tr.send(p)
+			return err
+		})
+		assert.Equal([]string{"a"}, tr.sentNames())
+		assert.Equal(0, s.len())
+		assert.Equal(int64(1), statsd.Counts()["datadog.tracer.traces_dropped"])
+	})
+
+	t.Run("evict-size", func(t *testing.T) {
+		assert := assert.New(t)
+		var statsd testStatsdClient
+		p := spoolPayload(t, "a")
+		max := int64(spoolHeaderLen+p.buf.Len()) * 2
+		s, err := newSpool(t.TempDir(), max, 0, &statsd)
+		require.NoError(t, err)
+		require.NoError(t, s.push(p))
+		require.NoError(t, s.push(spoolPayload(t, "b")))
+		require.NoError(t, s.push(spoolPayload(t, "c")))
+		assert.Equal(2, s.len())
+		assert.LessOrEqual(s.size, max)
+		assert.Equal(int64(1), statsd.Counts()["datadog.tracer.spool.evicted"])
+
+		tr := &toggleTransport{}
+		s.drain(func(p *payload) error {
+			_, err := tr.send(p)
+			return err
+		})
+		assert.Equal([]string{"b", "c"}, tr.sentNames())
+	})
+
+	t.Run("evict-age", func(t *testing.T) {
+		assert := assert.New(t)
+		s, err := newSpool(t.TempDir(), 0, time.Millisecond, &testStatsdClient{})
+		require.NoError(t, err)
+		require.NoError(t, s.push(spoolPayload(t, "a")))
+		time.Sleep(5 * time.Millisecond)
+		_, ok := s.peek()
+		assert.False(ok)
+		assert.Equal(0, s.len())
+	})
+
+	t.Run("reload", func(t *testing.T) {
+		assert := assert.New(t)
+		dir := t.TempDir()
+		s, err := newSpool(dir, 0, 0, &testStatsdClient{})
+		require.NoError(t, err)
+		require.NoError(t, s.push(spoolPayload(t, "a")))
+		require.NoError(t, s.push(spoolPayload(t, "b")))
+		// simulate a crash while writing a payload
+		require.NoError(t, os.WriteFile(filepath.Join(dir, spoolName(time.Now(), 99)+spoolTmpExt), []byte("garbage"), 0o600))
+
+		s, err = newSpool(dir, 0, 0, &testStatsdClient{})
+		require.NoError(t, err)
+		assert.Equal(2, s.len())
+		require.NoError(t, s.push(spoolPayload(t, "c")))
+
+		tr := &toggleTransport{}
+		s.drain(func(p *payload) error {
+			_, err := tr.send(p)
+			return err
+		})
+		assert.Equal([]string{"a", "b", "c"}, tr.sentNames())
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(files, 0)
+	})
+}
+
+func TestTraceWriterSpool(t *testing.T) {
+	assert := assert.New(t)
+	tr := &toggleTransport{down: true}
+	c := newConfig(func(c *config) {
+		c.transport = tr
+	}, WithTraceSpool(t.TempDir(), 0, 0))
+	var statsd testStatsdClient
+	h := newAgentTraceWriter(c, newPrioritySampler(), &statsd)
+	require.NotNil(t, h.spool)
+
+	h.add([]*span{newBasicSpan("a")})
+	h.flush()
+	h.wg.Wait()
+	h.add([]*span{newBasicSpan("b")})
+	h.flush()
+	h.wg.Wait()
+	assert.Equal(2, h.spool.len())
+	assert.Empty(tr.sentNames())
+	assert.Zero(statsd.Counts()["datadog.tracer.traces_dropped"])
+
+	tr.setDown(false)
+	h.add([]*span{newBasicSpan("c")})
+	h.flush()
+	h.wg.Wait()
+	assert.Equal([]string{"a", "b", "c"}, tr.sentNames())
+	assert.Equal(0, h.spool.len())
+
+	// payloads rejected by the agent are dropped instead of being spooled
+	tr.reject = "bad"
+	h.add([]*span{newBasicSpan("bad")})
+	h.flush()
+	h.wg.Wait()
+	assert.Equal(0, h.spool.len())
+	assert.Equal(int64(1), statsd.Counts()["datadog.tracer.traces_dropped"])
+}
@@ -0,0 +1,321 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	globalinternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
+)
+
+const (
+	// defaultTailSamplingWindow is the default duration during which the chunks
+	// of a trace are held waiting for its local root span to finish.
+	defaultTailSamplingWindow = 2 * time.Second
+
+	// defaultTailSamplingMaxSpans is the default maximum number of spans held by
+	// the tail sampler.
+	defaultTailSamplingMaxSpans = 10000
+)
+
+// TailSamplingConfig configures the tail sampler enabled using WithTailSampling.
+//
+// Unlike the sampling rules, which decide whether to keep a trace when it starts,
+// the tail sampler decides once the local trace is finished. It keeps every trace
+// holding an error span, a span lasting longer than its latency threshold or a
+// span matching one of the tag rules, and keeps the other traces at the given
+// rate. Its decision overrides the one made by the sampler when the trace started,
+// but not the ones made by users, using ext.ManualKeep or ext.ManualDrop, or by
+// AppSec, which are final. It should be used with care in distributed traces, as the services downstream may have
+// kept traces that the tail sampler drops, or conversely. The dropped traces are
+// only dropped by the tracer when the agent supports it, otherwise they are sent
+// to the agent with a user reject sampling priority.
+type TailSamplingConfig struct {
+	// Rate is the rate, between 0 and 1, at which the traces not kept because of
+	// errors, latency or tags are kept.
+	Rate float64
+
+	// LatencyThreshold keeps the traces holding a span lasting at least as long.
+	// Zero disables it.
+	LatencyThreshold time.Duration
+
+	// ResourceLatencyThresholds overrides LatencyThreshold for the spans having
+	// the given resource names. Zero disables it for that resource.
+	ResourceLatencyThresholds map[string]time.Duration
+
+	// Tags keeps the traces holding a span with a tag matching one of the rules.
+	// Keys are tag names, and values are globs matching the tag values, in which
+	// '*' matches any sequence of characters and '?' any single character.
+	Tags map[string]string
+
+	// Window is how long the chunks of a trace are held, waiting for its local
+	// root span to finish, before the trace is decided upon with the spans
+	// received so far. It only matters when partial flushing is enabled, or when
+	// the root span finishes long after its children. Defaults to 2 seconds.
+	Window time.Duration
+
+	// MaxSpans is the maximum number of spans held. When reached, the oldest
+	// traces are decided upon early. Defaults to 10000.
+	MaxSpans int
+}
+
+// tailReason is the reason why the tail sampler kept a trace.
+type tailReason int
+
+const (
+	tailReasonError tailReason = iota
+	tailReasonLatency
+	tailReasonTag
+	tailReasonRate
+	tailReasonUser // the trace was kept by the user, or by AppSec
+	tailReasonNone // the trace was dropped
+
+	numTailReasons = tailReasonNone
+)
+
+var tailReasonNames = [numTailReasons]string{"reason:error", "reason:latency", "reason:tag", "reason:rate", "reason:user"}
+
+// tailTrace holds the chunks of a trace waiting for a tail sampling decision.
+type tailTrace struct {
+	chunks   []*chunk
+	spans    int
+	deadline time.Time
+	decided  bool
+}
+
+// tailSampler buffers the chunks of local traces until they are complete, and
+// decides whether to keep them. It is only used from the tracer's worker
+// goroutine, apart from its counters.
+type tailSampler struct {
+	cfg  TailSamplingConfig
+	tags map[string]*regexp.Regexp
+	emit func(*chunk) // called with the decided chunks
+
+	// canDropP0s reports whether the agent lets the tracer drop the rejected
+	// traces. When it doesn't, they are sent with a reject sampling priority.
+	canDropP0s func() bool
+
+	traces map[traceID]*tailTrace
+	queue  []*tailTrace // traces in arrival order, possibly already decided
+	spans  int          // number of spans held
+
+	kept             [numTailReasons]uint32
+	dropped, evicted uint32
+}
+
+func newTailSampler(cfg TailSamplingConfig, canDropP0s func() bool, emit func(*chunk)) *tailSampler {
+	if cfg.Window <= 0 {
+		cfg.Window = defaultTailSamplingWindow
+	}
+	if cfg.MaxSpans <= 0 {
+		cfg.MaxSpans = defaultTailSamplingMaxSpans
+	}
+	if cfg.Rate < 0 || cfg.Rate > 1 {
+		log.Warn("Tail sampling rate %f is not between 0 and 1, using 1.", cfg.Rate)
+		cfg.Rate = 1
+	}
+	ts := &tailSampler{
+		cfg:        cfg,
+		tags:       make(map[string]*regexp.Regexp, len(cfg.Tags)),
+		emit:       emit,
+		canDropP0s: canDropP0s,
+		traces:     make(map[traceID]*tailTrace),
+	}
+	for k, v := range cfg.Tags {
+		if v == "" {
+			v = "*"
+		}
+		ts.tags[k] = globMatch(v)
+	}
+	return ts
+}
+
+// add buffers the chunk c until its trace is decided upon.
+func (ts *tailSampler) add(c *chunk, now time.Time) {
+	if len(c.spans) == 0 {
+		return
+	}
+	ts.expire(now)
+	id := c.spans[0].context.traceID
+	tt, ok := ts.traces[id]
+	if !ok {
+		tt = &tailTrace{deadline: now.Add(ts.cfg.Window)}
+		ts.traces[id] = tt
+		ts.queue = append(ts.queue, tt)
+	}
+	tt.chunks = append(tt.chunks, c)
+	tt.spans += len(c.spans)
+	ts.spans += len(c.spans)
+	if hasLocalRoot(c) {
+		ts.decide(id, tt)
+	}
+	for ts.spans > ts.cfg.MaxSpans && len(ts.queue) > 0 {
+		tt := ts.queue[0]
+		ts.queue = ts.queue[1:]
+		if !tt.decided {
+			atomic.AddUint32(&ts.evicted, 1)
+			ts.decide(tt.chunks[0].spans[0].context.traceID, tt)
+		}
+	}
+}
+
+// expire decides upon the traces held for longer than the window.
+func (ts *tailSampler) expire(now time.Time) {
+	for len(ts.queue) > 0 {
+		tt := ts.queue[0]
+		if !tt.decided && now.Before(tt.deadline) {
+			return
+		}
+		ts.queue = ts.queue[1:]
+		if !tt.decided {
+			ts.decide(tt.chunks[0].spans[0].context.traceID, tt)
+		}
+	}
+}
+
+// flush decides upon all the traces held.
+func (ts *tailSampler) flush() {
+	for _, tt := range ts.queue {
+		if !tt.decided {
+			ts.decide(tt.chunks[0].spans[0].context.traceID, tt)
+		}
+	}
+	ts.queue = nil
+}
+
+// decide decides whether to keep the trace tt and emits its chunks.
+func (ts *tailSampler) decide(id traceID, tt *tailTrace) {
+	tt.decided = true
+	delete(ts.traces, id)
+	ts.spans -= tt.spans
+
+	if p, ok := tt.chunks[0].spans[0].context.trace.samplingPriority(); ok && (p >= ext.PriorityUserKeep || p <= ext.PriorityUserReject) {
+		// The decisions made by users, e.g. using ext.ManualKeep or ext.ManualDrop,
+		// or by AppSec are final, so the chunks are emitted as they are.
+		if p > 0 {
+			atomic.AddUint32(&ts.kept[tailReasonUser], 1)
+		}
+		for _, c := range tt.chunks {
+			ts.emit(c)
+		}
+		tt.chunks = nil
+		return
+	}
+	reason := ts.reason(tt)
+	if reason == tailReasonNone {
+		atomic.AddUint32(&ts.dropped, 1)
+	} else {
+		atomic.AddUint32(&ts.kept[reason], 1)
+	}
+	for _, c := range tt.chunks {
+		applyTailDecision(c, reason, ts.canDropP0s())
+		ts.emit(c)
+	}
+	tt.chunks = nil
+}
+
+// reason returns why the trace tt should be kept, or tailReasonNone if it should
+// be dropped.
+func (ts *tailSampler) reason(tt *tailTrace) tailReason {
+	var latency, tag bool
+	for _, c := range tt.chunks {
+		for _, s := range c.spans {
+			if s.Error != 0 {
+				return tailReasonError
+			}
+			latency = latency || ts.slow(s)
+			tag = tag || ts.matchTags(s)
+		}
+	}
+	switch {
+	case latency:
+		return tailReasonLatency
+	case tag:
+		return tailReasonTag
+	case sampledByRate(tt.chunks[0].spans[0].TraceID, ts.cfg.Rate):
+		return tailReasonRate
+	default:
+		return tailReasonNone
+	}
+}
+
+// slow reports whether s lasted at least as long as its latency threshold.
+func (ts *tailSampler) slow(s *span) bool {
+	threshold, ok := ts.cfg.ResourceLatencyThresholds[s.Resource]
+	if !ok {
+		threshold = ts.cfg.LatencyThreshold
+	}
+	return threshold > 0 && time.Duration(s.Duration) >= threshold
+}
+
+// matchTags reports whether s has a tag matching one of the tag rules.
+func (ts *tailSampler) matchTags(s *span) bool {
+	for k, re := range ts.tags {
+		if v, ok := s.Meta[k]; ok && re.MatchString(v) {
+			return true
+		}
+		if v, ok := s.Metrics[k]; ok && re.MatchString(strconv.FormatFloat(v, 'g', -1, 64)) {
+			return true
+		}
+	}
+	return false
+}
+
+// report sends the counters of the tail sampler to statsd, and resets them.
+func (ts *tailSampler) report(statsd globalinternal.StatsdClient) {
+	for r, tag := range tailReasonNames {
+		statsd.Count("datadog.tracer.tail_sampling.kept", int64(atomic.SwapUint32(&ts.kept[r], 0)), []string{tag}, 1)
+	}
+	statsd.Count("datadog.tracer.tail_sampling.dropped", int64(atomic.SwapUint32(&ts.dropped, 0)), nil, 1)
+	statsd.Count("datadog.tracer.tail_sampling.evicted", int64(atomic.SwapUint32(&ts.evicted, 0)), nil, 1)
+}
+
+// hasLocalRoot reports whether c holds the local root span of its trace.
+func hasLocalRoot(c *chunk) bool {
+	root := c.spans[0].context.trace.root
+	for _, s := range c.spans {
+		if s == root {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTailDecision sets the sampling priority of the chunk c according to the
+// tail sampling decision. When dropP0s is true, the rejected chunks are dropped
+// by sampleChunk, otherwise they are sent to the agent with their reject priority.
+func applyTailDecision(c *chunk, reason tailReason, dropP0s bool) {
+	p, sampler := ext.PriorityUserKeep, samplernames.Manual
+	switch reason {
+	case tailReasonRate:
+		sampler = samplernames.RuleRate
+	case tailReasonNone:
+		p = ext.PriorityUserReject
+	}
+	trace := c.spans[0].context.trace
+	dm, ok := trace.setTailSamplingPriority(p, sampler)
+	if ok {
+		c.spans[0].setMeta(keyDecisionMaker, dm)
+	} else {
+		delete(c.spans[0].Meta, keyDecisionMaker)
+	}
+	for _, s := range c.spans {
+		if s == trace.root {
+			s.setMetric(keySamplingPriority, float64(p))
+		}
+	}
+	if dropP0s {
+		c.willSend = p > 0
+	} else {
+		c.willSend = true
+	}
+}
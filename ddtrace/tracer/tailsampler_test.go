@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package tracer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailSampling(t *testing.T) {
+	t.Run("reasons", func(t *testing.T) {
+		tracer, transport, flush, stop := startTestTracer(t, WithTailSampling(TailSamplingConfig{
+			LatencyThreshold:          time.Second,
+			ResourceLatencyThresholds: map[string]time.Duration{"GET /slow": 10 * time.Millisecond},
+			Tags:                      map[string]string{"user.tier": "gold*"},
+		}))
+		defer stop()
+		tracer.config.featureFlags = map[string]struct{}{"discovery": {}}
+		tracer.config.agent.DropP0s = true
+		tracer.config.agent.Stats = true
+
+		start := time.Now()
+		tracer.StartSpan("dropped").Finish()
+		tracer.StartSpan("error").Finish(WithError(errors.New("boom")))
+		tracer.StartSpan("latency", ResourceName("GET /slow"), StartTime(start)).Finish(FinishTime(start.Add(20 * time.Millisecond)))
+		tracer.StartSpan("fast", ResourceName("GET /fast"), StartTime(start)).Finish(FinishTime(start.Add(20 * time.Millisecond)))
+		root := tracer.StartSpan("tag")
+		tracer.StartSpan("child", ChildOf(root.Context()), Tag("user.tier", "golden")).Finish()
+		root.Finish()
+		flush(3)
+
+		traces := transport.Traces()
+		require.Len(t, traces, 3)
+		var names []string
+		for _, tr := range traces {
+			names = append(names, tr[0].Name)
+			assert.Equal(t, float64(ext.PriorityUserKeep), tr[0].Metrics[keySamplingPriority])
+			assert.Equal(t, "-4", tr[0].Meta[keyDecisionMaker])
+		}
+		assert.Equal(t, []string{"error", "latency", "tag"}, names)
+		assert.Equal(t, uint32(1), tracer.tailSampler.kept[tailReasonError])
+		assert.Equal(t, uint32(1), tracer.tailSampler.kept[tailReasonLatency])
+		assert.Equal(t, uint32(1), tracer.tailSampler.kept[tailReasonTag])
+		assert.Equal(t, uint32(2), tracer.tailSampler.dropped)
+	})
+
+	t.Run("no-client-drop", func(t *testing.T) {
+		// the agent doesn't let the tracer drop P0 traces, so the rejected ones
+		// are sent with a reject sampling priority.
+		tracer, transport, flush, stop := startTestTracer(t, WithTailSampling(TailSamplingConfig{}))
+		defer stop()
+		require.False(t, tracer.config.canDropP0s())
+
+		tracer.StartSpan("dropped").Finish()
+		tracer.StartSpan("error").Finish(WithError(errors.New("boom")))
+		flush(2)
+
+		priorities := make(map[string]float64)
+		for _, tr := range transport.Traces() {
+			priorities[tr[0].Name] = tr[0].Metrics[keySamplingPriority]
+		}
+		assert.Equal(t, map[string]float64{
+			"dropped": float64(ext.PriorityUserReject),
+			"error":   float64(ext.PriorityUserKeep),
+		}, priorities)
+		assert.Equal(t, uint32(1), tracer.tailSampler.dropped)
+	})
+
+	t.Run("user-priority", func(t *testing.T) {
+		// the traces kept or dropped by users or by AppSec are not overridden,
+		// even when the tail sampler would decide otherwise.
+		for _, tc := range []struct {
+			name string
+			rate float64
+			keep bool
+			opt  StartSpanOption
+			dm   string
+		}{
+			{name: "manual-keep", rate: 0, keep: true, opt: Tag(ext.ManualKeep, true), dm: "-4"},
+			{name: "appsec-keep", rate: 0, keep: true, opt: Tag(ext.ManualKeep, samplernames.AppSec), dm: "-5"},
+			{name: "manual-drop", rate: 1, opt: Tag(ext.ManualDrop, true)},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				tracer, transport, flush, stop := startTestTracer(t, WithTailSampling(TailSamplingConfig{Rate: tc.rate}))
+				defer stop()
+				tracer.config.featureFlags = map[string]struct{}{"discovery": {}}
+				tracer.config.agent.DropP0s = true
+				tracer.config.agent.Stats = true
+
+				tracer.StartSpan("user", tc.opt).Finish()
+				tracer.StartSpan("error").Finish(WithError(errors.New("boom")))
+				want := 1
+				if tc.keep {
+					want = 2
+				}
+				flush(want)
+
+				spans := make(map[string]*span)
+				for _, tr := range transport.Traces() {
+					spans[tr[0].Name] = tr[0]
+				}
+				require.Contains(t, spans, "error")
+				if !tc.keep {
+					assert.NotContains(t, spans, "user")
+					assert.Zero(t, tracer.tailSampler.kept[tailReasonUser])
+					return
+				}
+				require.Contains(t, spans, "user")
+				assert.Equal(t, float64(ext.PriorityUserKeep), spans["user"].Metrics[keySamplingPriority])
+				assert.Equal(t, tc.dm, spans["user"].Meta[keyDecisionMaker])
+				assert.Equal(t, uint32(1), tracer.tailSampler.kept[tailReasonUser])
+			})
+		}
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		tracer, transport, flush, stop := startTestTracer(t, WithTailSampling(TailSamplingConfig{Rate: 1}))
+		defer stop()
+
+		tracer.StartSpan("op").Finish()
+		flush(1)
+
+		spans := transport.Traces()[0]
+		assert.Equal(t, float64(ext.PriorityUserKeep), spans[0].Metrics[keySamplingPriority])
+		assert.Equal(t, "-3", spans[0].Meta[keyDecisionMaker])
+		assert.Equal(t, uint32(1), tracer.tailSampler.kept[tailReasonRate])
+	})
+
+	t.Run("partial-flush", func(t *testing.T) {
+		tracer, transport, flush, stop := startTestTracer(t,
+			WithPartialFlushing(2),
+			WithTailSampling(TailSamplingConfig{Window: time.Hour}),
+		)
+		defer stop()
+
+		root := tracer.StartSpan("root")
+		tracer.StartSpan("child", ChildOf(root.Context())).Finish()
+		tracer.StartSpan("child", ChildOf(root.Context())).Finish()
+
+		// the error in the root span keeps the chunk flushed earlier, which would
+		// otherwise have been dropped.
+		root.Finish(WithError(errors.New("boom")))
+		flush(2)
+		traces := transport.Traces()
+		require.Len(t, traces, 2)
+		assert.Len(t, traces[0], 2)
+		assert.Len(t, traces[1], 1)
+	})
+}
+
+func TestTailSamplerBuffer(t *testing.T) {
+	tracer, _, _, stop := startTestTracer(t)
+	defer stop()
+
+	var emitted []*chunk
+	canDropP0s := func() bool { return true }
+	ts := newTailSampler(TailSamplingConfig{Window: time.Second, MaxSpans: 2}, canDropP0s, func(c *chunk) {
+		emitted = append(emitted, c)
+	})
+	// returns a chunk holding a child span, so that it's held until its trace expires
+	newChunk := func() *chunk {
+		root := tracer.StartSpan("root")
+		child := tracer.StartSpan("child", ChildOf(root.Context())).(*span)
+		return &chunk{spans: []*span{child}, willSend: true}
+	}
+
+	now := time.Now()
+	first, second := newChunk(), newChunk()
+	ts.add(first, now)
+	ts.add(second, now.Add(time.Millisecond))
+	assert.Empty(t, emitted)
+	assert.Equal(t, 2, ts.spans)
+
+	t.Run("evicted", func(t *testing.T) {
+		ts.add(newChunk(), now.Add(2*time.Millisecond))
+		require.Len(t, emitted, 1)
+		assert.Same(t, first, emitted[0])
+		assert.False(t, emitted[0].willSend)
+		assert.Equal(t, uint32(1), ts.evicted)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		ts.expire(now.Add(time.Second + time.Millisecond))
+		require.Len(t, emitted, 2)
+		assert.Same(t, second, emitted[1])
+		assert.Equal(t, 1, ts.spans)
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		ts.flush()
+		assert.Len(t, emitted, 3)
+		assert.Equal(t, 0, ts.spans)
+		assert.Empty(t, ts.traces)
+		assert.Equal(t, uint32(3), ts.dropped)
+	})
+}
@@ -8,6 +8,7 @@ package tracer
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -237,6 +238,15 @@ func getPropagators(cfg *PropagatorConfig, ps string) ([]Propagator, string) {
 		case "b3 single header":
 			list = append(list, &propagatorB3SingleHeader{})
 			listNames = append(listNames, v)
+		case "baggage":
+			list = append(list, &propagatorBaggage{})
+			listNames = append(listNames, v)
+		case "xray":
+			list = append(list, &propagatorXRay{})
+			listNames = append(listNames, v)
+		case "jaeger":
+			list = append(list, &propagatorJaeger{})
+			listNames = append(listNames, v)
 		case "none":
 			log.Warn("Propagator \"none\" has no effect when combined with other propagators. " +
 				"To disable the propagator, set to `none`")
@@ -268,10 +278,14 @@ func (p *chainedPropagator) Inject(spanCtx ddtrace.SpanContext, carrier interfac
 // trace context that could be extracted will be returned, and other extractors will
 // be ignored. However, the W3C tracestate header value will always be extracted and
 // stored in the local trace context even if a previous propagator has already succeeded
-// so long as the trace-ids match.
+// so long as the trace-ids match. Likewise, W3C baggage is always added to the
+// extracted context when the baggage propagator is enabled.
 func (p *chainedPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
 	var ctx ddtrace.SpanContext
 	for _, v := range p.extractors {
+		if _, isBaggage := v.(*propagatorBaggage); isBaggage {
+			continue // Baggage carries no trace context, it is extracted below.
+		}
 		if ctx != nil {
 			// A local trace context has already been extracted.
 			p, isW3C := v.(*propagatorW3c)
@@ -285,14 +299,33 @@ func (p *chainedPropagator) Extract(carrier interface{}) (ddtrace.SpanContext, e
 		ctx, err = v.Extract(carrier)
 		if ctx != nil {
 			if p.onlyExtractFirst {
-				// Return early if the customer configured that only the first successful
+				// Stop early if the customer configured that only the first successful
 				// extraction should occur.
-				return ctx, nil
+				break
 			}
 		} else if err != ErrSpanContextNotFound {
 			return nil, err
 		}
 	}
+	for _, v := range p.extractors {
+		b, isBaggage := v.(*propagatorBaggage)
+		if !isBaggage {
+			continue
+		}
+		bctx, err := b.Extract(carrier)
+		if err != nil {
+			continue
+		}
+		if ctx == nil {
+			ctx = bctx
+			continue
+		}
+		sctx := ctx.(*spanContext)
+		bctx.ForeachBaggageItem(func(k, v string) bool {
+			sctx.setBaggageItem(k, v)
+			return true
+		})
+	}
 	if ctx == nil {
 		return nil, ErrSpanContextNotFound
 	}
@@ -1083,3 +1116,405 @@ func extractTraceID128(ctx *spanContext, v string) error {
 	}
 	return nil
 }
+
+const (
+	// baggageHeader is the W3C baggage header.
+	// See https://www.w3.org/TR/baggage/
+	baggageHeader = "baggage"
+
+	// baggageMaxItems is the maximum number of baggage items propagated.
+	baggageMaxItems = 64
+
+	// baggageMaxBytes is the maximum size of the baggage header.
+	baggageMaxBytes = 8192
+)
+
+// propagatorBaggage implements Propagator and injects/extracts the baggage
+// items of span contexts using the W3C baggage header. It does not propagate
+// any trace context: when it is the only successful extractor, the extracted
+// context carries baggage alone and spans started from it begin a new trace.
+// Only TextMap carriers are supported.
+type propagatorBaggage struct{}
+
+func (p *propagatorBaggage) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	switch c := carrier.(type) {
+	case TextMapWriter:
+		return p.injectTextMap(spanCtx, c)
+	default:
+		return ErrInvalidCarrier
+	}
+}
+
+// injectTextMap writes the baggage items of spanCtx into the writer, as a
+// comma separated list of percent-encoded key=value pairs. Items are written
+// in key order; those which would exceed baggageMaxItems or baggageMaxBytes
+// are dropped.
+func (*propagatorBaggage) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
+	ctx, ok := spanCtx.(*spanContext)
+	if !ok {
+		return ErrInvalidSpanContext
+	}
+	items := make(map[string]string)
+	ctx.ForeachBaggageItem(func(k, v string) bool {
+		items[k] = v
+		return true
+	})
+	if len(items) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	n := 0
+	for _, k := range sortedKeys(items) {
+		if n == baggageMaxItems {
+			log.Warn("Baggage item limit (%d) reached, dropping the remaining %d items.", baggageMaxItems, len(items)-n)
+			break
+		}
+		member := encodeBaggage(k, isBaggageKeyChar) + "=" + encodeBaggage(items[k], isBaggageValueChar)
+		size := sb.Len() + len(member)
+		if sb.Len() > 0 {
+			size++
+		}
+		if size > baggageMaxBytes {
+			log.Warn("Baggage header size limit (%d bytes) reached, dropping item %q.", baggageMaxBytes, k)
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(member)
+		n++
+	}
+	if sb.Len() > 0 {
+		writer.Set(baggageHeader, sb.String())
+	}
+	return nil
+}
+
+func (p *propagatorBaggage) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	switch c := carrier.(type) {
+	case TextMapReader:
+		return p.extractTextMap(c)
+	default:
+		return nil, ErrInvalidCarrier
+	}
+}
+
+// extractTextMap returns a context holding the baggage items found in the
+// baggage header of reader. If the header is malformed, all of its items are
+// discarded. Properties of list members are ignored.
+func (*propagatorBaggage) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
+	var header string
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) == baggageHeader {
+			if header != "" {
+				header += ","
+			}
+			header += v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if header == "" {
+		return nil, ErrSpanContextNotFound
+	}
+	items, err := parseBaggage(header)
+	if err != nil {
+		log.Debug("Discarding malformed baggage header: %v", err)
+		return nil, ErrSpanContextNotFound
+	}
+	if len(items) == 0 {
+		return nil, ErrSpanContextNotFound
+	}
+	ctx := &spanContext{baggageOnly: true}
+	for _, kv := range items {
+		ctx.setBaggageItem(kv[0], kv[1])
+	}
+	return ctx, nil
+}
+
+// parseBaggage parses the value of a W3C baggage header into key/value pairs,
+// keeping at most baggageMaxItems of them and ignoring anything past
+// baggageMaxBytes.
+func parseBaggage(header string) ([][2]string, error) {
+	if len(header) > baggageMaxBytes {
+		// only keep the complete members within the limit
+		header = header[:baggageMaxBytes]
+		if i := strings.LastIndexByte(header, ','); i >= 0 {
+			header = header[:i]
+		} else {
+			return nil, nil
+		}
+	}
+	var items [][2]string
+	for _, member := range strings.Split(header, ",") {
+		if len(items) == baggageMaxItems {
+			break
+		}
+		member = strings.Trim(member, " \t")
+		if member == "" {
+			continue
+		}
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i] // drop the properties
+		}
+		k, v, ok := strings.Cut(member, "=")
+		if !ok {
+			return nil, fmt.Errorf("missing '=' in %q", member)
+		}
+		k, err := url.PathUnescape(strings.Trim(k, " \t"))
+		if err != nil || k == "" {
+			return nil, fmt.Errorf("invalid key in %q", member)
+		}
+		v, err = url.PathUnescape(strings.Trim(v, " \t"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %q", member)
+		}
+		items = append(items, [2]string{k, v})
+	}
+	return items, nil
+}
+
+// isBaggageKeyChar reports whether c may appear unencoded in a baggage key,
+// which is an RFC 7230 token.
+func isBaggageKeyChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&'*+-.^_`|~", c) >= 0
+}
+
+// isBaggageValueChar reports whether c may appear unencoded in a baggage
+// value: any printable ASCII character except space, '"', ',', ';', '\' and
+// '%', which is reserved for encoding.
+func isBaggageValueChar(c byte) bool {
+	return c > 0x20 && c < 0x7f && strings.IndexByte("\",;\\%", c) < 0
+}
+
+// encodeBaggage percent-encodes the bytes of s for which allowed returns false.
+func encodeBaggage(s string, allowed func(byte) bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if allowed(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+// xrayHeader is the AWS X-Ray tracing header.
+// See https://docs.aws.amazon.com/xray/latest/devguide/xray-concepts.html#xray-concepts-tracingheader
+const xrayHeader = "x-amzn-trace-id"
+
+// propagatorXRay implements Propagator and injects/extracts span contexts
+// using the AWS X-Ray tracing header. Only TextMap carriers are supported.
+//
+// An X-Ray trace ID is made of a version, the 32-bit epoch of the trace start
+// in hex and 96 random bits in hex, e.g. 1-5759e988-bd862e3fe1be46a994272793.
+// The epoch and the first 32 random bits form the upper 64 bits of the trace
+// ID, which matches the layout of the 128-bit trace IDs generated by the tracer.
+type propagatorXRay struct{}
+
+func (p *propagatorXRay) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	switch c := carrier.(type) {
+	case TextMapWriter:
+		return p.injectTextMap(spanCtx, c)
+	default:
+		return ErrInvalidCarrier
+	}
+}
+
+func (*propagatorXRay) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
+	ctx, ok := spanCtx.(*spanContext)
+	if !ok || ctx.traceID.Empty() || ctx.spanID == 0 {
+		return ErrInvalidSpanContext
+	}
+	upper := ctx.traceID.Upper()
+	v := fmt.Sprintf("Root=1-%08x-%08x%016x;Parent=%016x", upper>>32, upper&0xffffffff, ctx.traceID.Lower(), ctx.spanID)
+	if p, ok := ctx.SamplingPriority(); ok {
+		if p >= ext.PriorityAutoKeep {
+			v += ";Sampled=1"
+		} else {
+			v += ";Sampled=0"
+		}
+	}
+	writer.Set(xrayHeader, v)
+	return nil
+}
+
+func (p *propagatorXRay) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	switch c := carrier.(type) {
+	case TextMapReader:
+		return p.extractTextMap(c)
+	default:
+		return nil, ErrInvalidCarrier
+	}
+}
+
+func (*propagatorXRay) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
+	var ctx spanContext
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) != xrayHeader {
+			return nil
+		}
+		for _, field := range strings.Split(v, ";") {
+			key, val, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch key {
+			case "Root":
+				if err := parseXRayTraceID(&ctx, val); err != nil {
+					return err
+				}
+			case "Parent":
+				if len(val) != 16 {
+					return ErrSpanContextCorrupted
+				}
+				id, err := strconv.ParseUint(val, 16, 64)
+				if err != nil {
+					return ErrSpanContextCorrupted
+				}
+				ctx.spanID = id
+			case "Sampled":
+				switch val {
+				case "1":
+					ctx.setSamplingPriority(ext.PriorityAutoKeep, samplernames.Unknown)
+				case "0":
+					ctx.setSamplingPriority(ext.PriorityAutoReject, samplernames.Unknown)
+				}
+				// "?" defers the decision to the receiver
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.traceID.Empty() || ctx.spanID == 0 {
+		return nil, ErrSpanContextNotFound
+	}
+	return &ctx, nil
+}
+
+// parseXRayTraceID sets the trace ID of ctx from the X-Ray trace ID v.
+func parseXRayTraceID(ctx *spanContext, v string) error {
+	parts := strings.Split(v, "-")
+	if len(parts) != 3 || parts[0] != "1" || len(parts[1]) != 8 || len(parts[2]) != 24 {
+		return ErrSpanContextCorrupted
+	}
+	upper, err := strconv.ParseUint(parts[1]+parts[2][:8], 16, 64)
+	if err != nil {
+		return ErrSpanContextCorrupted
+	}
+	lower, err := strconv.ParseUint(parts[2][8:], 16, 64)
+	if err != nil {
+		return ErrSpanContextCorrupted
+	}
+	ctx.traceID.SetUpper(upper)
+	ctx.traceID.SetLower(lower)
+	return nil
+}
+
+// jaegerHeader is the Jaeger tracing header.
+// See https://www.jaegertracing.io/docs/1.50/client-libraries/#propagation-format
+const jaegerHeader = "uber-trace-id"
+
+const (
+	jaegerFlagSampled = 0x1 // the trace is sampled
+	jaegerFlagDebug   = 0x2 // the trace is forcibly sampled
+)
+
+// propagatorJaeger implements Propagator and injects/extracts span contexts
+// using the Jaeger uber-trace-id header, whose value has the format
+// {trace-id}:{span-id}:{parent-span-id}:{flags}. Only TextMap carriers are supported.
+type propagatorJaeger struct{}
+
+func (p *propagatorJaeger) Inject(spanCtx ddtrace.SpanContext, carrier interface{}) error {
+	switch c := carrier.(type) {
+	case TextMapWriter:
+		return p.injectTextMap(spanCtx, c)
+	default:
+		return ErrInvalidCarrier
+	}
+}
+
+func (*propagatorJaeger) injectTextMap(spanCtx ddtrace.SpanContext, writer TextMapWriter) error {
+	ctx, ok := spanCtx.(*spanContext)
+	if !ok || ctx.traceID.Empty() || ctx.spanID == 0 {
+		return ErrInvalidSpanContext
+	}
+	var traceID string
+	if ctx.traceID.HasUpper() {
+		traceID = fmt.Sprintf("%016x%016x", ctx.traceID.Upper(), ctx.traceID.Lower())
+	} else {
+		traceID = fmt.Sprintf("%016x", ctx.traceID.Lower())
+	}
+	var flags int
+	if p, ok := ctx.SamplingPriority(); ok && p >= ext.PriorityAutoKeep {
+		flags = jaegerFlagSampled
+	}
+	// the parent span ID field is deprecated and always set to 0
+	writer.Set(jaegerHeader, fmt.Sprintf("%s:%016x:0:%x", traceID, ctx.spanID, flags))
+	return nil
+}
+
+func (p *propagatorJaeger) Extract(carrier interface{}) (ddtrace.SpanContext, error) {
+	switch c := carrier.(type) {
+	case TextMapReader:
+		return p.extractTextMap(c)
+	default:
+		return nil, ErrInvalidCarrier
+	}
+}
+
+func (*propagatorJaeger) extractTextMap(reader TextMapReader) (ddtrace.SpanContext, error) {
+	var ctx spanContext
+	err := reader.ForeachKey(func(k, v string) error {
+		if strings.ToLower(k) != jaegerHeader {
+			return nil
+		}
+		if strings.Contains(v, "%") {
+			// the value may be URL encoded, e.g. when sent as a query parameter
+			unescaped, err := url.QueryUnescape(v)
+			if err != nil {
+				return ErrSpanContextCorrupted
+			}
+			v = unescaped
+		}
+		parts := strings.Split(v, ":")
+		if len(parts) != 4 || len(parts[0]) == 0 || len(parts[0]) > 32 {
+			return ErrSpanContextCorrupted
+		}
+		if err := extractTraceID128(&ctx, parts[0]); err != nil {
+			return err
+		}
+		id, err := strconv.ParseUint(parts[1], 16, 64)
+		if err != nil {
+			return ErrSpanContextCorrupted
+		}
+		ctx.spanID = id
+		flags, err := strconv.ParseUint(parts[3], 16, 8)
+		if err != nil {
+			return ErrSpanContextCorrupted
+		}
+		switch {
+		case flags&jaegerFlagDebug != 0:
+			ctx.setSamplingPriority(ext.PriorityUserKeep, samplernames.Unknown)
+		case flags&jaegerFlagSampled != 0:
+			ctx.setSamplingPriority(ext.PriorityAutoKeep, samplernames.Unknown)
+		default:
+			ctx.setSamplingPriority(ext.PriorityAutoReject, samplernames.Unknown)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ctx.traceID.Empty() || ctx.spanID == 0 {
+		return nil, ErrSpanContextNotFound
+	}
+	return &ctx, nil
+}
@@ -1855,6 +1855,224 @@ func TestW3CExtractsBaggage(t *testing.T) {
 	assert.True(t, found)
 }
 
+func TestPropagatorBaggage(t *testing.T) {
+	t.Run("inject", func(t *testing.T) {
+		t.Setenv(headerPropagationStyleInject, "datadog,baggage")
+		tracer := newTracer()
+		defer tracer.Stop()
+		root := tracer.StartSpan("web.request")
+		root.SetBaggageItem("user.id", "x=1, y;2")
+		root.SetBaggageItem("key with space", "café")
+		headers := TextMapCarrier{}
+		require.NoError(t, tracer.Inject(root.Context(), headers))
+		assert.Equal(t, "key%20with%20space=caf%C3%A9,user.id=x=1%2C%20y%3B2", headers[baggageHeader])
+		// the datadog propagator still propagates baggage as well
+		assert.Equal(t, "x=1, y;2", headers[DefaultBaggageHeaderPrefix+"user.id"])
+	})
+
+	t.Run("inject/limits", func(t *testing.T) {
+		ctx := &spanContext{}
+		for i := 0; i < baggageMaxItems+10; i++ {
+			ctx.setBaggageItem(fmt.Sprintf("key%03d", i), "v")
+		}
+		headers := TextMapCarrier{}
+		require.NoError(t, (&propagatorBaggage{}).Inject(ctx, headers))
+		assert.Len(t, strings.Split(headers[baggageHeader], ","), baggageMaxItems)
+
+		ctx = &spanContext{}
+		ctx.setBaggageItem("a", strings.Repeat("x", baggageMaxBytes-10))
+		ctx.setBaggageItem("b", strings.Repeat("y", 20))
+		ctx.setBaggageItem("c", "z")
+		headers = TextMapCarrier{}
+		require.NoError(t, (&propagatorBaggage{}).Inject(ctx, headers))
+		h := headers[baggageHeader]
+		assert.LessOrEqual(t, len(h), baggageMaxBytes)
+		assert.True(t, strings.HasSuffix(h, ",c=z"))
+		assert.NotContains(t, h, "b=")
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		t.Setenv(headerPropagationStyleExtract, "tracecontext,baggage")
+		tracer := newTracer()
+		defer tracer.Stop()
+		headers := TextMapCarrier{
+			traceparentHeader: "00-12345678901234567890123456789012-1234567890123456-01",
+			baggageHeader:     "user.id = x=1%2C%20y%3B2 ;prop=1, key%20with%20space=caf%C3%A9",
+		}
+		sctx, err := tracer.Extract(headers)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(0x7890123456789012), sctx.TraceID())
+		assert.Equal(t, "x=1, y;2", sctx.(*spanContext).baggageItem("user.id"))
+		assert.Equal(t, "café", sctx.(*spanContext).baggageItem("key with space"))
+	})
+
+	t.Run("extract/baggage-only", func(t *testing.T) {
+		t.Setenv(headerPropagationStyleExtract, "datadog,baggage")
+		tracer := newTracer()
+		internal.SetGlobalTracer(tracer)
+		defer tracer.Stop()
+		defer internal.SetGlobalTracer(&internal.NoopTracer{})
+		sctx, err := tracer.Extract(TextMapCarrier{baggageHeader: "k=v"})
+		require.NoError(t, err)
+		assert.Zero(t, sctx.TraceID())
+
+		// a span started from baggage alone begins a new trace
+		child := tracer.StartSpan("web.request", ChildOf(sctx)).(*span)
+		defer child.Finish()
+		assert.NotZero(t, child.TraceID)
+		assert.Zero(t, child.ParentID)
+		assert.Equal(t, "v", child.BaggageItem("k"))
+	})
+
+	t.Run("extract/malformed", func(t *testing.T) {
+		for _, h := range []string{"novalue", "=v", "k=%zz", "a=1,b"} {
+			_, err := (&propagatorBaggage{}).Extract(TextMapCarrier{baggageHeader: h})
+			assert.Equal(t, ErrSpanContextNotFound, err, h)
+		}
+	})
+
+	t.Run("extract/limits", func(t *testing.T) {
+		var members []string
+		for i := 0; i < baggageMaxItems+10; i++ {
+			members = append(members, fmt.Sprintf("key%d=v", i))
+		}
+		sctx, err := (&propagatorBaggage{}).Extract(TextMapCarrier{baggageHeader: strings.Join(members, ",")})
+		require.NoError(t, err)
+		n := 0
+		sctx.ForeachBaggageItem(func(_, _ string) bool {
+			n++
+			return true
+		})
+		assert.Equal(t, baggageMaxItems, n)
+	})
+}
+
+func TestPropagatorXRay(t *testing.T) {
+	t.Setenv(headerPropagationStyle, "xray")
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	t.Run("extract", func(t *testing.T) {
+		for _, tc := range []struct {
+			header   string
+			upper    uint64
+			lower    uint64
+			spanID   uint64
+			priority int // -1 when the sampling decision is deferred
+		}{
+			{"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1", 0x5759e988bd862e3f, 0xe1be46a994272793, 0x53995c3f42cd8ad8, 1},
+			{"Root=1-00000000-000000000000000000000001; Parent=0000000000000002; Sampled=0", 0, 1, 2, 0},
+			{"Self=1-abc;Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=?", 0x5759e988bd862e3f, 0xe1be46a994272793, 0x53995c3f42cd8ad8, -1},
+		} {
+			ctx, err := tracer.Extract(HTTPHeadersCarrier{"X-Amzn-Trace-Id": []string{tc.header}})
+			require.NoError(t, err, tc.header)
+			sctx := ctx.(*spanContext)
+			assert.Equal(t, tc.upper, sctx.traceID.Upper(), tc.header)
+			assert.Equal(t, tc.lower, sctx.traceID.Lower(), tc.header)
+			assert.Equal(t, tc.spanID, sctx.spanID, tc.header)
+			p, ok := sctx.SamplingPriority()
+			if tc.priority < 0 {
+				assert.False(t, ok, tc.header)
+			} else {
+				assert.Equal(t, tc.priority, p, tc.header)
+			}
+		}
+	})
+
+	t.Run("extract/malformed", func(t *testing.T) {
+		for _, h := range []string{
+			"Root=2-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8",
+			"Root=1-5759e988-bd862e3f;Parent=53995c3f42cd8ad8",
+			"Root=1-5759e988-bd862e3fe1be46a99427279z;Parent=53995c3f42cd8ad8",
+			"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=1",
+		} {
+			_, err := tracer.Extract(TextMapCarrier{xrayHeader: h})
+			assert.Equal(t, ErrSpanContextCorrupted, err, h)
+		}
+		_, err := tracer.Extract(TextMapCarrier{xrayHeader: "Root=1-5759e988-bd862e3fe1be46a994272793"})
+		assert.Equal(t, ErrSpanContextNotFound, err)
+	})
+
+	t.Run("inject", func(t *testing.T) {
+		root := tracer.StartSpan("web.request").(*span)
+		ctx := root.Context().(*spanContext)
+		ctx.traceID.SetUpper(0x5759e988bd862e3f)
+		ctx.traceID.SetLower(0xe1be46a994272793)
+		ctx.spanID = 0x53995c3f42cd8ad8
+		ctx.setSamplingPriority(ext.PriorityUserKeep, samplernames.Manual)
+		headers := TextMapCarrier{}
+		require.NoError(t, tracer.Inject(ctx, headers))
+		assert.Equal(t, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1", headers[xrayHeader])
+
+		// round trip
+		sctx, err := tracer.Extract(headers)
+		require.NoError(t, err)
+		assert.Equal(t, ctx.traceID, sctx.(*spanContext).traceID)
+		assert.Equal(t, ctx.spanID, sctx.(*spanContext).spanID)
+	})
+}
+
+func TestPropagatorJaeger(t *testing.T) {
+	t.Setenv(headerPropagationStyle, "jaeger")
+	tracer := newTracer()
+	defer tracer.Stop()
+
+	t.Run("extract", func(t *testing.T) {
+		for _, tc := range []struct {
+			header   string
+			upper    uint64
+			lower    uint64
+			spanID   uint64
+			priority int
+		}{
+			{"4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", 0x4bf92f3577b34da6, 0xa3ce929d0e0e4736, 0xf067aa0ba902b7, 1},
+			{"a3ce929d0e0e4736:f067aa0ba902b7:0:0", 0, 0xa3ce929d0e0e4736, 0xf067aa0ba902b7, 0},
+			{"1f:2:0:3", 0, 0x1f, 2, 2},
+			{"4bf92f3577b34da6a3ce929d0e0e4736%3A00f067aa0ba902b7%3A0%3A1", 0x4bf92f3577b34da6, 0xa3ce929d0e0e4736, 0xf067aa0ba902b7, 1},
+		} {
+			ctx, err := tracer.Extract(HTTPHeadersCarrier{"Uber-Trace-Id": []string{tc.header}})
+			require.NoError(t, err, tc.header)
+			sctx := ctx.(*spanContext)
+			assert.Equal(t, tc.upper, sctx.traceID.Upper(), tc.header)
+			assert.Equal(t, tc.lower, sctx.traceID.Lower(), tc.header)
+			assert.Equal(t, tc.spanID, sctx.spanID, tc.header)
+			p, ok := sctx.SamplingPriority()
+			assert.True(t, ok, tc.header)
+			assert.Equal(t, tc.priority, p, tc.header)
+		}
+	})
+
+	t.Run("extract/malformed", func(t *testing.T) {
+		for _, h := range []string{
+			"4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0",
+			"4bf92f3577b34da6a3ce929d0e0e4736:zz:0:1",
+			"4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:xyz",
+			"004bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1",
+		} {
+			_, err := tracer.Extract(TextMapCarrier{jaegerHeader: h})
+			assert.Equal(t, ErrSpanContextCorrupted, err, h)
+		}
+	})
+
+	t.Run("inject", func(t *testing.T) {
+		root := tracer.StartSpan("web.request").(*span)
+		ctx := root.Context().(*spanContext)
+		ctx.traceID.SetUpper(0x4bf92f3577b34da6)
+		ctx.traceID.SetLower(0xa3ce929d0e0e4736)
+		ctx.spanID = 0xf067aa0ba902b7
+		ctx.setSamplingPriority(ext.PriorityAutoKeep, samplernames.AgentRate)
+		headers := TextMapCarrier{}
+		require.NoError(t, tracer.Inject(ctx, headers))
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736:00f067aa0ba902b7:0:1", headers[jaegerHeader])
+
+		ctx.traceID = traceIDFrom64Bits(0xa3ce929d0e0e4736)
+		ctx.setSamplingPriority(ext.PriorityUserReject, samplernames.Manual)
+		headers = TextMapCarrier{}
+		require.NoError(t, tracer.Inject(ctx, headers))
+		assert.Equal(t, "a3ce929d0e0e4736:00f067aa0ba902b7:0:0", headers[jaegerHeader])
+	})
+}
+
 func TestNonePropagator(t *testing.T) {
 	t.Run("inject/none", func(t *testing.T) {
 		t.Setenv(headerPropagationStyleInject, "none")
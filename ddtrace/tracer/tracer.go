@@ -86,6 +86,9 @@ type tracer struct {
 	// partialTrace the number of partially dropped traces.
 	partialTraces uint32
 
+	// droppedProcessorChunks records the number of chunks dropped by span processors.
+	droppedProcessorChunks uint32
+
 	// rulesSampling holds an instance of the rules sampler used to apply either trace sampling,
 	// or single span sampling rules on spans. These are user-defined
 	// rules for applying a sampling rate to spans that match the designated service
@@ -99,12 +102,33 @@ type tracer struct {
 	// statsd is used for tracking metrics associated with the runtime and the tracer.
 	statsd globalinternal.StatsdClient
 
-	// dataStreams processes data streams monitoring information
-	dataStreams *datastreams.Processor
+	// dataStreams processes data streams monitoring information. It is nil when
+	// data streams monitoring is disabled.
+	dataStreams atomic.Pointer[datastreams.Processor]
+
+	// togglesMu guards the runtime toggling of the tracer's goroutines, i.e. the
+	// runtime metrics reporter and the data streams processor.
+	togglesMu sync.Mutex
+
+	// runtimeMetricsStop stops the runtime metrics reporter. It is nil when
+	// runtime metrics aren't reported.
+	runtimeMetricsStop chan struct{}
+
+	// tracingDisabled reports whether tracing was disabled at runtime, in which
+	// case finished traces are dropped.
+	tracingDisabled atomic.Bool
 
 	// abandonedSpansDebugger specifies where and how potentially abandoned spans are stored
 	// when abandoned spans debugging is enabled.
 	abandonedSpansDebugger *abandonedSpansDebugger
+
+	// debugServer keeps and serves recently finished traces when the debug server
+	// is enabled. It is nil otherwise.
+	debugServer *debugServer
+
+	// tailSampler holds finished traces until they are decided upon when tail
+	// sampling is enabled. It is nil otherwise, and only used by the worker.
+	tailSampler *tailSampler
 }
 
 const (
@@ -149,8 +173,8 @@ func Start(opts ...StartOption) {
 	if t.config.logStartup {
 		logStartup(t)
 	}
-	if t.dataStreams != nil {
-		t.dataStreams.Start()
+	if p := t.dataStreams.Load(); p != nil {
+		p.Start()
 	}
 	// Start AppSec with remote configuration
 	cfg := remoteconfig.DefaultClientConfig()
@@ -223,6 +247,20 @@ func SetUser(s Span, id string, opts ...UserMonitoringOption) {
 	sp.SetUser(id, opts...)
 }
 
+// AddSpanEvent attaches an event with the given name to s. The options can be
+// used to set the time at which the event occurred and its attributes. It has
+// no effect if s does not implement ddtrace.SpanWithEvents.
+func AddSpanEvent(s Span, name string, opts ...SpanEventOption) {
+	if s == nil {
+		return
+	}
+	sp, ok := s.(ddtrace.SpanWithEvents)
+	if !ok {
+		return
+	}
+	sp.AddEvent(name, opts...)
+}
+
 // payloadQueueSize is the buffer size of the trace channel.
 const payloadQueueSize = 1000
 
@@ -251,14 +289,17 @@ func newUnstartedTracer(opts ...StartOption) *tracer {
 	}
 	globalRate := globalSampleRate()
 	rulesSampler := newRulesSampler(c.traceRules, c.spanRules, globalRate)
-	c.traceSampleRate = newDynamicConfig("trace_sample_rate", globalRate, rulesSampler.traces.setGlobalSampleRate, equal[float64])
-	var dataStreamsProcessor *datastreams.Processor
-	if c.dataStreamsMonitoringEnabled {
-		dataStreamsProcessor = datastreams.NewProcessor(statsd, c.env, c.serviceName, c.version, c.agentURL, c.httpClient, func() bool {
-			f := loadAgentFeatures(c.logToStdout, c.agentURL, c.httpClient)
-			return f.DataStreams
-		})
+	if c.adaptiveRateLimit > 0 {
+		rulesSampler.traces.adaptive = newAdaptiveRateLimiter(c.adaptiveRateLimit, c.adaptiveRateLimitMin, time.Now())
+		if !rulesSampler.traces.enabled() {
+			log.Warn("Adaptive rate limit has no effect until trace sampling rules or DD_TRACE_SAMPLE_RATE are set")
+		}
+	} else if c.adaptiveRateLimit < 0 {
+		log.Warn("Ignoring negative adaptive rate limit %f", c.adaptiveRateLimit)
 	}
+	c.traceSampleRate = newDynamicConfig("trace_sample_rate", globalRate, rulesSampler.traces.setGlobalSampleRate, equal[float64])
+	c.traceSamplingRules = newDynamicConfig[samplingRules]("trace_sample_rules", c.traceRules, rulesSampler.traces.setRules, equalSamplingRules)
+	c.spanSamplingRules = newDynamicConfig[samplingRules]("span_sample_rules", c.spanRules, rulesSampler.spans.setRules, equalSamplingRules)
 	t := &tracer{
 		config:           c,
 		traceWriter:      writer,
@@ -278,9 +319,18 @@ func newUnstartedTracer(opts ...StartOption) *tracer {
 				Cache:            c.agent.HasFlag("sql_cache"),
 			},
 		}),
-		statsd:      statsd,
-		dataStreams: dataStreamsProcessor,
+		statsd: statsd,
+	}
+	if c.dataStreamsMonitoringEnabled {
+		t.dataStreams.Store(t.newDataStreamsProcessor())
+	}
+	if c.tailSampling != nil {
+		t.tailSampler = newTailSampler(*c.tailSampling, c.canDropP0s, t.sendChunk)
 	}
+	c.tracingEnabled = newDynamicConfig("trace_enabled", c.enabled, t.setTracingEnabled, equal[bool])
+	c.runtimeMetricsEnabled = newDynamicConfig("runtime_metrics_enabled", c.runtimeMetrics, t.setRuntimeMetrics, equal[bool])
+	c.dataStreamsEnabled = newDynamicConfig("data_streams_enabled", c.dataStreamsMonitoringEnabled, t.setDataStreams, equal[bool])
+	c.partialFlush = newDynamicConfig("trace_partial_flush_enabled", c.partialFlushEnabled, func(bool) bool { return true }, equal[bool])
 	return t
 }
 
@@ -293,19 +343,28 @@ func newTracer(opts ...StartOption) *tracer {
 	t := newUnstartedTracer(opts...)
 	c := t.config
 	t.statsd.Incr("datadog.tracer.started", nil, 1)
+	setLogsInjection(c.logsInjection.get())
 	if c.runtimeMetrics {
-		log.Debug("Runtime metrics enabled.")
-		t.wg.Add(1)
-		go func() {
-			defer t.wg.Done()
-			t.reportRuntimeMetrics(defaultMetricsReportInterval)
-		}()
+		t.setRuntimeMetrics(true)
 	}
 	if c.debugAbandonedSpans {
 		log.Info("Abandoned spans logs enabled.")
 		t.abandonedSpansDebugger = newAbandonedSpansDebugger()
 		t.abandonedSpansDebugger.Start(t.config.spanTimeout)
 	}
+	if c.debugServerAddr != "" {
+		ds, err := newDebugServer(c.debugServerAddr)
+		if err != nil {
+			log.Error("Failed to start debug server: %v", err)
+		} else {
+			t.debugServer = ds
+			t.wg.Add(1)
+			go func() {
+				defer t.wg.Done()
+				ds.start()
+			}()
+		}
+	}
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
@@ -339,8 +398,8 @@ func newTracer(opts ...StartOption) *tracer {
 func Flush() {
 	if t, ok := internal.GetGlobalTracer().(*tracer); ok {
 		t.flushSync()
-		if t.dataStreams != nil {
-			t.dataStreams.Flush()
+		if p := t.dataStreams.Load(); p != nil {
+			p.Flush()
 		}
 	}
 }
@@ -358,16 +417,19 @@ func (t *tracer) worker(tick <-chan time.Time) {
 	for {
 		select {
 		case trace := <-t.out:
-			t.sampleChunk(trace)
-			if len(trace.spans) != 0 {
-				t.traceWriter.add(trace.spans)
+			t.writeChunk(trace)
+		case now := <-tick:
+			if t.tailSampler != nil {
+				t.tailSampler.expire(now)
 			}
-		case <-tick:
 			t.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:scheduled"}, 1)
 			t.traceWriter.flush()
 
 		case done := <-t.flush:
 			t.statsd.Incr("datadog.tracer.flush_triggered", []string{"reason:invoked"}, 1)
+			if t.tailSampler != nil {
+				t.tailSampler.flush()
+			}
 			t.traceWriter.flush()
 			t.statsd.Flush()
 			t.stats.flushAndSend(time.Now(), withCurrentBucket)
@@ -383,19 +445,43 @@ func (t *tracer) worker(tick <-chan time.Time) {
 			for {
 				select {
 				case trace := <-t.out:
-					t.sampleChunk(trace)
-					if len(trace.spans) != 0 {
-						t.traceWriter.add(trace.spans)
-					}
+					t.writeChunk(trace)
 				default:
 					break loop
 				}
 			}
+			if t.tailSampler != nil {
+				t.tailSampler.flush()
+			}
 			return
 		}
 	}
 }
 
+// writeChunk hands the chunk c to the tail sampler when enabled, which sends it
+// once its trace is decided upon, or sends it right away.
+func (t *tracer) writeChunk(c *chunk) {
+	if t.tailSampler != nil {
+		t.tailSampler.add(c, time.Now())
+		return
+	}
+	t.sendChunk(c)
+}
+
+// sendChunk samples the chunk c and runs the span processors on it, before
+// handing it to the trace writer.
+func (t *tracer) sendChunk(c *chunk) {
+	t.sampleChunk(c)
+	if len(c.spans) == 0 || !t.processChunk(c) {
+		return
+	}
+	if t.debugServer != nil {
+		// record the chunk as it is written, once sampled, processed and redacted
+		t.debugServer.record(c)
+	}
+	t.traceWriter.add(c.spans)
+}
+
 // chunk holds information about a trace chunk to be flushed, including its spans.
 // The chunk may be a fully finished local trace chunk, or only a portion of the local trace chunk in the case of
 // partial flushing.
@@ -440,6 +526,9 @@ func (t *tracer) pushChunk(trace *chunk) {
 		return
 	default:
 	}
+	if t.tracingDisabled.Load() {
+		return
+	}
 	select {
 	case t.out <- trace:
 	default:
@@ -479,6 +568,11 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 			}
 		}
 	}
+	var baggage *spanContext
+	if context != nil && context.baggageOnly {
+		// The parent only carries W3C baggage: start a new trace inheriting it.
+		baggage, context = context, nil
+	}
 	if pprofContext == nil {
 		// For root span's without context, there is no pprofContext, but we need
 		// one to avoid a panic() in pprof.WithLabels(). Using context.Background()
@@ -531,6 +625,12 @@ func (t *tracer) StartSpan(operationName string, options ...ddtrace.StartSpanOpt
 		}
 	}
 	span.context = newSpanContext(span, context)
+	if baggage != nil {
+		baggage.ForeachBaggageItem(func(k, v string) bool {
+			span.context.setBaggageItem(k, v)
+			return true
+		})
+	}
 	span.setMetric(ext.Pid, float64(t.pid))
 	span.setMeta("language", "go")
 
@@ -644,21 +744,31 @@ func spanResourcePIISafe(s *span) bool {
 // Stop stops the tracer.
 func (t *tracer) Stop() {
 	t.stopOnce.Do(func() {
+		// hold togglesMu so that no goroutine is started once stopping
+		t.togglesMu.Lock()
 		close(t.stop)
+		t.togglesMu.Unlock()
 		t.statsd.Incr("datadog.tracer.stopped", nil, 1)
 	})
 	t.abandonedSpansDebugger.Stop()
+	t.debugServer.stop()
 	t.stats.Stop()
 	t.wg.Wait()
 	t.traceWriter.stop()
 	t.statsd.Close()
-	if t.dataStreams != nil {
-		t.dataStreams.Stop()
-	}
+	t.setDataStreams(false)
+	// restore the default of log correlation integrations
+	setLogsInjection(true)
 	appsec.Stop()
 	remoteconfig.Stop()
 }
 
+// setTracingEnabled sets whether finished traces are sent. Always returns true.
+func (t *tracer) setTracingEnabled(enabled bool) bool {
+	t.tracingDisabled.Store(!enabled)
+	return true
+}
+
 // Inject uses the configured or default TextMap Propagator.
 func (t *tracer) Inject(ctx ddtrace.SpanContext, carrier interface{}) error {
 	t.updateSampling(ctx)
@@ -7,6 +7,7 @@ package tracer
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -160,6 +161,7 @@ func (t *httpTransport) send(p *payload) (body io.ReadCloser, err error) {
 			stats.Count("datadog.tracer.dropped_p0_traces", int64(droppedTraces),
 				[]string{fmt.Sprintf("partial:%s", strconv.FormatBool(partialTraces > 0))}, 1)
 			stats.Count("datadog.tracer.dropped_p0_spans", int64(droppedSpans), nil, 1)
+			stats.Count("datadog.tracer.dropped_processor_chunks", int64(atomic.SwapUint32(&t.droppedProcessorChunks, 0)), nil, 1)
 		}
 		req.Header.Set("Datadog-Client-Dropped-P0-Traces", strconv.Itoa(droppedTraces))
 		req.Header.Set("Datadog-Client-Dropped-P0-Spans", strconv.Itoa(droppedSpans))
@@ -176,13 +178,34 @@ func (t *httpTransport) send(p *payload) (body io.ReadCloser, err error) {
 		response.Body.Close()
 		txt := http.StatusText(code)
 		if n > 0 {
-			return nil, fmt.Errorf("%s (Status: %s)", msg[:n], txt)
+			return nil, &statusError{code: code, msg: fmt.Sprintf("%s (Status: %s)", msg[:n], txt)}
 		}
-		return nil, fmt.Errorf("%s", txt)
+		return nil, &statusError{code: code, msg: txt}
 	}
 	return response.Body, nil
 }
 
+// statusError is returned by httpTransport.send when the agent responds with an
+// error status code.
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string { return e.msg }
+
+// isRetriableSendError reports whether sending a payload which failed with err
+// may succeed later, in which case it is worth retrying or spooling it. This is
+// the case of network errors, server errors and rate limiting, but not of the
+// other client errors, e.g. for payloads the agent will never accept.
+func isRetriableSendError(err error) bool {
+	var se *statusError
+	if !errors.As(err, &se) {
+		return true
+	}
+	return se.code >= 500 || se.code == http.StatusTooManyRequests
+}
+
 func (t *httpTransport) endpoint() string {
 	return t.traceURL
 }
@@ -50,16 +50,29 @@ type agentTraceWriter struct {
 
 	// statsd is used to send metrics
 	statsd globalinternal.StatsdClient
+
+	// spool, when not nil, stores payloads on disk which could not be sent
+	// to the agent, in order to replay them later.
+	spool *spool
 }
 
 func newAgentTraceWriter(c *config, s *prioritySampler, statsdClient globalinternal.StatsdClient) *agentTraceWriter {
-	return &agentTraceWriter{
+	w := &agentTraceWriter{
 		config:           c,
 		payload:          newPayload(),
 		climit:           make(chan struct{}, concurrentConnectionLimit),
 		prioritySampling: s,
 		statsd:           statsdClient,
 	}
+	if c.spoolDir != "" {
+		sp, err := newSpool(c.spoolDir, c.spoolMaxSize, c.spoolMaxAge, statsdClient)
+		if err != nil {
+			log.Warn("Trace spool disabled: %v", err)
+		} else {
+			w.spool = sp
+		}
+	}
+	return w
 }
 
 func (h *agentTraceWriter) add(trace []*span) {
@@ -82,6 +95,14 @@ func (h *agentTraceWriter) stop() {
 // flush will push any currently buffered traces to the server.
 func (h *agentTraceWriter) flush() {
 	if h.payload.itemCount() == 0 {
+		if h.spool != nil && h.spool.len() > 0 {
+			// nothing new to send, but take the chance to replay what's pending
+			h.wg.Add(1)
+			go func() {
+				defer h.wg.Done()
+				h.spool.drain(h.sendSpooled)
+			}()
+		}
 		return
 	}
 	h.wg.Add(1)
@@ -101,6 +122,15 @@ func (h *agentTraceWriter) flush() {
 			h.wg.Done()
 		}(time.Now())
 
+		if h.spool != nil && h.spool.len() > 0 {
+			// Older payloads are waiting to be delivered; queue this one behind
+			// them to preserve ordering.
+			if err := h.spool.push(p); err != nil {
+				log.Error("lost %d traces: unable to spool payload: %v", p.itemCount(), err)
+			}
+			h.spool.drain(h.sendSpooled)
+			return
+		}
 		var count, size int
 		var err error
 		for attempt := 0; attempt <= h.config.sendRetries; attempt++ {
@@ -117,15 +147,41 @@ func (h *agentTraceWriter) flush() {
 				}
 				return
 			}
+			if !isRetriableSendError(err) {
+				break
+			}
 			log.Error("failure sending traces (attempt %d), will retry: %v", attempt+1, err)
 			p.reset()
 			time.Sleep(time.Millisecond)
 		}
+		if h.spool != nil && isRetriableSendError(err) {
+			serr := h.spool.push(p)
+			if serr == nil {
+				log.Warn("spooled %d traces to disk after failing to send them: %v", count, err)
+				return
+			}
+			log.Error("unable to spool payload: %v", serr)
+		}
 		h.statsd.Count("datadog.tracer.traces_dropped", int64(count), []string{"reason:send_failed"}, 1)
 		log.Error("lost %d traces: %v", count, err)
 	}(oldp)
 }
 
+// sendSpooled sends a payload replayed from the spool.
+func (h *agentTraceWriter) sendSpooled(p *payload) error {
+	size, count := p.size(), p.itemCount()
+	rc, err := h.config.transport.send(p)
+	if err != nil {
+		return err
+	}
+	h.statsd.Count("datadog.tracer.flush_bytes", int64(size), nil, 1)
+	h.statsd.Count("datadog.tracer.flush_traces", int64(count), nil, 1)
+	if err := h.prioritySampling.readRatesJSON(rc); err != nil {
+		h.statsd.Incr("datadog.tracer.decode_error", nil, 1)
+	}
+	return nil
+}
+
 // logWriter specifies the output target of the logTraceWriter; replaced in tests.
 var logWriter io.Writer = os.Stdout
 
@@ -241,6 +297,28 @@ func (h *logTraceWriter) encodeSpan(s *span) {
 	h.buf.Write(strconv.AppendInt(scratch[:0], s.Duration, 10))
 	h.buf.WriteString(`,"service":`)
 	h.marshalString(s.Service)
+	if len(s.SpanEvents) > 0 {
+		h.buf.WriteString(`,"span_events":[`)
+		for i, e := range s.SpanEvents {
+			if i > 0 {
+				h.buf.WriteString(`,`)
+			}
+			h.buf.WriteString(`{"name":`)
+			h.marshalString(e.Name)
+			h.buf.WriteString(`,"time_unix_nano":`)
+			h.buf.Write(strconv.AppendUint(scratch[:0], e.TimeUnixNano, 10))
+			if len(e.Attributes) > 0 {
+				if m, err := json.Marshal(e.Attributes); err != nil {
+					log.Error("Error marshaling attributes of span event %q: %v", e.Name, err)
+				} else {
+					h.buf.WriteString(`,"attributes":`)
+					h.buf.Write(m)
+				}
+			}
+			h.buf.WriteString(`}`)
+		}
+		h.buf.WriteString(`]`)
+	}
 	h.buf.WriteString(`}`)
 }
 
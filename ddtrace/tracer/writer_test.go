@@ -15,6 +15,7 @@ import (
 	"strings"
 	"testing"
 
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 
 	"github.com/stretchr/testify/assert"
@@ -236,6 +237,21 @@ func TestLogWriter(t *testing.T) {
 		assert.NotContains(str, "\n")
 		assert.Contains(str, "\\n")
 	})
+
+	t.Run("span-events", func(t *testing.T) {
+		assert := assert.New(t)
+		s := newSpan("name", "srv", "res", 2, 1, 3)
+		s.Start = 12
+		s.SpanEvents = []ddtrace.SpanEvent{
+			{Name: "retry", TimeUnixNano: 15, Attributes: map[string]interface{}{"attempt": 2}},
+			{Name: "done", TimeUnixNano: 16},
+		}
+
+		var w logTraceWriter
+		w.encodeSpan(s)
+
+		assert.Equal(`{"trace_id":"1","span_id":"2","parent_id":"3","name":"name","resource":"res","error":0,"meta":{},"metrics":{},"start":12,"duration":0,"service":"srv","span_events":[{"name":"retry","time_unix_nano":15,"attributes":{"attempt":2}},{"name":"done","time_unix_nano":16}]}`, w.buf.String())
+	})
 }
 
 func TestLogWriterOverflow(t *testing.T) {
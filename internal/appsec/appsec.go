@@ -25,6 +25,15 @@ func Enabled() bool {
 	return activeAppSec != nil && activeAppSec.started
 }
 
+// RASPEnabled returns true when AppSec is enabled along with its Runtime
+// Application Self-Protection, so that integrations must protect the outbound
+// HTTP requests and SQL queries they instrument.
+func RASPEnabled() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return activeAppSec != nil && activeAppSec.started && activeAppSec.cfg.RASP
+}
+
 // Start AppSec when enabled is enabled by both using the appsec build tag and
 // setting the environment variable DD_APPSEC_ENABLED to true.
 func Start(opts ...config.StartOption) {
@@ -130,12 +139,24 @@ type appsec struct {
 	limiter   *limiter.TokenTicker
 	wafHandle *wafHandle
 	started   bool
+	// rulesMu serializes the updates of the security rules, which can come from
+	// both remote configuration and the rules directory.
+	rulesMu  sync.Mutex
+	rulesDir *rulesDir
+	// rulesDirBase holds the base rules restored when the rules.json file of the
+	// rules directory is removed. It is captured once, as the base rules of the
+	// RulesManager may come from rules.json when AppSec is restarted.
+	rulesDirBase config.RulesFragment
 }
 
 func newAppSec(cfg *config.Config) *appsec {
-	return &appsec{
+	a := &appsec{
 		cfg: cfg,
 	}
+	if cfg.RulesManager != nil {
+		a.rulesDirBase = cfg.RulesManager.Base
+	}
+	return a
 }
 
 // Start AppSec by registering its security protections according to the configured the security rules.
@@ -160,6 +181,9 @@ func (a *appsec) start(telemetry *appsecTelemetry) error {
 		return err
 	}
 
+	// Apply and watch the local rules directory, if any
+	a.startRulesDir()
+
 	a.enableRCBlocking()
 
 	a.started = true
@@ -183,6 +207,7 @@ func (a *appsec) stop() {
 	a.started = false
 	// Disable RC blocking first so that the following is guaranteed not to be concurrent anymore.
 	a.disableRCBlocking()
+	a.stopRulesDir()
 
 	// Disable the currently applied instrumentation
 	dyngo.SwapRootOperation(nil)
@@ -12,12 +12,26 @@ import (
 	"time"
 
 	internal "github.com/DataDog/appsec-internal-go/appsec"
+	sharedinternal "gopkg.in/DataDog/dd-trace-go.v1/internal"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/remoteconfig"
 )
 
 // EnvEnabled is the env var used to enable/disable appsec
 const EnvEnabled = "DD_APPSEC_ENABLED"
 
+// EnvRASPEnabled is the env var used to enable/disable the Runtime Application
+// Self-Protection (RASP) of outbound HTTP requests and SQL queries, which is
+// enabled by default along with appsec.
+const EnvRASPEnabled = "DD_APPSEC_RASP_ENABLED"
+
+// EnvRulesDir is the env var used to set a local directory of security rules
+// files, which are watched for changes and applied on top of the base rules.
+const EnvRulesDir = "DD_APPSEC_RULES_DIR"
+
+// defaultRulesDirPollInterval is the default interval at which the rules directory
+// is checked for changes.
+const defaultRulesDirPollInterval = 5 * time.Second
+
 // StartOption is used to customize the AppSec configuration when invoked with appsec.Start()
 type StartOption func(c *Config)
 
@@ -34,6 +48,12 @@ type Config struct {
 	Obfuscator internal.ObfuscatorConfig
 	// APISec configuration
 	APISec internal.APISecConfig
+	// RASP enables the exploit prevention of outbound HTTP requests and SQL queries
+	RASP bool
+	// RulesDir is the local directory of rules files watched for changes. Empty if disabled (default)
+	RulesDir string
+	// RulesDirPollInterval is the interval at which RulesDir is checked for changes
+	RulesDirPollInterval time.Duration
 	// RC is the remote configuration client used to receive product configuration updates. Nil if RC is disabled (default)
 	RC *remoteconfig.ClientConfig
 }
@@ -72,10 +92,13 @@ func NewConfig() (*Config, error) {
 	}
 
 	return &Config{
-		RulesManager:   r,
-		WAFTimeout:     internal.WAFTimeoutFromEnv(),
-		TraceRateLimit: int64(internal.RateLimitFromEnv()),
-		Obfuscator:     internal.NewObfuscatorConfig(),
-		APISec:         internal.NewAPISecConfig(),
+		RulesManager:         r,
+		WAFTimeout:           internal.WAFTimeoutFromEnv(),
+		TraceRateLimit:       int64(internal.RateLimitFromEnv()),
+		Obfuscator:           internal.NewObfuscatorConfig(),
+		APISec:               internal.NewAPISecConfig(),
+		RASP:                 sharedinternal.BoolEnv(EnvRASPEnabled, true),
+		RulesDir:             os.Getenv(EnvRulesDir),
+		RulesDirPollInterval: defaultRulesDirPollInterval,
 	}, nil
 }
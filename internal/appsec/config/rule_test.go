@@ -27,3 +27,19 @@ func TestStaticRule(t *testing.T) {
 	require.NotNil(t, waf)
 	waf.Close()
 }
+
+func TestRulesManagerClone(t *testing.T) {
+	r, err := NewRulesManeger([]byte(`{"version":"2.2","rules":[{"id":"1"}],"actions":[{"id":"block","type":"block_request"}]}`))
+	require.NoError(t, err)
+	r.ChangeBase(r.Base, "rules/path")
+	r.AddEdit("edit/path", RulesFragment{Exclusions: []interface{}{"exclusion"}})
+	r.Compile()
+
+	clone := r.Clone()
+	require.Equal(t, r.BasePath, clone.BasePath)
+	require.Equal(t, r.Base.Rules, clone.Base.Rules)
+	require.Equal(t, r.Base.Actions, clone.Base.Actions)
+	require.Equal(t, r.Edits, clone.Edits)
+	clone.Compile()
+	require.Equal(t, r.Raw(), clone.Raw())
+}
@@ -72,13 +72,14 @@ func DefaultRulesFragment() RulesFragment {
 func (f *RulesFragment) clone() (clone RulesFragment) {
 	clone.Version = f.Version
 	clone.Metadata = f.Metadata
+	clone.Rules = cloneSlice(f.Rules)
 	clone.Overrides = cloneSlice(f.Overrides)
 	clone.Exclusions = cloneSlice(f.Exclusions)
 	clone.RulesData = cloneSlice(f.RulesData)
+	clone.Actions = cloneSlice(f.Actions)
 	clone.CustomRules = cloneSlice(f.CustomRules)
 	clone.Processors = cloneSlice(f.Processors)
 	clone.Scanners = cloneSlice(f.Scanners)
-	// TODO (Francois Mazeau): copy more fields once we handle them
 	return
 }
 
@@ -115,6 +116,7 @@ func (r *RulesManager) Clone() (clone RulesManager) {
 		clone.Edits[k] = v
 	}
 	clone.Base = r.Base.clone()
+	clone.BasePath = r.BasePath
 	clone.Latest = r.Latest.clone()
 	return
 }
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package customsec defines the API and contract for AppSec to monitor arbitrary
+// application data, such as the messages of a queue consumer, which isn't
+// received through one of the instrumented protocols.
+package customsec
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/customsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/trace"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// Monitor starts and finishes the custom operation of the given addresses. When
+// ctx holds the operation of a monitored request, the addresses are monitored
+// along with the request, and the request's security events and actions are
+// updated. Otherwise, they are monitored on their own, and their security events
+// are set on span. It returns a *events.BlockingSecurityEvent error when an
+// action blocks, in which case the caller must abort its processing.
+func Monitor(ctx context.Context, span trace.TagSetter, addresses map[string]any) error {
+	parent, _ := ctx.Value(listener.ContextKey{}).(dyngo.Operation)
+	var err error
+	op := &types.CustomOperation{Operation: dyngo.NewOperation(parent)}
+	dyngo.OnData(op, func(e error) { err = e })
+	dyngo.StartOperation(op, types.CustomOperationArgs{Addresses: addresses})
+	dyngo.FinishOperation(op, types.CustomOperationRes{})
+	if parent == nil {
+		if err := trace.SetEventSpanTags(span, op.Events()); err != nil {
+			log.Error("appsec: unexpected error while creating the appsec events tags: %v", err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package customsec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/customsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec"
+	httpsectypes "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+
+	"github.com/stretchr/testify/require"
+)
+
+type tagsRecorder map[string]any
+
+func (r tagsRecorder) SetTag(k string, v any) { r[k] = v }
+
+func TestMonitor(t *testing.T) {
+	root := dyngo.NewRootOperation()
+	dyngo.SwapRootOperation(root)
+	defer dyngo.SwapRootOperation(dyngo.NewRootOperation())
+
+	actions := sharedsec.Actions{"block": sharedsec.NewBlockRequestAction(403, 10, "auto")}
+	// monitor mimics the custom WAF event listener, blocking the "attack" values
+	monitor := func(op *types.CustomOperation, events shared.SecurityEventsAdder, args types.CustomOperationArgs) {
+		if args.Addresses["custom.message"] == "attack" {
+			shared.ProcessRASPActions(op, actions, []string{"block"})
+			events.AddSecurityEvents([]any{"event"})
+		}
+	}
+	dyngo.On(root, func(op *types.CustomOperation, args types.CustomOperationArgs) {
+		if op.Parent() == root {
+			monitor(op, op, args)
+		}
+	})
+	var requestEvents []any
+	dyngo.On(root, func(op *httpsectypes.Operation, _ httpsectypes.HandlerOperationArgs) {
+		dyngo.On(op, func(customOp *types.CustomOperation, args types.CustomOperationArgs) {
+			monitor(customOp, op, args)
+		})
+		dyngo.OnFinish(op, func(op *httpsectypes.Operation, _ httpsectypes.HandlerOperationRes) {
+			requestEvents = op.Events()
+		})
+	})
+
+	t.Run("no-request", func(t *testing.T) {
+		span := tagsRecorder{}
+		require.NoError(t, Monitor(context.Background(), span, map[string]any{"custom.message": "hello"}))
+		require.Empty(t, span)
+
+		err := Monitor(context.Background(), span, map[string]any{"custom.message": "attack"})
+		var blocked *events.BlockingSecurityEvent
+		require.True(t, errors.As(err, &blocked))
+		require.Equal(t, `{"triggers":["event"]}`, span["_dd.appsec.json"])
+		require.Equal(t, true, span["appsec.event"])
+	})
+
+	t.Run("request", func(t *testing.T) {
+		var action *sharedsec.Action
+		ctx, op := httpsec.StartOperation(context.Background(), httpsectypes.HandlerOperationArgs{}, func(op *httpsectypes.Operation) {
+			dyngo.OnData(op, func(a *sharedsec.Action) { action = a })
+		})
+
+		span := tagsRecorder{}
+		require.NoError(t, Monitor(ctx, span, map[string]any{"custom.message": "hello"}))
+		require.Nil(t, action)
+
+		err := Monitor(ctx, span, map[string]any{"custom.message": "attack"})
+		var blocked *events.BlockingSecurityEvent
+		require.True(t, errors.As(err, &blocked))
+		// the action bubbles up to the request, so that it's blocked too
+		require.NotNil(t, action)
+		require.True(t, action.Blocking())
+		// the events belong to the request
+		require.Empty(t, span)
+		op.Finish(httpsectypes.HandlerOperationRes{})
+		require.Equal(t, []any{"event"}, requestEvents)
+	})
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package types
+
+import (
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/trace"
+)
+
+type (
+	// CustomOperation type representing a call to appsec.Monitor(), either while
+	// serving a monitored request, or outside of any request. In the latter case,
+	// its security events are kept by the operation itself.
+	CustomOperation struct {
+		dyngo.Operation
+		trace.SecurityEventsHolder
+	}
+
+	// CustomOperationArgs is the custom operation arguments.
+	CustomOperationArgs struct {
+		// Addresses holds the values to monitor, indexed by their WAF address.
+		Addresses map[string]any
+	}
+
+	// CustomOperationRes is the custom operation results.
+	CustomOperationRes struct{}
+)
+
+func (CustomOperationArgs) IsArgOf(*CustomOperation)   {}
+func (CustomOperationRes) IsResultOf(*CustomOperation) {}
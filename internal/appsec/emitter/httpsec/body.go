@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httpsec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WrapBodyHandler wraps the given HTTP handler so that the request body, when it
+// is no larger than limit bytes, is parsed according to its content type and
+// monitored as if passed to MonitorParsedBody. The body read is buffered again so
+// that the handler can still read it entirely. The handler isn't called when the
+// request must be blocked because of its body. It must be wrapped by WrapHandler
+// in order for the body to be monitored along with the request.
+func WrapBodyHandler(handler http.Handler, limit int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body := readRequestBody(r, limit); body != nil {
+			if parsed := ParseBody(r.Header.Get("Content-Type"), body); parsed != nil {
+				if err := MonitorParsedBody(r.Context(), parsed); err != nil {
+					// The request is blocked and the blocking response is written by WrapHandler
+					return
+				}
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// readRequestBody reads the body of r when it is no larger than limit bytes, and
+// replaces it with a reader returning the same bytes. It returns nil when the body
+// is empty or too large.
+func readRequestBody(r *http.Request, limit int64) []byte {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength > limit {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, limit+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil || len(body) == 0 || int64(len(body)) > limit {
+		return nil
+	}
+	return body
+}
+
+// ParseBody parses the given request or response body according to its content
+// type, which can be JSON, form-urlencoded, multipart or XML. It returns nil when
+// the content type isn't supported or the body is malformed.
+func ParseBody(contentType string, body []byte) any {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return nil
+		}
+		return v
+	case mediaType == "application/x-www-form-urlencoded":
+		v, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil
+		}
+		return map[string][]string(v)
+	case mediaType == "multipart/form-data":
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)))
+		if err != nil {
+			return nil
+		}
+		defer form.RemoveAll()
+		return form.Value
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		v, err := parseXML(body)
+		if err != nil {
+			return nil
+		}
+		return v
+	default:
+		return nil
+	}
+}
+
+// parseXML parses the XML document body into a map holding its root element.
+// Elements are represented by maps holding their attributes under the `@name`
+// keys, their text under the `#text` key, and their child elements under their
+// name as lists of elements.
+func parseXML(body []byte) (map[string]any, error) {
+	type element struct {
+		name   string
+		fields map[string]any
+		text   strings.Builder
+	}
+	root := &element{fields: make(map[string]any)}
+	stack := []*element{root}
+	d := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			e := &element{name: tok.Name.Local, fields: make(map[string]any, len(tok.Attr))}
+			for _, attr := range tok.Attr {
+				e.fields["@"+attr.Name.Local] = attr.Value
+			}
+			stack = append(stack, e)
+		case xml.EndElement:
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if text := strings.TrimSpace(e.text.String()); text != "" {
+				e.fields["#text"] = text
+			}
+			parent := stack[len(stack)-1].fields
+			children, _ := parent[e.name].([]any)
+			parent[e.name] = append(children, e.fields)
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(tok)
+		}
+	}
+	return root.fields, nil
+}
@@ -0,0 +1,155 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httpsec
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBody(t *testing.T) {
+	var multipartBody bytes.Buffer
+	mw := multipart.NewWriter(&multipartBody)
+	mw.WriteField("name", "value")
+	mw.WriteField("name", "other")
+	mw.Close()
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		expected    any
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"a":[1,"b"],"c":{"d":true}}`,
+			expected:    map[string]any{"a": []any{1.0, "b"}, "c": map[string]any{"d": true}},
+		},
+		{
+			name:        "json-suffix",
+			contentType: "application/vnd.api+json",
+			body:        `["a"]`,
+			expected:    []any{"a"},
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "a=1&a=2&b=3",
+			expected:    map[string][]string{"a": {"1", "2"}, "b": {"3"}},
+		},
+		{
+			name:        "multipart",
+			contentType: mw.FormDataContentType(),
+			body:        multipartBody.String(),
+			expected:    map[string][]string{"name": {"value", "other"}},
+		},
+		{
+			name:        "xml",
+			contentType: "text/xml",
+			body:        `<user id="1"><name>alice</name><role>admin</role><role>dev</role></user>`,
+			expected: map[string]any{"user": []any{map[string]any{
+				"@id":  "1",
+				"name": []any{map[string]any{"#text": "alice"}},
+				"role": []any{map[string]any{"#text": "admin"}, map[string]any{"#text": "dev"}},
+			}}},
+		},
+		{name: "malformed-json", contentType: "application/json", body: `{"a":`},
+		{name: "malformed-xml", contentType: "application/xml", body: `<a><b></a>`},
+		{name: "unsupported", contentType: "text/plain", body: "hello"},
+		{name: "no-content-type", body: `{"a":1}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseBody(tc.contentType, []byte(tc.body)))
+		})
+	}
+}
+
+func TestWrapBodyHandler(t *testing.T) {
+	root := dyngo.NewRootOperation()
+	dyngo.SwapRootOperation(root)
+	defer dyngo.SwapRootOperation(dyngo.NewRootOperation())
+
+	var monitored []any
+	dyngo.On(root, func(op *types.Operation, _ types.HandlerOperationArgs) {
+		dyngo.On(op, func(bodyOp *types.SDKBodyOperation, args types.SDKBodyOperationArgs) {
+			monitored = append(monitored, args.Body)
+			if m, _ := args.Body.(map[string]any); m["block"] == true {
+				dyngo.EmitData(bodyOp, types.NewMonitoringError("Request blocked"))
+			}
+		})
+	})
+
+	var read string
+	h := WrapBodyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		read = string(b)
+		w.Write([]byte("ok"))
+	}), 16)
+	serve := func(body string, chunked bool) *httptest.ResponseRecorder {
+		monitored, read = nil, ""
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if chunked {
+			r.ContentLength = -1
+		}
+		ctx, op := StartOperation(context.Background(), types.HandlerOperationArgs{})
+		defer op.Finish(types.HandlerOperationRes{})
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r.WithContext(ctx))
+		return w
+	}
+
+	t.Run("monitored", func(t *testing.T) {
+		w := serve(`{"a":1}`, false)
+		assert.Equal(t, []any{map[string]any{"a": 1.0}}, monitored)
+		assert.Equal(t, `{"a":1}`, read)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+
+	t.Run("too-large", func(t *testing.T) {
+		for _, chunked := range []bool{false, true} {
+			serve(`{"a":"0123456789abcdef"}`, chunked)
+			assert.Empty(t, monitored)
+			assert.Equal(t, `{"a":"0123456789abcdef"}`, read)
+		}
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		w := serve(`{"block":true}`, false)
+		assert.Len(t, monitored, 1)
+		assert.Empty(t, read)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+type bodyRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (w bodyRecorder) Body() []byte { return w.ResponseRecorder.Body.Bytes() }
+
+func TestMakeHandlerOperationResBody(t *testing.T) {
+	w := bodyRecorder{httptest.NewRecorder()}
+	assert.Nil(t, MakeHandlerOperationRes(w).Body)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"id":1}`))
+	assert.Equal(t, map[string]any{"id": 1.0}, MakeHandlerOperationRes(w).Body)
+}
@@ -131,13 +131,20 @@ func MakeHandlerOperationArgs(r *http.Request, clientIP netip.Addr, pathParams m
 	}
 }
 
-// MakeHandlerOperationRes creates the HandlerOperationRes value.
+// MakeHandlerOperationRes creates the HandlerOperationRes value. The response
+// body is parsed when w records it, see WrapBodyHandler.
 func MakeHandlerOperationRes(w http.ResponseWriter) types.HandlerOperationRes {
 	var status int
 	if mw, ok := w.(interface{ Status() int }); ok {
 		status = mw.Status()
 	}
-	return types.HandlerOperationRes{Status: status, Headers: headersRemoveCookies(w.Header())}
+	var body any
+	if bw, ok := w.(interface{ Body() []byte }); ok {
+		if b := bw.Body(); len(b) > 0 {
+			body = ParseBody(w.Header().Get("Content-Type"), b)
+		}
+	}
+	return types.HandlerOperationRes{Status: status, Headers: headersRemoveCookies(w.Header()), Body: body}
 }
 
 // Remove cookies from the request headers and return the map of headers
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httpsec
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/trace"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/trace/httptrace"
+)
+
+// HandlerMonitor monitors a request served by a framework which isn't based on
+// net/http, such as fasthttp, for which WrapHandler can't be used. It is the
+// responsibility of the integration to build the operation arguments and result,
+// and to write the responses of the actions returned by Action.
+type HandlerMonitor struct {
+	span   ddtrace.Span
+	args   types.HandlerOperationArgs
+	op     *types.Operation
+	action *sharedsec.Action
+}
+
+// StartHandlerMonitor starts the HTTP handler operation of the request described
+// by args, and sets the AppSec span tags known upfront, including the client IP
+// tags ipTags. It returns the request context holding the operation, which must
+// be finished using the Finish method of the returned monitor.
+func StartHandlerMonitor(ctx context.Context, span ddtrace.Span, args types.HandlerOperationArgs, ipTags map[string]string) (context.Context, *HandlerMonitor) {
+	trace.SetAppSecEnabledTags(span)
+	trace.SetTags(span, ipTags)
+	m := &HandlerMonitor{span: span, args: args}
+	ctx, m.op = StartOperation(ctx, args, func(op *types.Operation) {
+		dyngo.OnData(op, func(a *sharedsec.Action) {
+			m.action = a
+		})
+	})
+	return ctx, m
+}
+
+// Action returns the last action emitted by the operation, if any. When set after
+// StartHandlerMonitor, its response must be written instead of calling the request
+// handler. When set after Finish, its response must replace the one written by the
+// request handler.
+func (m *HandlerMonitor) Action() *sharedsec.Action {
+	return m.action
+}
+
+// Finish finishes the operation with the response of the request handler, and
+// sets the AppSec span tags.
+func (m *HandlerMonitor) Finish(res types.HandlerOperationRes) {
+	events := m.op.Finish(res)
+	if m.action != nil && m.action.Blocking() {
+		m.op.SetTag(trace.BlockedRequestTag, true)
+	}
+	setRequestHeadersTags(m.span, m.args.Headers)
+	setResponseHeadersTags(m.span, res.Headers)
+	trace.SetTags(m.span, m.op.Tags())
+	if len(events) > 0 {
+		httptrace.SetSecurityEventsTags(m.span, events)
+	}
+}
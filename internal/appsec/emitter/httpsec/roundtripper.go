@@ -0,0 +1,32 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httpsec
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener"
+)
+
+// ProtectRoundTrip starts and finishes the round trip operation of an outbound
+// HTTP request to url, made while serving the request monitored in ctx, if any.
+// It returns a *events.BlockingSecurityEvent error when the outbound request
+// must be aborted.
+func ProtectRoundTrip(ctx context.Context, url string) error {
+	parent, ok := ctx.Value(listener.ContextKey{}).(dyngo.Operation)
+	if !ok {
+		// The outbound request isn't made while serving a monitored request
+		return nil
+	}
+	var err error
+	op := &types.RoundTripOperation{Operation: dyngo.NewOperation(parent)}
+	dyngo.OnData(op, func(e error) { err = e })
+	dyngo.StartOperation(op, types.RoundTripOperationArgs{URL: url})
+	dyngo.FinishOperation(op, types.RoundTripOperationRes{})
+	return err
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httpsec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtectRoundTrip(t *testing.T) {
+	root := dyngo.NewRootOperation()
+	dyngo.SwapRootOperation(root)
+	defer dyngo.SwapRootOperation(dyngo.NewRootOperation())
+
+	actions := sharedsec.Actions{"block": sharedsec.NewBlockRequestAction(403, 10, "auto")}
+	var urls []string
+	dyngo.On(root, func(op *types.Operation, _ types.HandlerOperationArgs) {
+		dyngo.On(op, func(rtOp *types.RoundTripOperation, args types.RoundTripOperationArgs) {
+			urls = append(urls, args.URL)
+			if args.URL == "http://169.254.169.254/latest/meta-data" {
+				shared.ProcessRASPActions(rtOp, actions, []string{"block"})
+			}
+		})
+	})
+
+	// outbound requests made outside of a monitored request aren't protected
+	require.NoError(t, ProtectRoundTrip(context.Background(), "http://169.254.169.254/latest/meta-data"))
+	require.Empty(t, urls)
+
+	var action *sharedsec.Action
+	ctx, op := StartOperation(context.Background(), types.HandlerOperationArgs{}, func(op *types.Operation) {
+		dyngo.OnData(op, func(a *sharedsec.Action) { action = a })
+	})
+	defer op.Finish(types.HandlerOperationRes{})
+
+	require.NoError(t, ProtectRoundTrip(ctx, "https://example.com"))
+	require.Nil(t, action)
+
+	err := ProtectRoundTrip(ctx, "http://169.254.169.254/latest/meta-data")
+	var blocked *events.BlockingSecurityEvent
+	require.True(t, errors.As(err, &blocked))
+	require.NotNil(t, action)
+	require.Equal(t, []string{"https://example.com", "http://169.254.169.254/latest/meta-data"}, urls)
+}
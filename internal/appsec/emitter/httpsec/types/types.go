@@ -27,6 +27,12 @@ type (
 	SDKBodyOperation struct {
 		dyngo.Operation
 	}
+
+	// RoundTripOperation type representing an outbound HTTP request made while
+	// serving a monitored request.
+	RoundTripOperation struct {
+		dyngo.Operation
+	}
 )
 
 // Finish the HTTP handler operation, along with the given results and emits a
@@ -59,6 +65,8 @@ type (
 	// HandlerOperationRes is the HTTP handler operation results.
 	HandlerOperationRes struct {
 		Headers map[string][]string
+		// Body corresponds to the address `server.response.body`, when the response body was parsed.
+		Body any
 		// Status corresponds to the address `server.response.status`.
 		Status int
 	}
@@ -72,6 +80,15 @@ type (
 	// SDKBodyOperationRes is the SDK body operation results.
 	SDKBodyOperationRes struct{}
 
+	// RoundTripOperationArgs is the round trip operation arguments.
+	RoundTripOperationArgs struct {
+		// URL corresponds to the address `server.io.net.url`.
+		URL string
+	}
+
+	// RoundTripOperationRes is the round trip operation results.
+	RoundTripOperationRes struct{}
+
 	// MonitoringError is used to vehicle an HTTP error, usually resurfaced through Appsec SDKs.
 	MonitoringError struct {
 		msg string
@@ -99,5 +116,8 @@ func NewMonitoringError(msg string) error {
 func (SDKBodyOperationArgs) IsArgOf(*SDKBodyOperation)   {}
 func (SDKBodyOperationRes) IsResultOf(*SDKBodyOperation) {}
 
+func (RoundTripOperationArgs) IsArgOf(*RoundTripOperation)   {}
+func (RoundTripOperationRes) IsResultOf(*RoundTripOperation) {}
+
 func (HandlerOperationArgs) IsArgOf(*Operation)   {}
 func (HandlerOperationRes) IsResultOf(*Operation) {}
@@ -48,9 +48,20 @@ type (
 	// It holds the HTTP and gRPC handlers to be used instead of the regular
 	// request handler when said action is executed.
 	Action struct {
-		http     http.Handler
-		grpc     GRPCWrapper
-		blocking bool
+		http         http.Handler
+		httpResponse func(accept string) HTTPResponse
+		grpc         GRPCWrapper
+		blocking     bool
+	}
+
+	// HTTPResponse describes the HTTP response written by an action, so that it
+	// can be written by the integrations of frameworks which aren't based on
+	// net/http.
+	HTTPResponse struct {
+		Status int
+		// Headers holds the response headers, i.e. its Content-Type or Location.
+		Headers map[string]string
+		Body    []byte
 	}
 
 	// Actions represents a set of action bindings to an action name.
@@ -72,36 +83,32 @@ func (a *Action) Blocking() bool {
 
 // NewBlockHandler creates, initializes and returns a new BlockRequestAction
 func NewBlockHandler(status int, template string) http.Handler {
-	htmlHandler := newBlockRequestHandler(status, "text/html", blockedTemplateHTML)
-	jsonHandler := newBlockRequestHandler(status, "application/json", blockedTemplateJSON)
-	switch template {
-	case "json":
-		return jsonHandler
-	case "html":
-		return htmlHandler
-	default:
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			h := jsonHandler
-			hdr := r.Header.Get("Accept")
-			htmlIdx := strings.Index(hdr, "text/html")
-			jsonIdx := strings.Index(hdr, "application/json")
-			// Switch to html handler if text/html comes before application/json in the Accept header
-			if htmlIdx != -1 && (jsonIdx == -1 || htmlIdx < jsonIdx) {
-				h = htmlHandler
-			}
-			h.ServeHTTP(w, r)
-		})
-	}
-}
-
-func newBlockRequestHandler(status int, ct string, payload []byte) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", ct)
-		w.WriteHeader(status)
-		w.Write(payload)
+		resp := newBlockResponse(status, template, r.Header.Get("Accept"))
+		for k, v := range resp.Headers {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
 	})
 }
 
+// newBlockResponse returns the response blocking a request with the given Accept
+// header, using the given template. The "auto" template is the JSON one, unless
+// text/html comes before application/json in the Accept header.
+func newBlockResponse(status int, template, accept string) HTTPResponse {
+	html := template == "html"
+	if template != "json" && template != "html" {
+		htmlIdx := strings.Index(accept, "text/html")
+		jsonIdx := strings.Index(accept, "application/json")
+		html = htmlIdx != -1 && (jsonIdx == -1 || htmlIdx < jsonIdx)
+	}
+	if html {
+		return HTTPResponse{Status: status, Headers: map[string]string{"Content-Type": "text/html"}, Body: blockedTemplateHTML}
+	}
+	return HTTPResponse{Status: status, Headers: map[string]string{"Content-Type": "application/json"}, Body: blockedTemplateJSON}
+}
+
 func newGRPCBlockHandler(status int) GRPCWrapper {
 	return func(_ map[string][]string) (uint32, error) {
 		return uint32(status), errors.New("Request blocked")
@@ -111,7 +118,10 @@ func newGRPCBlockHandler(status int) GRPCWrapper {
 // NewBlockRequestAction creates an action for the "block" action type
 func NewBlockRequestAction(httpStatus, grpcStatus int, template string) *Action {
 	return &Action{
-		http:     NewBlockHandler(httpStatus, template),
+		http: NewBlockHandler(httpStatus, template),
+		httpResponse: func(accept string) HTTPResponse {
+			return newBlockResponse(httpStatus, template, accept)
+		},
 		grpc:     newGRPCBlockHandler(grpcStatus),
 		blocking: true,
 	}
@@ -121,6 +131,9 @@ func NewBlockRequestAction(httpStatus, grpcStatus int, template string) *Action
 func NewRedirectRequestAction(status int, loc string) *Action {
 	return &Action{
 		http: http.RedirectHandler(loc, status),
+		httpResponse: func(string) HTTPResponse {
+			return HTTPResponse{Status: status, Headers: map[string]string{"Location": loc}}
+		},
 		// gRPC is not handled by our SRB RFCs so far
 		// Use the default block handler for now
 		grpc: newGRPCBlockHandler(10),
@@ -132,6 +145,12 @@ func (a *Action) HTTP() http.Handler {
 	return a.http
 }
 
+// HTTPResponse returns the HTTP response written by the action to a request
+// having the given Accept header.
+func (a *Action) HTTPResponse(accept string) HTTPResponse {
+	return a.httpResponse(accept)
+}
+
 // GRPC returns the gRPC handler linked to the action object
 func (a *Action) GRPC() GRPCWrapper {
 	return a.grpc
@@ -201,3 +201,40 @@ func TestNewRedirectRequestAction(t *testing.T) {
 	}
 
 }
+
+func TestActionHTTPResponse(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		action   *Action
+		accept   string
+		expected HTTPResponse
+	}{
+		{
+			name:     "block-json",
+			action:   NewBlockRequestAction(403, 10, "json"),
+			accept:   "text/html",
+			expected: HTTPResponse{Status: 403, Headers: map[string]string{"Content-Type": "application/json"}, Body: blockedTemplateJSON},
+		},
+		{
+			name:     "block-auto-html",
+			action:   NewBlockRequestAction(403, 10, "auto"),
+			accept:   "text/html,application/json",
+			expected: HTTPResponse{Status: 403, Headers: map[string]string{"Content-Type": "text/html"}, Body: blockedTemplateHTML},
+		},
+		{
+			name:     "block-auto-json",
+			action:   NewBlockRequestAction(401, 10, "auto"),
+			accept:   "",
+			expected: HTTPResponse{Status: 401, Headers: map[string]string{"Content-Type": "application/json"}, Body: blockedTemplateJSON},
+		},
+		{
+			name:     "redirect",
+			action:   NewRedirectRequestAction(http.StatusSeeOther, "/blocked"),
+			expected: HTTPResponse{Status: http.StatusSeeOther, Headers: map[string]string{"Location": "/blocked"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.action.HTTPResponse(tc.accept))
+		})
+	}
+}
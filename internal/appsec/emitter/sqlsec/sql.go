@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+// Package sqlsec defines the SQL instrumentation API and contract for AppSec.
+// SQL integrations must use this package to protect the queries they execute
+// against SQL injections.
+package sqlsec
+
+import (
+	"context"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sqlsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener"
+)
+
+// ProtectSQLOperation starts and finishes the SQL operation of query, executed
+// by a database of type driver while serving the request monitored in ctx, if
+// any. It returns a *events.BlockingSecurityEvent error when the query must be
+// aborted.
+func ProtectSQLOperation(ctx context.Context, query, driver string) error {
+	parent, ok := ctx.Value(listener.ContextKey{}).(dyngo.Operation)
+	if !ok {
+		// The query isn't executed while serving a monitored request
+		return nil
+	}
+	var err error
+	op := &types.SQLOperation{Operation: dyngo.NewOperation(parent)}
+	dyngo.OnData(op, func(e error) { err = e })
+	dyngo.StartOperation(op, types.SQLOperationArgs{Query: query, Driver: driver})
+	dyngo.FinishOperation(op, types.SQLOperationRes{})
+	return err
+}
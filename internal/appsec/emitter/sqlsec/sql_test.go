@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package sqlsec
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec"
+	httpsectypes "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sqlsec/types"
+	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProtectSQLOperation(t *testing.T) {
+	root := dyngo.NewRootOperation()
+	dyngo.SwapRootOperation(root)
+	defer dyngo.SwapRootOperation(dyngo.NewRootOperation())
+
+	actions := sharedsec.Actions{"block": sharedsec.NewBlockRequestAction(403, 10, "auto")}
+	var args types.SQLOperationArgs
+	dyngo.On(root, func(op *httpsectypes.Operation, _ httpsectypes.HandlerOperationArgs) {
+		dyngo.On(op, func(sqlOp *types.SQLOperation, a types.SQLOperationArgs) {
+			args = a
+			if a.Query == "SELECT * FROM users WHERE id = '' OR 1=1" {
+				shared.ProcessRASPActions(sqlOp, actions, []string{"block"})
+			}
+		})
+	})
+
+	t.Run("no-request", func(t *testing.T) {
+		require.NoError(t, ProtectSQLOperation(context.Background(), "SELECT 1", "mysql"))
+		require.Empty(t, args)
+	})
+
+	var action *sharedsec.Action
+	ctx, op := httpsec.StartOperation(context.Background(), httpsectypes.HandlerOperationArgs{}, func(op *httpsectypes.Operation) {
+		dyngo.OnData(op, func(a *sharedsec.Action) { action = a })
+	})
+	defer op.Finish(httpsectypes.HandlerOperationRes{})
+
+	t.Run("allowed", func(t *testing.T) {
+		require.NoError(t, ProtectSQLOperation(ctx, "SELECT 1", "mysql"))
+		require.Equal(t, types.SQLOperationArgs{Query: "SELECT 1", Driver: "mysql"}, args)
+		require.Nil(t, action)
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		err := ProtectSQLOperation(ctx, "SELECT * FROM users WHERE id = '' OR 1=1", "postgresql")
+		var blocked *events.BlockingSecurityEvent
+		require.True(t, errors.As(err, &blocked))
+		// the action bubbles up to the request, so that it's blocked too
+		require.NotNil(t, action)
+		require.True(t, action.Blocking())
+	})
+}
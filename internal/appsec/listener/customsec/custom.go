@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package customsec
+
+import (
+	"time"
+
+	"github.com/DataDog/appsec-internal-go/limiter"
+	waf "github.com/DataDog/go-libddwaf/v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/config"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/customsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// Install registers the custom WAF Event Listener on the given root operation,
+// which monitors the custom operations started outside of any monitored request.
+func Install(wafHandle *waf.Handle, actions sharedsec.Actions, cfg *config.Config, lim limiter.Limiter, root dyngo.Operation) {
+	if wafHandle == nil {
+		log.Debug("appsec: no WAF Handle available, the custom WAF Event Listener will not be registered")
+		return
+	}
+	log.Debug("appsec: registering the custom WAF Event Listener")
+	dyngo.On(root, func(op *types.CustomOperation, args types.CustomOperationArgs) {
+		if op.Parent() != root {
+			// The operation is monitored by the listener of its request
+			return
+		}
+		wafCtx := waf.NewContext(wafHandle)
+		if wafCtx == nil {
+			// The WAF event listener got concurrently released
+			return
+		}
+		defer wafCtx.Close()
+		run(op, op, wafCtx, args, actions, cfg.WAFTimeout, lim)
+	})
+}
+
+// RegisterCustomListener registers the listener of the custom operations started
+// while serving the request monitored by op, which runs the WAF context wafCtx
+// of the request on their addresses. The security events are added to events.
+func RegisterCustomListener(op dyngo.Operation, events shared.SecurityEventsAdder, wafCtx *waf.Context, actions sharedsec.Actions, timeout time.Duration, limiter limiter.Limiter) {
+	dyngo.On(op, func(customOp *types.CustomOperation, args types.CustomOperationArgs) {
+		run(customOp, events, wafCtx, args, actions, timeout, limiter)
+	})
+}
+
+func run(op *types.CustomOperation, events shared.SecurityEventsAdder, wafCtx *waf.Context, args types.CustomOperationArgs, actions sharedsec.Actions, timeout time.Duration, limiter limiter.Limiter) {
+	wafResult := shared.RunWAF(wafCtx, waf.RunAddressData{Ephemeral: args.Addresses}, timeout)
+	if wafResult.HasActions() || wafResult.HasEvents() {
+		shared.ProcessRASPActions(op, actions, wafResult.Actions)
+		shared.AddSecurityEvents(events, limiter, wafResult.Events)
+		log.Debug("appsec: WAF detected suspicious custom data")
+	}
+}
@@ -18,8 +18,10 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/grpcsec/types"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/customsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/httpsec"
 	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sqlsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
 )
@@ -30,6 +32,9 @@ const (
 	GRPCServerRequestMetadata = "grpc.server.request.metadata"
 	HTTPClientIPAddr          = httpsec.HTTPClientIPAddr
 	UserIDAddr                = httpsec.UserIDAddr
+	ServerIoNetURLAddr        = httpsec.ServerIoNetURLAddr
+	ServerDBStatementAddr     = sqlsec.ServerDBStatementAddr
+	ServerDBTypeAddr          = sqlsec.ServerDBTypeAddr
 )
 
 // List of gRPC rule addresses currently supported by the WAF
@@ -38,6 +43,9 @@ var supportedAddresses = listener.AddressSet{
 	GRPCServerRequestMetadata: {},
 	HTTPClientIPAddr:          {},
 	UserIDAddr:                {},
+	ServerIoNetURLAddr:        {},
+	ServerDBStatementAddr:     {},
+	ServerDBTypeAddr:          {},
 }
 
 // Install registers the gRPC WAF Event Listener on the given root operation.
@@ -142,6 +150,18 @@ func (l *wafEventListener) onEvent(op *types.HandlerOperation, handlerArgs types
 		}
 	}
 
+	// Custom operations happen when appsec.Monitor() is called while serving the request
+	customsec.RegisterCustomListener(op, op, wafCtx, l.actions, l.config.WAFTimeout, l.limiter)
+
+	if l.config.RASP {
+		if _, ok := l.addresses[ServerIoNetURLAddr]; ok {
+			httpsec.RegisterRoundTripperListener(op, op, wafCtx, l.actions, l.config.WAFTimeout, l.limiter)
+		}
+		if _, ok := l.addresses[ServerDBStatementAddr]; ok {
+			sqlsec.RegisterSQLListener(op, op, wafCtx, l.actions, l.config.WAFTimeout, l.limiter)
+		}
+	}
+
 	dyngo.OnFinish(op, func(_ types.ReceiveOperation, res types.ReceiveOperationRes) {
 		if nbEvents.Load() == maxWAFEventsPerRequest {
 			logOnce.Do(func() {
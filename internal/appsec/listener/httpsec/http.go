@@ -18,7 +18,9 @@ import (
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/customsec"
 	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sqlsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/samplernames"
 )
@@ -34,6 +36,7 @@ const (
 	ServerRequestBodyAddr              = "server.request.body"
 	ServerResponseStatusAddr           = "server.response.status"
 	ServerResponseHeadersNoCookiesAddr = "server.response.headers.no_cookies"
+	ServerResponseBodyAddr             = "server.response.body"
 	HTTPClientIPAddr                   = "http.client_ip"
 	UserIDAddr                         = "usr.id"
 )
@@ -49,8 +52,12 @@ var supportedAddresses = listener.AddressSet{
 	ServerRequestBodyAddr:              {},
 	ServerResponseStatusAddr:           {},
 	ServerResponseHeadersNoCookiesAddr: {},
+	ServerResponseBodyAddr:             {},
 	HTTPClientIPAddr:                   {},
 	UserIDAddr:                         {},
+	ServerIoNetURLAddr:                 {},
+	sqlsec.ServerDBStatementAddr:       {},
+	sqlsec.ServerDBTypeAddr:            {},
 }
 
 // Install registers the HTTP WAF Event Listener on the given root operation.
@@ -178,10 +185,22 @@ func (l *wafEventListener) onEvent(op *types.Operation, args types.HandlerOperat
 		})
 	}
 
+	// Custom operations happen when appsec.Monitor() is called while serving the request
+	customsec.RegisterCustomListener(op, op, wafCtx, l.actions, l.config.WAFTimeout, l.limiter)
+
+	if l.config.RASP {
+		if _, ok := l.addresses[ServerIoNetURLAddr]; ok {
+			RegisterRoundTripperListener(op, op, wafCtx, l.actions, l.config.WAFTimeout, l.limiter)
+		}
+		if _, ok := l.addresses[sqlsec.ServerDBStatementAddr]; ok {
+			sqlsec.RegisterSQLListener(op, op, wafCtx, l.actions, l.config.WAFTimeout, l.limiter)
+		}
+	}
+
 	dyngo.OnFinish(op, func(op *types.Operation, res types.HandlerOperationRes) {
 		defer wafCtx.Close()
 
-		values = make(map[string]any, 2)
+		values = make(map[string]any, 3)
 		if _, ok := l.addresses[ServerResponseStatusAddr]; ok {
 			// serverResponseStatusAddr is a string address, so we must format the status code...
 			values[ServerResponseStatusAddr] = fmt.Sprintf("%d", res.Status)
@@ -191,6 +210,10 @@ func (l *wafEventListener) onEvent(op *types.Operation, args types.HandlerOperat
 			values[ServerResponseHeadersNoCookiesAddr] = res.Headers
 		}
 
+		if _, ok := l.addresses[ServerResponseBodyAddr]; ok && res.Body != nil {
+			values[ServerResponseBodyAddr] = res.Body
+		}
+
 		// Run the WAF, ignoring the returned actions - if any - since blocking after the request handler's
 		// response is not supported at the moment.
 		wafResult := shared.RunWAF(wafCtx, waf.RunAddressData{Persistent: values}, l.config.WAFTimeout)
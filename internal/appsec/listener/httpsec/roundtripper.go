@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package httpsec
+
+import (
+	"time"
+
+	"github.com/DataDog/appsec-internal-go/limiter"
+	waf "github.com/DataDog/go-libddwaf/v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/httpsec/types"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// ServerIoNetURLAddr is the address of the URL of an outbound HTTP request.
+const ServerIoNetURLAddr = "server.io.net.url"
+
+// RegisterRoundTripperListener registers the listener of the outbound HTTP
+// requests made while serving the request monitored by op, which runs the WAF
+// context wafCtx of the request on their URL so that the WAF can correlate it
+// with the request parameters. The security events are added to events.
+func RegisterRoundTripperListener(op dyngo.Operation, events shared.SecurityEventsAdder, wafCtx *waf.Context, actions sharedsec.Actions, timeout time.Duration, limiter limiter.Limiter) {
+	dyngo.On(op, func(rtOp *types.RoundTripOperation, args types.RoundTripOperationArgs) {
+		wafResult := shared.RunWAF(wafCtx, waf.RunAddressData{Ephemeral: map[string]any{ServerIoNetURLAddr: args.URL}}, timeout)
+		if wafResult.HasActions() || wafResult.HasEvents() {
+			shared.ProcessRASPActions(rtOp, actions, wafResult.Actions)
+			shared.AddSecurityEvents(events, limiter, wafResult.Events)
+			log.Debug("appsec: WAF detected a suspicious outbound request URL: %s", args.URL)
+		}
+	})
+}
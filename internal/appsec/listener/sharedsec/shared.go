@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/appsec-internal-go/limiter"
 	waf "github.com/DataDog/go-libddwaf/v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/trace"
@@ -27,12 +28,14 @@ func RunWAF(wafCtx *waf.Context, values waf.RunAddressData, timeout time.Duratio
 	return result
 }
 
-type securityEventsAdder interface {
+// SecurityEventsAdder is implemented by the operations holding the security
+// events of a monitored request.
+type SecurityEventsAdder interface {
 	AddSecurityEvents(events []any)
 }
 
 // Helper function to add sec events to an operation taking into account the rate limiter.
-func AddSecurityEvents(op securityEventsAdder, limiter limiter.Limiter, matches []any) {
+func AddSecurityEvents(op SecurityEventsAdder, limiter limiter.Limiter, matches []any) {
 	if len(matches) > 0 && limiter.Allow() {
 		op.AddSecurityEvents(matches)
 	}
@@ -89,3 +92,13 @@ func ProcessActions(op dyngo.Operation, actions sharedsec.Actions, actionIds []s
 	}
 	return interrupt
 }
+
+// ProcessRASPActions sends the relevant actions to the data listeners of the
+// RASP operation op, which bubble up to the operation of the monitored request.
+// When at least one of them blocks, the error aborting the protected call is also
+// sent to op.
+func ProcessRASPActions(op dyngo.Operation, actions sharedsec.Actions, actionIds []string) {
+	if ProcessActions(op, actions, actionIds) {
+		dyngo.EmitData(op, error(&events.BlockingSecurityEvent{}))
+	}
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package sqlsec
+
+import (
+	"time"
+
+	"github.com/DataDog/appsec-internal-go/limiter"
+	waf "github.com/DataDog/go-libddwaf/v2"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/dyngo"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/emitter/sqlsec/types"
+	shared "gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/listener/sharedsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+// SQL rule addresses currently supported by the WAF
+const (
+	ServerDBStatementAddr = "server.db.statement"
+	ServerDBTypeAddr      = "server.db.system"
+)
+
+// RegisterSQLListener registers the listener of the SQL queries executed while
+// serving the request monitored by op, which runs the WAF context wafCtx of the
+// request on their statement and database type so that the WAF can correlate
+// them with the request parameters. The security events are added to events.
+func RegisterSQLListener(op dyngo.Operation, events shared.SecurityEventsAdder, wafCtx *waf.Context, actions sharedsec.Actions, timeout time.Duration, limiter limiter.Limiter) {
+	dyngo.On(op, func(sqlOp *types.SQLOperation, args types.SQLOperationArgs) {
+		wafResult := shared.RunWAF(wafCtx, waf.RunAddressData{Ephemeral: map[string]any{
+			ServerDBStatementAddr: args.Query,
+			ServerDBTypeAddr:      args.Driver,
+		}}, timeout)
+		if wafResult.HasActions() || wafResult.HasEvents() {
+			shared.ProcessRASPActions(sqlOp, actions, wafResult.Actions)
+			shared.AddSecurityEvents(events, limiter, wafResult.Events)
+			log.Debug("appsec: WAF detected a suspicious SQL query: %s", args.Query)
+		}
+	})
+}
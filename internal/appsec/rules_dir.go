@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package appsec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/config"
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/log"
+)
+
+const (
+	// rulesDirBaseFile is the name of the file of the rules directory replacing
+	// the base rules when present.
+	rulesDirBaseFile = "rules.json"
+	// rulesDirEditPrefix prefixes the RulesManager edit paths of the other files
+	// of the rules directory.
+	rulesDirEditPrefix = "file:"
+)
+
+// fileStamp identifies the version of a file of the rules directory.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// rulesDirFiles holds the content of the rules directory, parsed as rules fragments.
+type rulesDirFiles struct {
+	base  *config.RulesFragment           // content of rules.json, if any
+	edits map[string]config.RulesFragment // content of the other files, by file name
+}
+
+// rulesDir watches a local directory of JSON rules files, such as rules.json,
+// rules_data.json, exclusions.json or actions.json, by polling it for changes.
+// The file rules.json replaces the base rules while it exists, and every other
+// file is applied as an edit of the rules, like the configs received through
+// remote configuration.
+type rulesDir struct {
+	path     string
+	interval time.Duration
+	// base is the base rules restored when rules.json is removed.
+	base config.RulesFragment
+	// stamps holds the version of the files last applied.
+	stamps map[string]fileStamp
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newRulesDir(path string, interval time.Duration, base config.RulesFragment) *rulesDir {
+	return &rulesDir{
+		path:     path,
+		interval: interval,
+		base:     base,
+		stop:     make(chan struct{}),
+	}
+}
+
+// scan returns the version of the JSON files of the directory.
+func (d *rulesDir) scan() (map[string]fileStamp, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	stamps := make(map[string]fileStamp, len(entries))
+	for _, e := range entries {
+		if !e.Type().IsRegular() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		stamps[e.Name()] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+// changed returns true when stamps differ from the ones last applied.
+func (d *rulesDir) changed(stamps map[string]fileStamp) bool {
+	if d.stamps == nil || len(stamps) != len(d.stamps) {
+		return true
+	}
+	for name, s := range stamps {
+		if prev, ok := d.stamps[name]; !ok || prev != s {
+			return true
+		}
+	}
+	return false
+}
+
+// read reads and parses the given files of the directory. It returns an error
+// when a file can't be read or isn't a valid rules fragment.
+func (d *rulesDir) read(stamps map[string]fileStamp) (rulesDirFiles, error) {
+	files := rulesDirFiles{edits: make(map[string]config.RulesFragment, len(stamps))}
+	for name := range stamps {
+		data, err := os.ReadFile(filepath.Join(d.path, name))
+		if err != nil {
+			return rulesDirFiles{}, err
+		}
+		var f config.RulesFragment
+		if err := json.Unmarshal(data, &f); err != nil {
+			return rulesDirFiles{}, fmt.Errorf("invalid rules file %s: %w", name, err)
+		}
+		if name == rulesDirBaseFile {
+			files.base = &f
+		} else {
+			files.edits[name] = f
+		}
+	}
+	return files, nil
+}
+
+// apply updates the state of the given RulesManager with the content of the
+// directory, replacing the one previously applied.
+func (d *rulesDir) apply(r *config.RulesManager, files rulesDirFiles) {
+	basePath := filepath.Join(d.path, rulesDirBaseFile)
+	if files.base != nil {
+		r.ChangeBase(*files.base, basePath)
+	} else if r.BasePath == basePath {
+		r.ChangeBase(d.base, "")
+	}
+	for path := range r.Edits {
+		if strings.HasPrefix(path, rulesDirEditPrefix) {
+			r.RemoveEdit(path)
+		}
+	}
+	for name, f := range files.edits {
+		r.AddEdit(rulesDirEditPrefix+name, f)
+	}
+}
+
+// startRulesDir applies the rules directory, when configured, and starts
+// watching it for changes.
+func (a *appsec) startRulesDir() {
+	if a.cfg.RulesDir == "" {
+		return
+	}
+	d := newRulesDir(a.cfg.RulesDir, a.cfg.RulesDirPollInterval, a.rulesDirBase)
+	a.rulesDir = d
+	a.reloadRulesDir()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				a.reloadRulesDir()
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopRulesDir stops watching the rules directory and waits for any ongoing
+// reload to complete.
+func (a *appsec) stopRulesDir() {
+	if a.rulesDir == nil {
+		return
+	}
+	close(a.rulesDir.stop)
+	a.rulesDir.wg.Wait()
+	a.rulesDir = nil
+}
+
+// reloadRulesDir applies the content of the rules directory when it changed since
+// it was last applied, and swaps the WAF handle with one using the new rules. The
+// current rules and WAF handle are kept when the directory content is invalid.
+func (a *appsec) reloadRulesDir() {
+	d := a.rulesDir
+	stamps, err := d.scan()
+	if err != nil {
+		log.Error("appsec: rules directory: could not read %s: %v", d.path, err)
+		return
+	}
+	if !d.changed(stamps) {
+		return
+	}
+	// Don't retry reading the files until they change again
+	d.stamps = stamps
+	files, err := d.read(stamps)
+	if err != nil {
+		log.Error("appsec: rules directory: not applying any changes because of error: %v", err)
+		return
+	}
+
+	a.rulesMu.Lock()
+	defer a.rulesMu.Unlock()
+	r := a.cfg.RulesManager.Clone()
+	d.apply(&r, files)
+	r.Compile()
+	log.Debug("appsec: rules directory: final compiled rules: %s", r.String())
+
+	// If an error occurs while updating the WAF handle, keep the current RulesManager and WAF handle
+	if err := a.swapWAF(r.Latest); err != nil {
+		log.Error("appsec: rules directory: could not apply the new security rules: %v", err)
+		return
+	}
+	a.cfg.RulesManager = &r
+	log.Info("appsec: rules directory: security rules updated from %s", d.path)
+}
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016 Datadog, Inc.
+
+package appsec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/config"
+
+	waf "github.com/DataDog/go-libddwaf/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRulesFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func TestRulesDir(t *testing.T) {
+	dir := t.TempDir()
+	r, err := config.NewRulesManeger(nil)
+	require.NoError(t, err)
+	r.AddEdit("asmdata", config.RulesFragment{})
+	d := newRulesDir(dir, time.Second, r.Base)
+
+	load := func(t *testing.T) {
+		t.Helper()
+		stamps, err := d.scan()
+		require.NoError(t, err)
+		require.True(t, d.changed(stamps))
+		d.stamps = stamps
+		files, err := d.read(stamps)
+		require.NoError(t, err)
+		d.apply(r, files)
+	}
+
+	t.Run("edits", func(t *testing.T) {
+		writeRulesFile(t, dir, "exclusions.json", `{"exclusions":[{"id":"exclusion"}]}`)
+		writeRulesFile(t, dir, "actions.json", `{"actions":[{"id":"block","type":"redirect_request","parameters":{"status_code":303,"location":"/blocked"}}]}`)
+		writeRulesFile(t, dir, "README.md", `not a rules file`)
+		load(t)
+		require.Contains(t, r.Edits, "asmdata")
+		require.Contains(t, r.Edits, "file:exclusions.json")
+		require.Contains(t, r.Edits, "file:actions.json")
+		require.Len(t, r.Edits, 3)
+		require.Equal(t, "redirect_request", r.Edits["file:actions.json"].Actions[0].Type)
+
+		stamps, err := d.scan()
+		require.NoError(t, err)
+		require.False(t, d.changed(stamps))
+	})
+
+	t.Run("base", func(t *testing.T) {
+		writeRulesFile(t, dir, "rules.json", `{"version":"2.2","rules":[{"id":"custom"}]}`)
+		load(t)
+		require.Equal(t, filepath.Join(dir, "rules.json"), r.BasePath)
+		require.Len(t, r.Base.Rules, 1)
+		require.NotContains(t, r.Edits, "file:rules.json")
+
+		require.NoError(t, os.Remove(filepath.Join(dir, "rules.json")))
+		load(t)
+		require.Empty(t, r.BasePath)
+		require.Equal(t, d.base, r.Base)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(dir, "exclusions.json")))
+		load(t)
+		require.NotContains(t, r.Edits, "file:exclusions.json")
+		require.Contains(t, r.Edits, "file:actions.json")
+		require.Contains(t, r.Edits, "asmdata")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		writeRulesFile(t, dir, "rules_data.json", `{"rules_data":`)
+		stamps, err := d.scan()
+		require.NoError(t, err)
+		_, err = d.read(stamps)
+		require.ErrorContains(t, err, "rules_data.json")
+	})
+}
+
+func TestRulesDirReload(t *testing.T) {
+	if supported, _ := waf.Health(); !supported {
+		t.Skip("WAF needs to be available for this test")
+	}
+	t.Setenv(config.EnvEnabled, "true")
+	dir := t.TempDir()
+	writeRulesFile(t, dir, "actions.json", `{"actions":[{"id":"block","type":"redirect_request","parameters":{"status_code":303,"location":"/blocked"}}]}`)
+	Start(func(c *config.Config) {
+		c.RulesDir = dir
+		c.RulesDirPollInterval = 10 * time.Millisecond
+	})
+	defer Stop()
+	require.True(t, Enabled())
+
+	actions := func() string {
+		activeAppSec.rulesMu.Lock()
+		defer activeAppSec.rulesMu.Unlock()
+		return activeAppSec.cfg.RulesManager.Edits["file:actions.json"].Actions[0].Type
+	}
+	require.Equal(t, "redirect_request", actions())
+
+	// Invalid rules are not applied and the previous ones are kept
+	writeRulesFile(t, dir, "actions.json", `{"actions":`)
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, "redirect_request", actions())
+
+	writeRulesFile(t, dir, "actions.json", `{"actions":[{"id":"block","type":"block_request","parameters":{"status_code":418,"type":"auto"}}]}`)
+	require.Eventually(t, func() bool { return actions() == "block_request" }, time.Second, 10*time.Millisecond)
+}
+
+func TestRulesDirRestart(t *testing.T) {
+	t.Run("base", func(t *testing.T) {
+		cfg, err := config.NewConfig()
+		require.NoError(t, err)
+		a := newAppSec(cfg)
+		base := cfg.RulesManager.Base
+
+		// The base rules of rules.json, applied while AppSec was started, are not
+		// the ones restored once the file is removed after a restart.
+		cfg.RulesManager.ChangeBase(config.RulesFragment{Version: "2.2", Rules: []interface{}{map[string]interface{}{"id": "custom"}}}, "rules.json")
+		require.Equal(t, base, a.rulesDirBase)
+	})
+
+	t.Run("stop-start", func(t *testing.T) {
+		if supported, _ := waf.Health(); !supported {
+			t.Skip("WAF needs to be available for this test")
+		}
+		t.Setenv(config.EnvEnabled, "true")
+		dir := t.TempDir()
+		writeRulesFile(t, dir, "rules.json", `{"version":"2.2","rules":[{"id":"custom","name":"custom","tags":{"type":"custom","category":"custom"},"conditions":[{"operator":"match_regex","parameters":{"inputs":[{"address":"server.request.uri.raw"}],"regex":"custom"}}]}]}`)
+		Start(func(c *config.Config) {
+			c.RulesDir = dir
+			c.RulesDirPollInterval = 10 * time.Millisecond
+		})
+		defer Stop()
+		require.True(t, Enabled())
+
+		rules := func() (string, int) {
+			activeAppSec.rulesMu.Lock()
+			defer activeAppSec.rulesMu.Unlock()
+			return activeAppSec.cfg.RulesManager.BasePath, len(activeAppSec.cfg.RulesManager.Base.Rules)
+		}
+		path, n := rules()
+		require.Equal(t, filepath.Join(dir, "rules.json"), path)
+		require.Equal(t, 1, n)
+
+		// Restart AppSec, as done when it is deactivated then activated through
+		// remote configuration, then remove rules.json
+		activeAppSec.stop()
+		require.NoError(t, activeAppSec.start(nil))
+		require.NoError(t, os.Remove(filepath.Join(dir, "rules.json")))
+		require.Eventually(t, func() bool {
+			path, n := rules()
+			return path == "" && n == len(activeAppSec.rulesDirBase.Rules) && n > 1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
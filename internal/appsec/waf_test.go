@@ -6,6 +6,8 @@
 package appsec_test
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -15,8 +17,10 @@ import (
 
 	waf "github.com/DataDog/go-libddwaf/v2"
 	pAppsec "gopkg.in/DataDog/dd-trace-go.v1/appsec"
+	"gopkg.in/DataDog/dd-trace-go.v1/appsec/events"
 	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec"
 	"gopkg.in/DataDog/dd-trace-go.v1/internal/appsec/config"
 
@@ -429,6 +433,172 @@ func TestBlocking(t *testing.T) {
 	}
 }
 
+// Test that request bodies automatically parsed by the net/http integration are monitored
+func TestBodyParsing(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "testdata/blocking.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+
+	var body string
+	mux := httptrace.NewServeMux(httptrace.WithAppSecBodyParsing(1024))
+	mux.HandleFunc("/body", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{name: "json/no-block", contentType: "application/json", body: `{"a":"value"}`, status: 200},
+		{name: "json/block", contentType: "application/json", body: `{"a":["$globals"]}`, status: 403},
+		{name: "form/block", contentType: "application/x-www-form-urlencoded", body: "a=$globals", status: 403},
+		{name: "xml/block", contentType: "application/xml", body: "<a><b>$globals</b></a>", status: 403},
+		{name: "unsupported/no-block", contentType: "text/plain", body: "$globals", status: 200},
+		{name: "too-large/no-block", contentType: "application/json", body: `{"a":"$globals","b":"` + strings.Repeat("b", 1024) + `"}`, status: 200},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			body = ""
+			res, err := srv.Client().Post(srv.URL+"/body", tc.contentType, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			require.Equal(t, tc.status, res.StatusCode)
+			if tc.status == 200 {
+				// the handler still reads the whole body
+				require.Equal(t, tc.body, body)
+			} else {
+				require.Empty(t, body)
+				spans := mt.FinishedSpans()
+				require.Len(t, spans, 1)
+				require.Contains(t, spans[0].Tag("_dd.appsec.json"), "crs-933-130-block")
+			}
+		})
+	}
+}
+
+// Test that outbound HTTP requests detected as SSRF exploits are aborted, along with the request being served
+func TestRASP(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "testdata/rasp.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+
+	client := httptrace.WrapClient(&http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: http.NoBody}, nil
+	})})
+	var roundTripErr error
+	mux := httptrace.NewServeMux()
+	mux.HandleFunc("/ssrf", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), "GET", r.URL.Query().Get("url"), nil)
+		res, err := client.Do(req)
+		if roundTripErr = err; err != nil {
+			return
+		}
+		res.Body.Close()
+		w.Write([]byte("Hello World!\n"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name    string
+		url     string
+		blocked bool
+	}{
+		{name: "no-block", url: "https://example.com"},
+		{name: "block", url: "http://169.254.169.254/latest/meta-data", blocked: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mt := mocktracer.Start()
+			defer mt.Stop()
+			res, err := srv.Client().Get(srv.URL + "/ssrf?url=" + url.QueryEscape(tc.url))
+			require.NoError(t, err)
+			defer res.Body.Close()
+			if !tc.blocked {
+				require.NoError(t, roundTripErr)
+				require.Equal(t, 200, res.StatusCode)
+				return
+			}
+			var blocked *events.BlockingSecurityEvent
+			require.True(t, errors.As(roundTripErr, &blocked))
+			require.Equal(t, 403, res.StatusCode)
+			var found bool
+			for _, s := range mt.FinishedSpans() {
+				if event, ok := s.Tag("_dd.appsec.json").(string); ok {
+					found = found || strings.Contains(event, "rasp-934-100")
+				}
+			}
+			require.True(t, found)
+		})
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	t.Setenv("DD_APPSEC_RULES", "testdata/custom_addresses.json")
+	appsec.Start()
+	defer appsec.Stop()
+	if !appsec.Enabled() {
+		t.Skip("AppSec needs to be enabled for this test")
+	}
+
+	t.Run("no-request", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		span, ctx := tracer.StartSpanFromContext(context.Background(), "queue.consume")
+		require.NoError(t, pAppsec.Monitor(ctx, map[string]any{"custom.message": map[string]any{"body": "hello"}}))
+		err := pAppsec.Monitor(ctx, map[string]any{"custom.message": map[string]any{"body": "attack"}})
+		span.Finish()
+
+		var blocked *events.BlockingSecurityEvent
+		require.True(t, errors.As(err, &blocked))
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		require.Contains(t, spans[0].Tag("_dd.appsec.json"), "custom-001")
+	})
+
+	t.Run("request", func(t *testing.T) {
+		mt := mocktracer.Start()
+		defer mt.Stop()
+		var monitorErr error
+		mux := httptrace.NewServeMux()
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if monitorErr = pAppsec.Monitor(r.Context(), map[string]any{"custom.message": map[string]any{"body": r.URL.Query().Get("message")}}); monitorErr != nil {
+				return
+			}
+			w.Write([]byte("Hello World!\n"))
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		res, err := srv.Client().Get(srv.URL + "/?message=attack")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		var blocked *events.BlockingSecurityEvent
+		require.True(t, errors.As(monitorErr, &blocked))
+		require.Equal(t, 403, res.StatusCode)
+		spans := mt.FinishedSpans()
+		require.Len(t, spans, 1)
+		require.Contains(t, spans[0].Tag("_dd.appsec.json"), "custom-001")
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
 // Test that API Security schemas get collected when API security is enabled
 func TestAPISecurity(t *testing.T) {
 	// Start and trace an HTTP server
@@ -26,8 +26,14 @@ type config struct {
 	mu            sync.RWMutex
 	analyticsRate float64
 	serviceName   string
+	env           string
+	version       string
 	runtimeID     string
 	headersAsTags *internal.LockMap
+
+	// logsInjectionDisabled is false by default, so that logs are correlated to
+	// traces unless disabled.
+	logsInjectionDisabled bool
 }
 
 // AnalyticsRate returns the sampling rate at which events should be marked. It uses
@@ -60,6 +66,50 @@ func SetServiceName(name string) {
 	cfg.serviceName = name
 }
 
+// Env returns the environment set for this application.
+func Env() string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return cfg.env
+}
+
+// SetEnv sets the global environment set for this application.
+func SetEnv(env string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.env = env
+}
+
+// LogsInjection reports whether log correlation integrations should add trace
+// information to log records.
+func LogsInjection() bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return !cfg.logsInjectionDisabled
+}
+
+// SetLogsInjection sets whether log correlation integrations should add trace
+// information to log records.
+func SetLogsInjection(enabled bool) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.logsInjectionDisabled = !enabled
+}
+
+// Version returns the version set for this application.
+func Version() string {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+	return cfg.version
+}
+
+// SetVersion sets the global version set for this application.
+func SetVersion(version string) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.version = version
+}
+
 // RuntimeID returns this process's unique runtime id.
 func RuntimeID() string {
 	cfg.mu.RLock()
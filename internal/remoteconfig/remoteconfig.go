@@ -70,6 +70,34 @@ const (
 	APMTracingHTTPHeaderTags
 	// APMTracingCustomTags enables APM client to set custom tags on all spans
 	APMTracingCustomTags
+	// ASMProcessorOverrides represents the capability for ASM to override the WAF processors
+	ASMProcessorOverrides
+	// ASMCustomDataScanners represents the capability for ASM to use user-defined WAF data scanners
+	ASMCustomDataScanners
+	// ASMExclusionData represents the capability for ASM to receive exclusion data
+	ASMExclusionData
+	// APMTracingEnabled enables APM client libraries to enable or disable tracing
+	APMTracingEnabled
+	// APMTracingDataStreamsEnabled enables APM client libraries to enable or disable data streams monitoring
+	APMTracingDataStreamsEnabled
+	// ASMRASPSQLI represents the capability for ASM to protect against SQL injection at runtime
+	ASMRASPSQLI
+	// ASMRASPLFI represents the capability for ASM to protect against local file inclusion at runtime
+	ASMRASPLFI
+	// ASMRASPSSRF represents the capability for ASM to protect against server-side request forgery at runtime
+	ASMRASPSSRF
+	// ASMRASPSHI represents the capability for ASM to protect against shell injection at runtime
+	ASMRASPSHI
+	// ASMRASPXXE represents the capability for ASM to protect against XML external entities at runtime
+	ASMRASPXXE
+	// ASMRASPRCE represents the capability for ASM to protect against remote code execution at runtime
+	ASMRASPRCE
+	// ASMRASPNOSQLI represents the capability for ASM to protect against NoSQL injection at runtime
+	ASMRASPNOSQLI
+	// ASMRASPXSS represents the capability for ASM to protect against cross-site scripting at runtime
+	ASMRASPXSS
+	// APMTracingSampleRules enables APM client libraries to set trace and single span sampling rules
+	APMTracingSampleRules
 )
 
 // ErrClientNotStarted is returned when the remote config client is not started.
@@ -54,7 +54,8 @@ func SetAgentlessEndpoint(endpoint string) string {
 }
 
 // Sanitize ensures the configuration values are valid and compatible.
-// It removes NaN and Inf values and converts string slices and maps into comma-separated strings.
+// It removes NaN and Inf values, converts string slices and maps into comma-separated strings
+// and other fmt.Stringer values into strings.
 func Sanitize(c Configuration) Configuration {
 	switch val := c.Value.(type) {
 	case float64:
@@ -85,6 +86,9 @@ func Sanitize(c Configuration) Configuration {
 			sb.WriteString(fmt.Sprint(val[k]))
 		}
 		c.Value = sb.String()
+	case fmt.Stringer:
+		// The telemetry API only supports primitive types.
+		c.Value = val.String()
 	}
 	return c
 }